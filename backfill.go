@@ -0,0 +1,195 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"parsing_media/parsers"
+	"parsing_media/rudate"
+	. "parsing_media/utils"
+	"strings"
+	"time"
+)
+
+// backfillDateLayout - формат дат --from/--to и дней в контрольной точке
+const backfillDateLayout = "2006-01-02"
+
+// backfillCheckpoint - состояние backfill, сохраняемое после каждой обработанной страницы архива.
+// При повторном запуске с тем же сайтом и диапазоном обход продолжается с Day/Page.
+type backfillCheckpoint struct {
+	Site      string    `json:"site"`
+	From      string    `json:"from"`
+	To        string    `json:"to"`
+	Day       string    `json:"day"`  // день, обработка которого не завершена
+	Page      int       `json:"page"` // первая необработанная страница этого дня
+	Links     int       `json:"links"`
+	Saved     int       `json:"saved"` // новые статьи и редакции за все запуски
+	Failed    int       `json:"failed"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// backfillOptions - параметры команды backfill
+type backfillOptions struct {
+	From, To       time.Time
+	MaxPages       int // предел страниц архива за один день
+	CheckpointPath string
+	Restart        bool // начать диапазон заново, не читая контрольную точку
+}
+
+// loadCheckpoint читает контрольную точку. Отсутствие файла - не ошибка: тогда возвращается nil.
+func loadCheckpoint(path string) (*backfillCheckpoint, error) {
+	raw, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("чтение контрольной точки %s: %w", path, err)
+	}
+	var checkpoint backfillCheckpoint
+	if err := json.Unmarshal(raw, &checkpoint); err != nil {
+		return nil, fmt.Errorf("разбор контрольной точки %s: %w", path, err)
+	}
+	return &checkpoint, nil
+}
+
+// runBackfill обходит архив сайта по дням от opts.From до opts.To включительно и сохраняет найденные статьи.
+// Страницы архива за день читаются по порядку, пока не закончатся ссылки или не будет достигнут opts.MaxPages.
+// После каждой страницы контрольная точка перезаписывается, поэтому прерванный обход продолжается с неё,
+// а статьи, уже сохранённые в БД, повторно не загружаются (если не включён cfg.Parsers.ForceRefetch).
+// Если страница не обработана целиком за parsers.timeout, обход останавливается с ошибкой, не сдвигая контрольную точку.
+func runBackfill(ctx context.Context, p parsers.Parser, archive parsers.Archive, opts backfillOptions) (*backfillCheckpoint, error) {
+	tag := strings.ToUpper(p.Name())
+	checkpoint := &backfillCheckpoint{
+		Site: p.Name(),
+		From: opts.From.Format(backfillDateLayout),
+		To:   opts.To.Format(backfillDateLayout),
+		Day:  opts.From.Format(backfillDateLayout),
+		Page: 1,
+	}
+
+	if !opts.Restart {
+		saved, err := loadCheckpoint(opts.CheckpointPath)
+		if err != nil {
+			return nil, err
+		}
+		switch {
+		case saved == nil:
+		case saved.Site != checkpoint.Site || saved.From != checkpoint.From || saved.To != checkpoint.To:
+			fmt.Printf("%s[%s]%s[WARNING] Контрольная точка %s относится к другому обходу (%s, %s..%s) и будет перезаписана%s\n",
+				ColorBlue, tag, ColorYellow, opts.CheckpointPath, saved.Site, saved.From, saved.To, ColorReset)
+		default:
+			checkpoint = saved
+			fmt.Printf("%s[%s]%s[INFO] Продолжение с %s, страница %d (ранее сохранено статей: %d)%s\n",
+				ColorBlue, tag, ColorYellow, checkpoint.Day, checkpoint.Page, checkpoint.Saved, ColorReset)
+		}
+	}
+
+	day, err := time.ParseInLocation(backfillDateLayout, checkpoint.Day, opts.From.Location())
+	if err != nil {
+		return checkpoint, fmt.Errorf("неверный день в контрольной точке '%s': %w", checkpoint.Day, err)
+	}
+	if day.After(opts.To) {
+		fmt.Printf("%s[%s]%s[INFO] Диапазон уже обработан (см. %s). Для повторного обхода укажите --restart%s\n", ColorBlue, tag, ColorYellow, opts.CheckpointPath, ColorReset)
+	}
+
+	var runOpts parsers.RunOptions
+	if !cfg.Parsers.ForceRefetch {
+		runOpts.Filter = knownURLs
+	}
+
+	for ; !day.After(opts.To); day = day.AddDate(0, 0, 1) {
+		// Сайты, которые не знают номер страницы, отдают первую: такие повторы завершают день
+		seen := make(map[string]bool)
+		for page := checkpoint.Page; page <= opts.MaxPages; page++ {
+			links, err := archive.ArchiveLinks(ctx, day, page)
+			if err != nil {
+				return checkpoint, fmt.Errorf("архив за %s, страница %d: %w", day.Format(backfillDateLayout), page, err)
+			}
+			var fresh []parsers.LinkItem
+			for _, link := range links {
+				if !seen[link.Href] {
+					seen[link.Href] = true
+					fresh = append(fresh, link)
+				}
+			}
+			if len(fresh) == 0 {
+				break
+			}
+
+			fmt.Printf("%s[%s]%s[INFO] Архив за %s, страница %d: ссылок %d%s\n", ColorBlue, tag, ColorYellow, day.Format(backfillDateLayout), page, len(fresh), ColorReset)
+			runCtx, cancel := context.WithTimeout(ctx, cfg.Parsers.Timeout)
+			result := parsers.RunLinks(runCtx, p, fresh, runOpts)
+			interrupted := runCtx.Err() != nil || result.Report.Cancelled > 0
+			cancel()
+			saved, err := saveArticles(ctx, tag, result.Articles)
+			parsers.PrintReport(result)
+			if err != nil {
+				return checkpoint, err
+			}
+			if ctx.Err() != nil {
+				// Страница обработана не полностью: при продолжении она загрузится снова
+				return checkpoint, ctx.Err()
+			}
+			if interrupted {
+				// Статьи страницы, до которых не дошла очередь, не должны потеряться: контрольная точка остаётся
+				// на этой странице, а при продолжении уже сохранённые статьи отсеет фильтр ссылок
+				checkpoint.Saved += len(saved.Inserted) + len(saved.Revised)
+				if err := saveCheckpoint(opts.CheckpointPath, checkpoint); err != nil {
+					return checkpoint, err
+				}
+				return checkpoint, fmt.Errorf("архив за %s, страница %d: статьи не загружены за parsers.timeout (%s), не обработано %d; повторный запуск продолжит с этой страницы",
+					day.Format(backfillDateLayout), page, cfg.Parsers.Timeout, result.Report.Cancelled)
+			}
+
+			checkpoint.Page = page + 1
+			checkpoint.Links += len(fresh)
+			checkpoint.Saved += len(saved.Inserted) + len(saved.Revised)
+			checkpoint.Failed += len(result.Report.Failed) + len(saved.Failed)
+			if err := saveCheckpoint(opts.CheckpointPath, checkpoint); err != nil {
+				return checkpoint, err
+			}
+		}
+
+		checkpoint.Day = day.AddDate(0, 0, 1).Format(backfillDateLayout)
+		checkpoint.Page = 1
+		if err := saveCheckpoint(opts.CheckpointPath, checkpoint); err != nil {
+			return checkpoint, err
+		}
+	}
+	return checkpoint, nil
+}
+
+// saveCheckpoint перезаписывает контрольную точку backfill
+func saveCheckpoint(path string, checkpoint *backfillCheckpoint) error {
+	checkpoint.UpdatedAt = time.Now()
+	if err := writeJSONFile(path, checkpoint); err != nil {
+		return fmt.Errorf("запись контрольной точки %s: %w", path, err)
+	}
+	return nil
+}
+
+// parseBackfillDate разбирает дату --from/--to в московском времени
+func parseBackfillDate(name, value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, fmt.Errorf("не указан --%s (формат %s)", name, backfillDateLayout)
+	}
+	day, err := time.ParseInLocation(backfillDateLayout, value, rudate.Moscow)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("неверная дата --%s '%s' (формат %s)", name, value, backfillDateLayout)
+	}
+	return day, nil
+}
+
+// archiveSites возвращает имена парсеров, сайты которых поддерживают backfill
+func archiveSites() []string {
+	var names []string
+	for _, p := range parsers.All() {
+		if _, ok := parsers.ArchiveOf(p); ok {
+			names = append(names, p.Name())
+		}
+	}
+	return names
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"parsing_media/parsers"
+	"parsing_media/rudate"
+	"parsing_media/storage"
+	. "parsing_media/utils"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// stubArchive отдаёт на первой странице каждого дня одни и те же ссылки, дальше страниц нет
+type stubArchive struct {
+	links []parsers.LinkItem
+}
+
+func (a stubArchive) ArchiveLinks(_ context.Context, _ time.Time, page int) ([]parsers.LinkItem, error) {
+	if page > 1 {
+		return nil, nil
+	}
+	return a.links, nil
+}
+
+// stubParser разбирает статью сразу, а статью slowHref - пока не отменят ctx, если slow включён
+type stubParser struct {
+	slowHref string
+	slow     atomic.Bool
+}
+
+func (p *stubParser) Name() string                                      { return "stub" }
+func (p *stubParser) SiteURL() string                                   { return "https://stub.test" }
+func (p *stubParser) Workers() int                                      { return 1 }
+func (p *stubParser) Links(context.Context) ([]parsers.LinkItem, error) { return nil, nil }
+
+func (p *stubParser) ParsePage(ctx context.Context, item parsers.LinkItem) PageResult {
+	if item.Href == p.slowHref && p.slow.Load() {
+		<-ctx.Done()
+		return PageResult{PageURL: item.Href, Error: ctx.Err()}
+	}
+	data := Data{Site: p.SiteURL(), Href: item.Href, Title: "Статья " + item.Href, Body: "Текст", Date: time.Date(2025, time.March, 1, 12, 0, 0, 0, rudate.Moscow)}
+	hash, err := data.Hashing()
+	if err != nil {
+		return PageResult{PageURL: item.Href, Error: err}
+	}
+	data.Hash = hash
+	return PageResult{PageURL: item.Href, Data: data}
+}
+
+func TestBackfillKeepsTimedOutPage(t *testing.T) {
+	savedCfg, savedStore, savedKnown := cfg, store, knownURLs
+	t.Cleanup(func() { cfg, store, knownURLs = savedCfg, savedStore, savedKnown })
+	cfg = DefaultConfig()
+	cfg.Parsers.Timeout = 100 * time.Millisecond
+	memory := storage.NewMemory()
+	store = memory
+	knownURLs = storage.NewKnownURLs(memory, 100)
+
+	var links []parsers.LinkItem
+	for i := 1; i <= 3; i++ {
+		links = append(links, parsers.LinkItem{Href: fmt.Sprintf("https://stub.test/news/%d", i)})
+	}
+	// Один обработчик: первая статья сохраняется, вторая ждёт таймаута, третья остаётся в очереди
+	p := &stubParser{slowHref: links[1].Href}
+	p.slow.Store(true)
+	day := time.Date(2025, time.March, 1, 0, 0, 0, 0, rudate.Moscow)
+	opts := backfillOptions{From: day, To: day, MaxPages: 5, CheckpointPath: filepath.Join(t.TempDir(), "backfill.json")}
+
+	checkpoint, err := runBackfill(context.Background(), p, stubArchive{links}, opts)
+	if err == nil {
+		t.Fatal("страница, не обработанная за parsers.timeout, должна завершать обход ошибкой")
+	}
+	if checkpoint.Day != "2025-03-01" || checkpoint.Page != 1 {
+		t.Errorf("контрольная точка перешла на %s, страница %d; want 2025-03-01, страница 1", checkpoint.Day, checkpoint.Page)
+	}
+	if saved := len(memory.Articles()); saved != 1 {
+		t.Errorf("после таймаута сохранено статей %d, want 1", saved)
+	}
+
+	p.slow.Store(false)
+	checkpoint, err = runBackfill(context.Background(), p, stubArchive{links}, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if checkpoint.Day != "2025-03-02" || checkpoint.Page != 1 {
+		t.Errorf("после продолжения контрольная точка на %s, страница %d; want 2025-03-02, страница 1", checkpoint.Day, checkpoint.Page)
+	}
+	if saved := len(memory.Articles()); saved != len(links) {
+		t.Errorf("после продолжения сохранено статей %d, want %d", saved, len(links))
+	}
+	if checkpoint.Saved != len(links) {
+		t.Errorf("checkpoint.Saved = %d, want %d", checkpoint.Saved, len(links))
+	}
+}
@@ -0,0 +1,589 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"net/url"
+	"os"
+	"parsing_media/parsers"
+	"parsing_media/storage"
+	. "parsing_media/utils"
+	"strings"
+	"time"
+)
+
+const usageText = `Использование: parsing_media [--config=config.yaml] <команда> [параметры]
+
+Команды:
+  menu                          интерактивное меню (по умолчанию, если команда не указана)
+  run [--sites=ria,rbc] [--force]
+                                один проход по выбранным (или всем) сайтам
+  loop [--sites=...] [--interval=3m] [--force] [--recrawl]
+                                запуск по расписаниям сайтов до SIGINT/SIGTERM
+                                (--interval - для сайтов без своего расписания в loop.schedules)
+                                --force загружает статьи, даже если их ссылки уже есть в БД
+                                --recrawl добавляет повторную проверку недавних статей (recrawl)
+  list                          список доступных парсеров
+  parse-url [--site=RIA] <url>  разобрать одну статью и вывести результат без сохранения
+  recrawl                       один проход повторной проверки недавних статей (правки и удаления)
+  backfill --site=lenta --from=2024-01-01 --to=2024-03-31 [--max-pages=50] [--checkpoint=файл]
+           [--restart] [--force]
+                                загрузка статей за прошедшие дни из архива сайта; после каждой
+                                страницы архива пишется контрольная точка (по умолчанию
+                                backfill_<сайт>.json), прерванный обход продолжается с неё
+  history [--diff] [--from=N] [--to=M] <url>
+                                редакции сохранённой статьи; --diff - отличия редакции N от M
+                                (по умолчанию предпоследней от последней)
+  migrate up|down [--steps=1]|status
+                                применить, откатить или показать миграции схемы (postgres, sqlite)
+  retag                         заново связать сохранённые статьи с нормализованными тегами и
+                                категориями таксономии (storage.taxonomy_file; postgres, sqlite)
+
+Конфигурация читается из --config, PARSING_MEDIA_CONFIG или config.yaml (если есть),
+затем переопределяется переменными окружения PARSING_MEDIA_* (см. config.example.yaml).
+
+Коды завершения: 0 - успех (и штатная остановка loop), 1 - ошибка парсинга или БД,
+2 - неверные аргументы, 130 - run/parse-url/backfill прерваны сигналом.
+`
+
+// runCommand разбирает аргументы командной строки и выполняет подкоманду. Возвращает код завершения.
+func runCommand(ctx context.Context, args []string) int {
+	globalFlags := newFlagSet("parsing_media")
+	configPath := globalFlags.String("config", os.Getenv("PARSING_MEDIA_CONFIG"), "путь к файлу конфигурации")
+	if code, ok := parseFlags(globalFlags, args); !ok {
+		return code
+	}
+	args = globalFlags.Args()
+
+	loaded, err := LoadConfig(*configPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s[FATAL] %v%s\n", ColorRed, err, ColorReset)
+		return exitUsage
+	}
+	cfg = loaded
+
+	command := "menu"
+	if len(args) > 0 {
+		command, args = args[0], args[1:]
+	}
+
+	switch command {
+	case "menu":
+		return cmdMenu(ctx)
+	case "run":
+		return cmdRun(ctx, args)
+	case "loop":
+		return cmdLoop(ctx, args)
+	case "list":
+		return cmdList(args)
+	case "parse-url":
+		return cmdParseURL(ctx, args)
+	case "recrawl":
+		return cmdRecrawl(ctx, args)
+	case "backfill":
+		return cmdBackfill(ctx, args)
+	case "history":
+		return cmdHistory(ctx, args)
+	case "migrate":
+		return cmdMigrate(ctx, args)
+	case "retag":
+		return cmdRetag(ctx, args)
+	case "help", "-h", "-help", "--help":
+		fmt.Print(usageText)
+		return exitOK
+	default:
+		fmt.Fprintf(os.Stderr, "%s[ERROR] Неизвестная команда '%s'%s\n\n%s", ColorRed, command, ColorReset, usageText)
+		return exitUsage
+	}
+}
+
+// newFlagSet создаёт набор флагов подкоманды, печатающий общую справку при ошибке
+func newFlagSet(name string) *flag.FlagSet {
+	flags := flag.NewFlagSet(name, flag.ContinueOnError)
+	flags.Usage = func() {
+		fmt.Fprint(os.Stderr, usageText)
+	}
+	return flags
+}
+
+// parseFlags разбирает флаги подкоманды. ok=false означает, что нужно сразу выйти с кодом code.
+func parseFlags(flags *flag.FlagSet, args []string) (code int, ok bool) {
+	if err := flags.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return exitOK, false
+		}
+		return exitUsage, false
+	}
+	return exitOK, true
+}
+
+// selectParsers возвращает парсеры из списка имён через запятую. Пустой список означает все парсеры.
+func selectParsers(sites string) ([]parsers.Parser, error) {
+	if strings.TrimSpace(sites) == "" {
+		return parsers.All(), nil
+	}
+
+	var selected []parsers.Parser
+	var unknown []string
+	for _, name := range strings.Split(sites, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		p, ok := parsers.Find(name)
+		if !ok {
+			unknown = append(unknown, name)
+			continue
+		}
+		selected = append(selected, p)
+	}
+	if len(unknown) > 0 {
+		return nil, fmt.Errorf("неизвестные сайты: %s (см. команду list)", strings.Join(unknown, ", "))
+	}
+	if len(selected) == 0 {
+		return nil, fmt.Errorf("не указано ни одного сайта")
+	}
+	return selected, nil
+}
+
+// findParserByURL подбирает парсер по домену ссылки (с учётом поддоменов)
+func findParserByURL(pageURL string) (parsers.Parser, bool) {
+	u, err := url.Parse(pageURL)
+	if err != nil {
+		return nil, false
+	}
+	host := strings.TrimPrefix(strings.ToLower(u.Hostname()), "www.")
+
+	for _, p := range parsers.All() {
+		siteURL, err := url.Parse(p.SiteURL())
+		if err != nil {
+			continue
+		}
+		siteHost := strings.TrimPrefix(strings.ToLower(siteURL.Hostname()), "www.")
+		if host == siteHost || strings.HasSuffix(host, "."+siteHost) {
+			return p, true
+		}
+	}
+	return nil, false
+}
+
+func cmdMenu(ctx context.Context) int {
+	loadParsers()
+	if err := openStorage(ctx); err != nil {
+		return exitFailure
+	}
+	defer closeStorage()
+	return runMenu(ctx)
+}
+
+func cmdRun(ctx context.Context, args []string) int {
+	flags := newFlagSet("run")
+	sites := flags.String("sites", "", "список сайтов через запятую (по умолчанию все)")
+	force := flags.Bool("force", cfg.Parsers.ForceRefetch, "загружать статьи, уже сохранённые в БД")
+	if code, ok := parseFlags(flags, args); !ok {
+		return code
+	}
+
+	cfg.Parsers.ForceRefetch = *force
+	loadParsers()
+	parserList, err := selectParsers(*sites)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s[ERROR] %v%s\n", ColorRed, err, ColorReset)
+		return exitUsage
+	}
+	if err := openStorage(ctx); err != nil {
+		return exitFailure
+	}
+	defer closeStorage()
+
+	results := runParsers(ctx, parserList)
+	if ctx.Err() != nil {
+		return exitInterrupted
+	}
+
+	failed := 0
+	for _, r := range results {
+		if !resultSucceeded(r) {
+			failed++
+		}
+	}
+	if failed > 0 {
+		fmt.Printf("%s[ERROR] Парсеров, завершившихся с ошибкой или без данных: %d из %d%s\n", ColorRed, failed, len(results), ColorReset)
+		return exitFailure
+	}
+	return exitOK
+}
+
+func cmdLoop(ctx context.Context, args []string) int {
+	flags := newFlagSet("loop")
+	sites := flags.String("sites", "", "список сайтов через запятую (по умолчанию все)")
+	force := flags.Bool("force", cfg.Parsers.ForceRefetch, "загружать статьи, уже сохранённые в БД")
+	interval := flags.Duration("interval", cfg.Loop.Interval, "период запуска сайтов без собственного расписания")
+	recrawl := flags.Bool("recrawl", cfg.Recrawl.Enabled, "повторно проверять недавние статьи (см. recrawl в конфигурации)")
+	if code, ok := parseFlags(flags, args); !ok {
+		return code
+	}
+	if *interval <= 0 {
+		fmt.Fprintf(os.Stderr, "%s[ERROR] Интервал должен быть положительным: %v%s\n", ColorRed, *interval, ColorReset)
+		return exitUsage
+	}
+
+	cfg.Parsers.ForceRefetch = *force
+	cfg.Recrawl.Enabled = *recrawl
+	loadParsers()
+	parserList, err := selectParsers(*sites)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s[ERROR] %v%s\n", ColorRed, err, ColorReset)
+		return exitUsage
+	}
+	cfg.Loop.Interval = *interval
+	jobs, err := buildSchedules(parserList)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s[ERROR] %v%s\n", ColorRed, err, ColorReset)
+		return exitUsage
+	}
+	if err := openStorage(ctx); err != nil {
+		return exitFailure
+	}
+	defer closeStorage()
+
+	fmt.Printf("%s[INFO] Запуск %d парсеров по расписанию. Остановка - SIGINT/SIGTERM.%s\n", ColorBlue, len(jobs), ColorReset)
+	runScheduler(ctx, jobs)
+	fmt.Printf("\n%s[INFO] Цикл парсинга завершен.%s\n", ColorBlue, ColorReset)
+	return exitOK // цикл останавливается только сигналом, это штатное завершение
+}
+
+func cmdList(args []string) int {
+	flags := newFlagSet("list")
+	if code, ok := parseFlags(flags, args); !ok {
+		return code
+	}
+
+	loadParsers()
+	for _, p := range parsers.All() {
+		fmt.Printf("%-15s %-30s потоков: %d\n", p.Name(), p.SiteURL(), p.Workers())
+	}
+	return exitOK
+}
+
+func cmdParseURL(ctx context.Context, args []string) int {
+	flags := newFlagSet("parse-url")
+	site := flags.String("site", "", "имя парсера (по умолчанию определяется по домену ссылки)")
+	if code, ok := parseFlags(flags, args); !ok {
+		return code
+	}
+	if flags.NArg() != 1 {
+		fmt.Fprintf(os.Stderr, "%s[ERROR] Укажите ровно одну ссылку на статью%s\n\n%s", ColorRed, ColorReset, usageText)
+		return exitUsage
+	}
+	pageURL := flags.Arg(0)
+
+	loadParsers()
+	var p parsers.Parser
+	var ok bool
+	if *site != "" {
+		p, ok = parsers.Find(*site)
+	} else {
+		p, ok = findParserByURL(pageURL)
+	}
+	if !ok {
+		fmt.Fprintf(os.Stderr, "%s[ERROR] Не найден парсер для %s (укажите --site)%s\n", ColorRed, pageURL, ColorReset)
+		return exitUsage
+	}
+
+	runCtx, cancel := context.WithTimeout(ctx, cfg.Parsers.Timeout)
+	defer cancel()
+	startTime := time.Now()
+	result := p.ParsePage(runCtx, parsers.LinkItem{Href: pageURL})
+	tag := strings.ToUpper(p.Name())
+
+	if result.Error != nil {
+		fmt.Printf("%s[%s]%s[ERROR] %s: %v%s\n", ColorBlue, tag, ColorRed, pageURL, result.Error, ColorReset)
+		if ctx.Err() != nil {
+			return exitInterrupted
+		}
+		return exitFailure
+	}
+	if result.IsEmpty {
+		fmt.Printf("%s[%s]%s[WARNING] %s: нет данных: %s%s\n", ColorBlue, tag, ColorYellow, pageURL, strings.Join(result.Reasons, ", "), ColorReset)
+		return exitFailure
+	}
+
+	item := result.Data
+	fmt.Printf("%s[%s]%s[INFO] Статья разобрана за %s%s\n", ColorBlue, tag, ColorGreen, FormatDuration(time.Since(startTime)), ColorReset)
+	fmt.Printf("Сайт:   %s\nСсылка: %s\nХеш:    %s\nДата:   %s\nТеги:   %s\n",
+		item.Site, item.Href, item.Hash, item.Date.Format(time.RFC3339), strings.Join(item.Tags, ", "))
+	if !item.Modified.IsZero() {
+		fmt.Printf("Изменено: %s\n", item.Modified.Format(time.RFC3339))
+	}
+	for _, field := range []struct{ name, value string }{
+		{"Авторы", strings.Join(item.Authors, ", ")},
+		{"Рубрика", item.Rubric},
+		{"Иллюстрация", item.Image},
+		{"Вводка", item.Lead},
+	} {
+		if field.value != "" {
+			fmt.Printf("%s: %s\n", field.name, field.value)
+		}
+	}
+	result.Cleaning.Print(tag)
+	if item.BodyFallback {
+		fmt.Printf("%s[%s]%s[WARNING] Селекторы текста не сработали, текст извлечён резервным алгоритмом%s\n", ColorBlue, tag, ColorYellow, ColorReset)
+	}
+	fmt.Printf("Заголовок: %s\n\n%s\n", item.Title, item.Body)
+	return exitOK
+}
+
+func cmdRecrawl(ctx context.Context, args []string) int {
+	flags := newFlagSet("recrawl")
+	if code, ok := parseFlags(flags, args); !ok {
+		return code
+	}
+
+	loadParsers()
+	if err := openStorage(ctx); err != nil {
+		return exitFailure
+	}
+	defer closeStorage()
+
+	stats, err := recrawlOnce(ctx)
+	switch {
+	case ctx.Err() != nil:
+		return exitInterrupted
+	case err != nil:
+		fmt.Printf("%s[RECRAWL]%s[ERROR] %v%s\n", ColorBlue, ColorRed, err, ColorReset)
+		return exitFailure
+	case stats.Failed > 0:
+		return exitFailure
+	}
+	return exitOK
+}
+
+func cmdBackfill(ctx context.Context, args []string) int {
+	flags := newFlagSet("backfill")
+	site := flags.String("site", "", "сайт, архив которого нужно обойти")
+	from := flags.String("from", "", "первый день диапазона (ГГГГ-ММ-ДД)")
+	to := flags.String("to", "", "последний день диапазона (ГГГГ-ММ-ДД)")
+	maxPages := flags.Int("max-pages", 50, "предел страниц архива за один день")
+	checkpointPath := flags.String("checkpoint", "", "файл контрольной точки (по умолчанию backfill_<сайт>.json)")
+	restart := flags.Bool("restart", false, "начать диапазон заново, не продолжая с контрольной точки")
+	force := flags.Bool("force", cfg.Parsers.ForceRefetch, "загружать статьи, уже сохранённые в БД")
+	if code, ok := parseFlags(flags, args); !ok {
+		return code
+	}
+
+	opts := backfillOptions{MaxPages: *maxPages, CheckpointPath: *checkpointPath, Restart: *restart}
+	var err error
+	if opts.From, err = parseBackfillDate("from", *from); err == nil {
+		opts.To, err = parseBackfillDate("to", *to)
+	}
+	if err == nil && opts.To.Before(opts.From) {
+		err = fmt.Errorf("--to (%s) раньше --from (%s)", *to, *from)
+	}
+	if err == nil && opts.MaxPages < 1 {
+		err = fmt.Errorf("--max-pages должен быть не меньше 1")
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s[ERROR] %v%s\n", ColorRed, err, ColorReset)
+		return exitUsage
+	}
+
+	cfg.Parsers.ForceRefetch = *force
+	loadParsers()
+	p, ok := parsers.Find(*site)
+	if !ok {
+		fmt.Fprintf(os.Stderr, "%s[ERROR] Неизвестный сайт '%s' (см. команду list)%s\n", ColorRed, *site, ColorReset)
+		return exitUsage
+	}
+	archive, ok := parsers.ArchiveOf(p)
+	if !ok {
+		fmt.Fprintf(os.Stderr, "%s[ERROR] У парсера %s нет архива по датам. Поддерживают backfill: %s%s\n", ColorRed, p.Name(), strings.Join(archiveSites(), ", "), ColorReset)
+		return exitUsage
+	}
+	if opts.CheckpointPath == "" {
+		opts.CheckpointPath = fmt.Sprintf("backfill_%s.json", strings.ToLower(p.Name()))
+	}
+	if err := openStorage(ctx); err != nil {
+		return exitFailure
+	}
+	defer closeStorage()
+
+	tag := strings.ToUpper(p.Name())
+	startTime := time.Now()
+	checkpoint, err := runBackfill(ctx, p, archive, opts)
+	switch {
+	case ctx.Err() != nil && checkpoint != nil:
+		fmt.Printf("%s[%s]%s[WARNING] Обход прерван на %s, страница %d. Повторите команду, чтобы продолжить%s\n", ColorBlue, tag, ColorYellow, checkpoint.Day, checkpoint.Page, ColorReset)
+		return exitInterrupted
+	case err != nil:
+		fmt.Printf("%s[%s]%s[ERROR] %v%s\n", ColorBlue, tag, ColorRed, err, ColorReset)
+		if checkpoint != nil {
+			fmt.Printf("%s[%s]%s[INFO] Контрольная точка: %s, страница %d (%s)%s\n", ColorBlue, tag, ColorYellow, checkpoint.Day, checkpoint.Page, opts.CheckpointPath, ColorReset)
+		}
+		return exitFailure
+	}
+	fmt.Printf("%s[%s]%s[INFO] Архив %s..%s обработан за %s: ссылок %d, сохранено %d, ошибок %d%s\n",
+		ColorBlue, tag, ColorGreen, checkpoint.From, checkpoint.To, FormatDuration(time.Since(startTime)), checkpoint.Links, checkpoint.Saved, checkpoint.Failed, ColorReset)
+	return exitOK
+}
+
+func cmdHistory(ctx context.Context, args []string) int {
+	flags := newFlagSet("history")
+	showDiff := flags.Bool("diff", false, "показать отличия между редакциями")
+	from := flags.Int("from", 0, "номер старой редакции для --diff (по умолчанию предпоследняя)")
+	to := flags.Int("to", 0, "номер новой редакции для --diff (по умолчанию последняя)")
+	if code, ok := parseFlags(flags, args); !ok {
+		return code
+	}
+	if flags.NArg() != 1 {
+		fmt.Fprintf(os.Stderr, "%s[ERROR] Укажите ровно одну ссылку на статью%s\n\n%s", ColorRed, ColorReset, usageText)
+		return exitUsage
+	}
+	pageURL := flags.Arg(0)
+
+	if err := openStorage(ctx); err != nil {
+		return exitFailure
+	}
+	defer closeStorage()
+
+	revisions, err := store.Revisions(ctx, pageURL)
+	if err != nil {
+		fmt.Printf("%s[DB][ERROR] %v%s\n", ColorRed, err, ColorReset)
+		return exitFailure
+	}
+	if len(revisions) == 0 {
+		fmt.Printf("%s[WARNING] Статья %s не найдена в хранилище%s\n", ColorYellow, CanonicalURL(pageURL), ColorReset)
+		return exitFailure
+	}
+
+	if !*showDiff {
+		fmt.Printf("Статья: %s\nРедакций: %d\n\n", revisions[0].CanonicalURL, len(revisions))
+		for i, revision := range revisions {
+			fmt.Printf("%3d  %s  %s  %s\n", i+1, revision.FetchedAt.Local().Format("2006-01-02 15:04:05"), revision.ShortHash(), LimitString(revision.Title, 80))
+		}
+		return exitOK
+	}
+
+	if len(revisions) == 1 {
+		fmt.Printf("%s[INFO] У статьи одна редакция, сравнивать не с чем.%s\n", ColorBlue, ColorReset)
+		return exitOK
+	}
+	if *to == 0 {
+		*to = len(revisions)
+	}
+	if *from == 0 {
+		*from = *to - 1
+	}
+	if *from < 1 || *to < 1 || *from > len(revisions) || *to > len(revisions) {
+		fmt.Fprintf(os.Stderr, "%s[ERROR] Номера редакций должны быть от 1 до %d%s\n", ColorRed, len(revisions), ColorReset)
+		return exitUsage
+	}
+	fmt.Print(storage.DiffRevisions(revisions[*from-1], revisions[*to-1]).String())
+	return exitOK
+}
+
+func cmdRetag(ctx context.Context, args []string) int {
+	flags := newFlagSet("retag")
+	if code, ok := parseFlags(flags, args); !ok {
+		return code
+	}
+
+	if err := openStorage(ctx); err != nil {
+		return exitFailure
+	}
+	defer closeStorage()
+
+	retagger, ok := store.(storage.Retagger)
+	if !ok {
+		fmt.Fprintf(os.Stderr, "%s[ERROR] Хранилище '%s' не ведёт нормализованные теги%s\n", ColorRed, cfg.Storage.Backend, ColorReset)
+		return exitUsage
+	}
+
+	startTime := time.Now()
+	processed, err := retagger.Retag(ctx)
+	switch {
+	case ctx.Err() != nil:
+		return exitInterrupted
+	case err != nil:
+		fmt.Printf("%s[TAGS]%s[ERROR] Обработано статей до ошибки: %d: %v%s\n", ColorBlue, ColorRed, processed, err, ColorReset)
+		return exitFailure
+	}
+	fmt.Printf("%s[TAGS]%s[INFO] Теги и категории обновлены у %d статей за %s%s\n", ColorBlue, ColorGreen, processed, FormatDuration(time.Since(startTime)), ColorReset)
+	return exitOK
+}
+
+func cmdMigrate(ctx context.Context, args []string) int {
+	if len(args) == 0 {
+		fmt.Fprintf(os.Stderr, "%s[ERROR] Укажите действие: up, down или status%s\n\n%s", ColorRed, ColorReset, usageText)
+		return exitUsage
+	}
+	action, args := args[0], args[1:]
+
+	flags := newFlagSet("migrate " + action)
+	steps := flags.Int("steps", 1, "сколько последних миграций откатить (для down)")
+	if code, ok := parseFlags(flags, args); !ok {
+		return code
+	}
+	if action != "up" && action != "down" && action != "status" {
+		fmt.Fprintf(os.Stderr, "%s[ERROR] Неизвестное действие migrate '%s'%s\n\n%s", ColorRed, action, ColorReset, usageText)
+		return exitUsage
+	}
+	if *steps < 1 {
+		fmt.Fprintf(os.Stderr, "%s[ERROR] --steps должен быть не меньше 1%s\n", ColorRed, ColorReset)
+		return exitUsage
+	}
+
+	// Схема меняется только явными действиями команды
+	cfg.Storage.Migrate = "off"
+	if err := openStorage(ctx); err != nil {
+		return exitFailure
+	}
+	defer closeStorage()
+
+	migratable, ok := store.(storage.Migratable)
+	if !ok {
+		fmt.Fprintf(os.Stderr, "%s[ERROR] Хранилище '%s' не использует миграции%s\n", ColorRed, cfg.Storage.Backend, ColorReset)
+		return exitUsage
+	}
+	migrator, err := migratable.Migrator()
+	if err != nil {
+		fmt.Printf("%s[DB][ERROR] %v%s\n", ColorRed, err, ColorReset)
+		return exitFailure
+	}
+
+	var done []storage.Migration
+	switch action {
+	case "status":
+		statuses, err := migrator.Status(ctx)
+		if err != nil {
+			fmt.Printf("%s[DB][ERROR] %v%s\n", ColorRed, err, ColorReset)
+			return exitFailure
+		}
+		for _, status := range statuses {
+			state := "не применена"
+			if status.Applied() {
+				state = "применена " + status.AppliedAt.Local().Format("2006-01-02 15:04:05")
+			}
+			fmt.Printf("%04d  %-30s %s\n", status.Version, status.Name, state)
+		}
+		return exitOK
+	case "up":
+		done, err = migrator.Up(ctx)
+	case "down":
+		done, err = migrator.Down(ctx, *steps)
+	}
+
+	for _, migration := range done {
+		fmt.Printf("%s[DB] Миграция %s: %04d_%s%s\n", ColorGreen, action, migration.Version, migration.Name, ColorReset)
+	}
+	if err != nil {
+		fmt.Printf("%s[DB][ERROR] %v%s\n", ColorRed, err, ColorReset)
+		return exitFailure
+	}
+	if len(done) == 0 {
+		fmt.Printf("%s[DB] Нет миграций для выполнения.%s\n", ColorBlue, ColorReset)
+	}
+	return exitOK
+}
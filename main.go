@@ -1,203 +1,158 @@
 package main
 
 import (
-	"bufio"
+	"context"
+	_ "embed"
+	"errors"
 	"fmt"
+	"io/fs"
 	"os"
+	"os/signal"
 	"parsing_media/parsers"
+	"parsing_media/storage"
 	. "parsing_media/utils"
-	"strconv"
 	"strings"
 	"sync"
-	"time"
+	"syscall"
 )
 
-type ParserInfo struct {
-	Name string
-	Func func()
-}
+// defaultBoilerplate - правила очистки текста, с которыми собран бинарник; применяются, если boilerplate.yaml нет рядом
+//
+//go:embed boilerplate.yaml
+var defaultBoilerplate []byte
 
-var ParserDefinitions = []ParserInfo{
-	{Name: "RIA", Func: parsers.RiaMain},
-	{Name: "Gazeta", Func: parsers.GazetaMain},
-	{Name: "Lenta", Func: parsers.LentaMain},
-	{Name: "Vesti", Func: parsers.VestiMain},
-	{Name: "Kommersant", Func: parsers.KommersMain},
-	{Name: "MK", Func: parsers.MKMain},
-	{Name: "Fontanka", Func: parsers.FontankaMain},
-	{Name: "Smotrim", Func: parsers.SmotrimMain},
-	{Name: "DumaTV", Func: parsers.DumaTVMain},
-	{Name: "RBC", Func: parsers.RbcMain},
-	{Name: "Izvestiya", Func: parsers.IzMain},
-	{Name: "Interfax", Func: parsers.InterfaxMain},
-	{Name: "RG", Func: parsers.RGMain},
-	{Name: "KP", Func: parsers.KPMain},
-	{Name: "Ura", Func: parsers.UraMain},
-	{Name: "Life", Func: parsers.LifeMain},
-	{Name: "Regnum", Func: parsers.RegnumMain},
-	{Name: "AIF", Func: parsers.AifMain},
-}
+// cfg - настройки запуска, загружаются в runCommand до выполнения подкоманды
+var cfg = DefaultConfig()
 
-func displayMenu() {
-	fmt.Printf("\n%s--- МЕНЮ ЗАПУСКА ПАРСЕРОВ ---%s\n", ColorYellow, ColorReset)
-	fmt.Printf("!  - Выход\n")
-	fmt.Printf("0  - Запустить все циклично\n")
-	fmt.Println(strings.Repeat("-", 50))
-
-	const columns = 3
-	for i, p := range ParserDefinitions {
-		fmt.Printf("%2d - %-15s", i+1, p.Name)
-		if (i+1)%columns == 0 || i == len(ParserDefinitions)-1 {
-			fmt.Println()
-		}
+// store - хранилище статей, открывается в openStorage
+var store storage.Storage
+
+// knownURLs - кеш ссылок на уже сохранённые статьи, общий для всех парсеров процесса
+var knownURLs *storage.KnownURLs
+
+// Коды завершения программы
+const (
+	exitOK          = 0   // все парсеры отработали успешно
+	exitFailure     = 1   // хотя бы один парсер завершился ошибкой или ничего не собрал
+	exitUsage       = 2   // неверные аргументы командной строки
+	exitInterrupted = 130 // работа прервана сигналом
+)
+
+// runParser запускает один парсер, сохраняет собранные статьи и печатает сводку.
+// Дедлайн cfg.Parsers.Timeout ограничивает только сбор: то, что успели собрать, всё равно сохраняется.
+// Статьи, ссылки на которые уже есть в БД, не загружаются, если не включён cfg.Parsers.ForceRefetch.
+func runParser(ctx context.Context, p parsers.Parser) parsers.RunResult {
+	runCtx, cancel := context.WithTimeout(ctx, cfg.Parsers.Timeout)
+	defer cancel()
+
+	var opts parsers.RunOptions
+	if !cfg.Parsers.ForceRefetch {
+		opts.Filter = knownURLs
 	}
-	fmt.Println(strings.Repeat("-", 50))
-	fmt.Printf("Ваш выбор: ")
-}
 
-func main() {
+	result := parsers.Run(runCtx, p, opts)
+	saveArticles(ctx, strings.ToUpper(p.Name()), result.Articles)
+	parsers.PrintReport(result)
+	return result
+}
 
-	// Инициализация соединения с БД
-	fmt.Printf("%s[INFO] Инициализация соединения с базой данных...%s\n", ColorBlue, ColorReset)
-	if err := InitDB(); err != nil {
-		fmt.Printf("%s[FATAL] Ошибка подключения к БД: %v%s\n", ColorRed, err, ColorReset)
-		return
+// saveArticles сохраняет статьи, добавляет их ссылки в knownURLs и печатает итог сохранения
+func saveArticles(ctx context.Context, tag string, articles []Data) (storage.SaveResult, error) {
+	saved, err := store.Save(ctx, articles)
+	if err != nil {
+		fmt.Printf("%s[%s]%s[ERROR] Ошибка сохранения статей: %v%s\n", ColorBlue, tag, ColorRed, err, ColorReset)
+		return saved, err
 	}
-	fmt.Printf("%s[DB] Соединение с БД установлено. Готовность к работе.%s\n", ColorBlue, ColorReset)
-
-	reader := bufio.NewReader(os.Stdin)
-
-	for {
-		displayMenu()
-
-		input, _ := reader.ReadString('\n')
-		num := strings.TrimSpace(input)
-
-		switch num {
-		case "!":
-			fmt.Printf("%s[INFO] Завершение работы.%s\n", ColorBlue, ColorReset)
-			return
-		case "0":
-			runAllParsersInLoop(ParserDefinitions, reader)
-		default:
-			choice, err := strconv.Atoi(num)
-			if err != nil || choice < 1 || choice > len(ParserDefinitions) {
-				fmt.Printf("\n%s[ОШИБКА] Неверный ввод. Пожалуйста, выберите номер из списка.%s\n", ColorRed, ColorReset)
-				time.Sleep(2 * time.Second)
-				continue
-			}
-
-			selectedParser := ParserDefinitions[choice-1]
-			fmt.Printf("\n%s[INFO] Запуск парсера: %s%s\n", ColorBlue, selectedParser.Name, ColorReset)
-			selectedParser.Func()
-			fmt.Printf("\n%s[INFO] Парсер %s завершил работу.%s\n", ColorBlue, selectedParser.Name, ColorReset)
-		}
+	for _, article := range saved.Inserted {
+		knownURLs.Add(article.Href)
+	}
+	for _, article := range saved.Revised {
+		knownURLs.Add(article.Href)
+	}
+	for _, article := range saved.Duplicates {
+		knownURLs.Add(article.Href)
 	}
+	for _, failure := range saved.Failed {
+		fmt.Printf("%s[%s]%s[WARNING] Статья не сохранена: %s (%s): %v%s\n", ColorBlue, tag, ColorYellow, LimitString(failure.Article.Title, 40), failure.Article.Href, failure.Err, ColorReset)
+	}
+	if len(articles) > 0 {
+		fmt.Printf("%s[%s][DB] Сохранено новых: %d, новых редакций: %d, дубликатов: %d, ошибок: %d%s\n", ColorBlue, tag, len(saved.Inserted), len(saved.Revised), len(saved.Duplicates), len(saved.Failed), ColorReset)
+	}
+	return saved, nil
 }
 
-func runAllParsersInLoop(parsers []ParserInfo, reader *bufio.Reader) {
-	interruptChan := make(chan struct{})
-	var interruptOnce sync.Once
+// runParsers параллельно запускает все парсеры списка и возвращает их результаты в том же порядке
+func runParsers(ctx context.Context, parserList []parsers.Parser) []parsers.RunResult {
+	results := make([]parsers.RunResult, len(parserList))
+	var wg sync.WaitGroup
+	for i, p := range parserList {
+		wg.Add(1)
+		go func(i int, p parsers.Parser) {
+			defer wg.Done()
+			results[i] = runParser(ctx, p)
+		}(i, p)
+	}
+	wg.Wait()
+	return results
+}
 
-	go func() {
-		_, _ = reader.ReadString('\n')
-		interruptOnce.Do(func() {
-			close(interruptChan)
-		})
-	}()
+// resultSucceeded - парсер получил список ссылок и собрал хотя бы одну статью (или новых ссылок не было вовсе)
+func resultSucceeded(r parsers.RunResult) bool {
+	return r.Err == nil && (len(r.Links)-r.Known == 0 || len(r.Articles) > 0)
+}
 
-	fmt.Printf("\n%s[INFO] Запуск всех парсеров в цикле. Нажмите Enter для остановки после текущей итерации.%s\n", ColorBlue, ColorReset)
-
-	keepRunning := true
-	for keepRunning {
-		const totalWaitDuration = 3 * time.Minute
-		// 1. Засекаем время окончания цикла СРАЗУ
-		deadline := time.Now().Add(totalWaitDuration)
-
-		fmt.Printf("\n%s[INFO] Запускаем новую итерацию... Следующий запуск в %s%s\n", ColorBlue, deadline.Format("15:04:05"), ColorReset)
-
-		// 2. Запускаем все парсеры в фоне и получаем канал, который закроется по их завершению
-		parsersDoneChan := make(chan struct{})
-		go func() {
-			var wg sync.WaitGroup
-			for _, p := range parsers {
-				wg.Add(1)
-				go func(parserFunc func()) {
-					defer wg.Done()
-					parserFunc()
-				}(p.Func)
-			}
-			wg.Wait()
-			close(parsersDoneChan) // Сигналим о завершении
-		}()
-
-		// 3. Ждем, пока парсеры завершатся. Позволяем прервать ожидание.
-		select {
-		case <-parsersDoneChan:
-			fmt.Printf("%s[INFO] Все парсеры (%d) завершили свою работу.%s\n", ColorBlue, len(parsers), ColorReset)
-		case <-interruptChan:
-			fmt.Printf("\n%s[INFO] Обнаружен сигнал остановки во время работы парсеров. Ожидаем их завершения...%s\n", ColorYellow, ColorReset)
-			<-parsersDoneChan // Все равно дожидаемся завершения, чтобы не оставлять "висячих" процессов
-			fmt.Printf("%s[INFO] Парсеры завершили работу. Выходим из цикла.%s\n", ColorBlue, ColorReset)
-			keepRunning = false
-			continue // Переходим к следующей итерации (которая будет последней)
-		}
+// openStorage открывает хранилище из конфигурации с выводом статуса в консоль.
+// Закрыть его нужно через closeStorage.
+func openStorage(ctx context.Context) error {
+	fmt.Printf("%s[INFO] Инициализация хранилища '%s'...%s\n", ColorBlue, cfg.Storage.Backend, ColorReset)
+	opened, err := storage.Open(ctx, cfg)
+	if err != nil {
+		fmt.Printf("%s[FATAL] Ошибка подключения к хранилищу: %v%s\n", ColorRed, err, ColorReset)
+		return err
+	}
+	store = opened
+	knownURLs = storage.NewKnownURLs(store, cfg.Parsers.KnownCacheSize)
+	fmt.Printf("%s[DB] Хранилище '%s' подключено. Готовность к работе.%s\n", ColorBlue, cfg.Storage.Backend, ColorReset)
+	return nil
+}
 
-		// 4. Вычисляем оставшееся время и запускаем обратный отсчет
-		remainingDuration := time.Until(deadline)
-		if remainingDuration < 0 {
-			fmt.Printf("%s[WARN] Парсеры работали дольше выделенного времени (%v). Немедленный перезапуск.%s\n", ColorYellow, totalWaitDuration, ColorReset)
-			continue // Сразу переходим к следующей итерации
-		}
+// closeStorage закрывает хранилище, открытое openStorage
+func closeStorage() {
+	if err := store.Close(); err != nil {
+		fmt.Printf("%s[DB][WARN] Ошибка закрытия хранилища: %v%s\n", ColorYellow, err, ColorReset)
+	}
+}
 
-		// Блок с таймером на ОСТАВШЕЕСЯ время
-		timer := time.NewTimer(remainingDuration)
-		stopCountdownChan := make(chan struct{})
-		var wgCountdown sync.WaitGroup
-
-		wgCountdown.Add(1)
-		go func() {
-			defer wgCountdown.Done()
-			ticker := time.NewTicker(33 * time.Millisecond)
-			defer ticker.Stop()
-
-			for {
-				select {
-				case <-stopCountdownChan:
-					return
-				case <-ticker.C:
-					remaining := time.Until(deadline)
-					if remaining <= 0 {
-						fmt.Printf("\r%s[INFO] До повторного запуска: 0m 0.000s%s ", ColorBlue, ColorReset)
-						return
-					}
-					minutes := int(remaining.Minutes())
-					seconds := remaining.Seconds() - float64(minutes*60)
-					fmt.Printf("\r%s[INFO] До повторного запуска: %dm %.3fs%s ", ColorBlue, minutes, seconds, ColorReset)
-				}
-			}
-		}()
-
-		select {
-		case <-timer.C:
-			close(stopCountdownChan)
-			wgCountdown.Wait()
-			fmt.Printf("\r%s[INFO] До повторного запуска: 0m 0.000s%s \n", ColorBlue, ColorReset)
-		case <-interruptChan:
-			if !timer.Stop() {
-				select {
-				case <-timer.C:
-				default:
-				}
-			}
-			close(stopCountdownChan)
-			wgCountdown.Wait()
-			fmt.Println()
-			fmt.Printf("%s[INFO] Завершаем цикл...%s\n", ColorBlue, ColorReset)
-			keepRunning = false
+// loadParsers регистрирует декларативные парсеры из каталога определений, загружает правила очистки текста
+// и применяет к парсерам настройки. Отсутствие каталога определений не считается ошибкой.
+func loadParsers() {
+	sitesDir := cfg.Parsers.SitesDir
+	if count, err := parsers.RegisterDefinitions(sitesDir); err != nil {
+		if !errors.Is(err, fs.ErrNotExist) {
+			fmt.Printf("%s[WARNING] Ошибка загрузки определений сайтов: %v%s\n", ColorYellow, err, ColorReset)
 		}
+	} else if count > 0 {
+		fmt.Printf("%s[INFO] Загружено определений сайтов из '%s': %d%s\n", ColorBlue, sitesDir, count, ColorReset)
 	}
 
-	fmt.Printf("\n%s[INFO] Цикл парсинга завершен. Возврат в главное меню.%s\n", ColorBlue, ColorReset)
-	time.Sleep(2 * time.Second)
+	if count, err := parsers.UseBoilerplate(cfg.Parsers.BoilerplateFile, defaultBoilerplate); err != nil {
+		fmt.Printf("%s[WARNING] Ошибка загрузки правил очистки текста: %v%s\n", ColorYellow, err, ColorReset)
+	} else if count > 0 {
+		fmt.Printf("%s[INFO] Загружены правила очистки текста для сайтов: %d%s\n", ColorBlue, count, ColorReset)
+	}
+
+	parsers.ApplyConfig(cfg)
+}
+
+func main() {
+	// SIGINT/SIGTERM отменяют ctx: текущие запросы и транзакции прерываются, программа завершается.
+	// Повторный сигнал после отмены обрабатывается по умолчанию и завершает процесс сразу.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-ctx.Done()
+		stop()
+		fmt.Printf("\n%s[INFO] Получен сигнал завершения. Прерываем текущие запросы...%s\n", ColorYellow, ColorReset)
+	}()
+
+	os.Exit(runCommand(ctx, os.Args[1:]))
 }
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"parsing_media/parsers"
+	. "parsing_media/utils"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// readLine читает строку из reader, но не дольше, чем живёт ctx
+func readLine(ctx context.Context, reader *bufio.Reader) (string, bool) {
+	lineChan := make(chan string, 1)
+	go func() {
+		line, _ := reader.ReadString('\n')
+		lineChan <- line
+	}()
+
+	select {
+	case line := <-lineChan:
+		return line, true
+	case <-ctx.Done():
+		return "", false
+	}
+}
+
+func displayMenu() {
+	fmt.Printf("\n%s--- МЕНЮ ЗАПУСКА ПАРСЕРОВ ---%s\n", ColorYellow, ColorReset)
+	fmt.Printf("!  - Выход\n")
+	fmt.Printf("0  - Запустить все циклично\n")
+	fmt.Println(strings.Repeat("-", 50))
+
+	const columns = 3
+	allParsers := parsers.All()
+	for i, p := range allParsers {
+		fmt.Printf("%2d - %-15s", i+1, p.Name())
+		if (i+1)%columns == 0 || i == len(allParsers)-1 {
+			fmt.Println()
+		}
+	}
+	fmt.Println(strings.Repeat("-", 50))
+	fmt.Printf("Ваш выбор: ")
+}
+
+// runMenu - интерактивный режим с выбором парсера из меню
+func runMenu(ctx context.Context) int {
+	reader := bufio.NewReader(os.Stdin)
+	allParsers := parsers.All()
+
+	for {
+		if ctx.Err() != nil {
+			fmt.Printf("%s[INFO] Завершение работы.%s\n", ColorBlue, ColorReset)
+			return exitInterrupted
+		}
+		displayMenu()
+
+		input, ok := readLine(ctx, reader)
+		if !ok {
+			fmt.Printf("%s[INFO] Завершение работы.%s\n", ColorBlue, ColorReset)
+			return exitInterrupted
+		}
+		num := strings.TrimSpace(input)
+
+		switch num {
+		case "!":
+			fmt.Printf("%s[INFO] Завершение работы.%s\n", ColorBlue, ColorReset)
+			return exitOK
+		case "0":
+			runAllParsersInLoop(ctx, allParsers, reader)
+		default:
+			choice, err := strconv.Atoi(num)
+			if err != nil || choice < 1 || choice > len(allParsers) {
+				fmt.Printf("\n%s[ОШИБКА] Неверный ввод. Пожалуйста, выберите номер из списка.%s\n", ColorRed, ColorReset)
+				time.Sleep(2 * time.Second)
+				continue
+			}
+
+			selectedParser := allParsers[choice-1]
+			fmt.Printf("\n%s[INFO] Запуск парсера: %s%s\n", ColorBlue, selectedParser.Name(), ColorReset)
+			runParser(ctx, selectedParser)
+			fmt.Printf("\n%s[INFO] Парсер %s завершил работу.%s\n", ColorBlue, selectedParser.Name(), ColorReset)
+		}
+	}
+}
+
+// runAllParsersInLoop - пункт меню "0": запуск всех парсеров по их расписаниям до нажатия Enter
+func runAllParsersInLoop(ctx context.Context, parserList []parsers.Parser, reader *bufio.Reader) {
+	// Enter или сигнал завершения отменяют loopCtx и прерывают работающие парсеры
+	loopCtx, stopLoop := context.WithCancel(ctx)
+	defer stopLoop()
+
+	go func() {
+		_, _ = reader.ReadString('\n')
+		stopLoop()
+	}()
+
+	jobs, err := buildSchedules(parserList)
+	if err != nil {
+		fmt.Printf("%s[ERROR] %v%s\n", ColorRed, err, ColorReset)
+		return
+	}
+
+	fmt.Printf("\n%s[INFO] Запуск всех парсеров по расписанию. Нажмите Enter для остановки.%s\n", ColorBlue, ColorReset)
+	runScheduler(loopCtx, jobs)
+
+	fmt.Printf("\n%s[INFO] Цикл парсинга завершен. Возврат в главное меню.%s\n", ColorBlue, ColorReset)
+	time.Sleep(2 * time.Second)
+}
@@ -0,0 +1,60 @@
+package parsers
+
+import (
+	"context"
+	"regexp"
+	"strconv"
+	"time"
+)
+
+// Archive реализуется парсерами сайтов, у которых есть архив новостей по дням.
+// Через него команда backfill собирает ссылки на статьи за прошедшие даты.
+type Archive interface {
+	// ArchiveLinks возвращает ссылки со страницы page (нумерация с 1) архива за день day.
+	// Пустой список означает, что страниц за этот день больше нет.
+	ArchiveLinks(ctx context.Context, day time.Time, page int) ([]LinkItem, error)
+}
+
+// optionalArchive реализуется парсерами, у которых архив есть не всегда (декларативные определения без archive_url)
+type optionalArchive interface {
+	hasArchive() bool
+}
+
+// ArchiveOf возвращает архив сайта парсера p, если сайт его поддерживает
+func ArchiveOf(p Parser) (Archive, bool) {
+	archive, ok := p.(Archive)
+	if optional, isOptional := p.(optionalArchive); ok && isOptional && !optional.hasArchive() {
+		return nil, false
+	}
+	return archive, ok
+}
+
+var archivePlaceholder = regexp.MustCompile(`\{([^{}]+)\}`)
+
+// archiveURL подставляет в шаблон адреса архива номер страницы ({page}) и дату: остальные
+// выражения в фигурных скобках - форматы даты Go, например "https://lenta.ru/news/{2006/01/02}/page/{page}/".
+// Второй результат false, если страница больше первой, а в шаблоне нет {page}: у архива одна страница за день.
+func archiveURL(template string, day time.Time, page int) (string, bool) {
+	hasPage := false
+	expanded := archivePlaceholder.ReplaceAllStringFunc(template, func(placeholder string) string {
+		layout := placeholder[1 : len(placeholder)-1]
+		if layout == "page" {
+			hasPage = true
+			return strconv.Itoa(page)
+		}
+		return day.Format(layout)
+	})
+	if page > 1 && !hasPage {
+		return "", false
+	}
+	return expanded, true
+}
+
+// archivePageURL выбирает шаблон для страницы page: у многих сайтов первая страница архива
+// открывается по адресу без номера, а следующие - по адресу с {page}
+func archivePageURL(firstPage, nextPages string, day time.Time, page int) (string, bool) {
+	if page == 1 || nextPages == "" {
+		return archiveURL(firstPage, day, page)
+	}
+	return archiveURL(nextPages, day, page)
+}
@@ -0,0 +1,36 @@
+package parsers
+
+import (
+	"parsing_media/rudate"
+	"testing"
+	"time"
+)
+
+func TestArchivePageURL(t *testing.T) {
+	day := time.Date(2025, time.March, 7, 0, 0, 0, 0, rudate.Moscow)
+	tests := []struct {
+		name      string
+		first     string
+		next      string
+		page      int
+		want      string
+		wantFound bool
+	}{
+		{"дата и страница", "https://lenta.ru/news/{2006/01/02}/page/{page}/", "", 1, "https://lenta.ru/news/2025/03/07/page/1/", true},
+		{"вторая страница", "https://lenta.ru/news/{2006/01/02}/page/{page}/", "", 3, "https://lenta.ru/news/2025/03/07/page/3/", true},
+		{"дата без нулей", "https://site.ru/archive/{2006/1/2}/", "", 1, "https://site.ru/archive/2025/3/7/", true},
+		{"дата в параметре", "https://site.ru/news?date={02.01.2006}&p={page}", "", 2, "https://site.ru/news?date=07.03.2025&p=2", true},
+		{"одна страница за день", "https://site.ru/archive/{2006-01-02}/", "", 1, "https://site.ru/archive/2025-03-07/", true},
+		{"нет {page} для второй страницы", "https://site.ru/archive/{2006-01-02}/", "", 2, "", false},
+		{"первая страница без номера", "https://site.ru/{2006/01/02}/", "https://site.ru/{2006/01/02}/page-{page}/", 1, "https://site.ru/2025/03/07/", true},
+		{"следующие страницы по второму шаблону", "https://site.ru/{2006/01/02}/", "https://site.ru/{2006/01/02}/page-{page}/", 2, "https://site.ru/2025/03/07/page-2/", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, found := archivePageURL(tt.first, tt.next, day, tt.page)
+			if got != tt.want || found != tt.wantFound {
+				t.Errorf("archivePageURL(%q, %q, %d) = %q, %v; want %q, %v", tt.first, tt.next, tt.page, got, found, tt.want, tt.wantFound)
+			}
+		})
+	}
+}
@@ -0,0 +1,55 @@
+package parsers
+
+import (
+	"context"
+	"fmt"
+	. "parsing_media/utils"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// boilerplate - правила очистки страниц статей по сайтам (parsers.boilerplate_file)
+var boilerplate *Boilerplate
+
+// UseBoilerplate загружает правила очистки текста из файла path (или встроенные defaults, если файла по умолчанию нет)
+// и возвращает количество сайтов с собственными правилами. При ошибке ранее загруженные правила остаются в силе.
+func UseBoilerplate(path string, defaults []byte) (int, error) {
+	loaded, err := LoadBoilerplate(path, defaults)
+	if err != nil {
+		return 0, err
+	}
+	boilerplate = loaded
+	return loaded.Sites(), nil
+}
+
+// articlePage - загруженная и очищенная страница статьи со статистикой правил очистки, которую finishPage
+// прикладывает к результату разбора
+type articlePage struct {
+	*goquery.Document
+	cleaning CleaningStats
+}
+
+// article загружает страницу статьи и удаляет из неё служебные блоки по правилам сайта
+func (b *baseParser) article(ctx context.Context, pageURL string) (*articlePage, error) {
+	doc, err := GetArticleHTMLForClient(ctx, b.client, pageURL)
+	if err != nil {
+		return nil, err
+	}
+	page := &articlePage{Document: doc, cleaning: make(CleaningStats)}
+	boilerplate.Clean(b.siteURL, doc, page.cleaning)
+	return page, nil
+}
+
+// compileDefinitionRules собирает правила очистки из полей body_remove и skip_paragraph_* определения сайта
+func compileDefinitionRules(def *SiteDefinition) (*CompiledRules, error) {
+	rules, err := CompileRules(BoilerplateRules{
+		Paragraphs:   def.BodySelector,
+		Remove:       def.BodyRemove,
+		DropPrefixes: def.SkipParagraphPrefixes,
+		DropContains: def.SkipParagraphContains,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("определение %s: %w", def.Name, err)
+	}
+	return rules, nil
+}
@@ -0,0 +1,429 @@
+package parsers
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"parsing_media/rudate"
+	. "parsing_media/utils"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/PuerkitoBio/goquery"
+	"gopkg.in/yaml.v3"
+)
+
+// SiteDefinition описывает сайт декларативно: страницу-список, селекторы и правила разбора.
+// Файлы определений (.yaml, .yml, .json) позволяют добавить или поправить сайт без написания кода.
+type SiteDefinition struct {
+	Name    string `yaml:"name" json:"name"`
+	SiteURL string `yaml:"site_url" json:"site_url"`
+	ListURL string `yaml:"list_url" json:"list_url"`
+	Workers int    `yaml:"workers" json:"workers"`
+
+	LinkSelector string   `yaml:"link_selector" json:"link_selector"`
+	LinkPrefixes []string `yaml:"link_prefixes" json:"link_prefixes"` // допустимые префиксы абсолютных ссылок (по умолчанию site_url)
+	LinkExclude  []string `yaml:"link_exclude" json:"link_exclude"`   // ссылки, содержащие любую из подстрок, отбрасываются
+	StripQuery   bool     `yaml:"strip_query" json:"strip_query"`
+	MaxLinks     int      `yaml:"max_links" json:"max_links"`
+
+	// RSS/Atom-ленты и карты сайта дополняют страницу-список или заменяют её, если list_url не задан
+	// (см. parsers.link_sources). Их ссылки отбираются по link_prefixes и link_exclude.
+	FeedURLs    []string `yaml:"feed_urls" json:"feed_urls"`
+	SitemapURLs []string `yaml:"sitemap_urls" json:"sitemap_urls"` // без них источник sitemap берёт карты из robots.txt
+
+	// Архив по дням для команды backfill (см. archiveURL): дата в фигурных скобках - формат Go, {page} - номер страницы
+	ArchiveURL          string `yaml:"archive_url" json:"archive_url"`
+	ArchivePagesURL     string `yaml:"archive_pages_url" json:"archive_pages_url"`         // вторая и следующие страницы, если адрес отличается
+	ArchiveLinkSelector string `yaml:"archive_link_selector" json:"archive_link_selector"` // по умолчанию link_selector
+
+	TitleSelectors []string `yaml:"title_selectors" json:"title_selectors"`
+
+	BodySelector          string   `yaml:"body_selector" json:"body_selector"`
+	BodyRemove            []string `yaml:"body_remove" json:"body_remove"` // селекторы, удаляемые из документа перед сбором текста
+	SkipParagraphPrefixes []string `yaml:"skip_paragraph_prefixes" json:"skip_paragraph_prefixes"`
+	SkipParagraphContains []string `yaml:"skip_paragraph_contains" json:"skip_paragraph_contains"`
+
+	DateSelector  string   `yaml:"date_selector" json:"date_selector"`
+	DateAttr      string   `yaml:"date_attr" json:"date_attr"`           // пусто - брать текст элемента
+	DateLayouts   []string `yaml:"date_layouts" json:"date_layouts"`     // "RFC3339" или формат Go; если ни один не подошёл - общий разбор rudate
+	RussianMonths bool     `yaml:"russian_months" json:"russian_months"` // заменять названия месяцев на номера перед разбором по date_layouts
+	Timezone      string   `yaml:"timezone" json:"timezone"`
+
+	TagSelector   string `yaml:"tag_selector" json:"tag_selector"`
+	TagsMandatory bool   `yaml:"tags_mandatory" json:"tags_mandatory"`
+
+	// Необязательные поля статьи (см. metadataSelectors)
+	AuthorSelector   string `yaml:"author_selector" json:"author_selector"`
+	LeadSelector     string `yaml:"lead_selector" json:"lead_selector"`
+	RubricSelector   string `yaml:"rubric_selector" json:"rubric_selector"`
+	ImageSelector    string `yaml:"image_selector" json:"image_selector"`
+	ModifiedSelector string `yaml:"modified_selector" json:"modified_selector"`
+	RichBodySelector string `yaml:"rich_body_selector" json:"rich_body_selector"` // контейнер текста для parsers.keep_rich_body
+}
+
+// Validate проверяет обязательные поля определения
+func (d *SiteDefinition) Validate() error {
+	var missing []string
+	if d.Name == "" {
+		missing = append(missing, "name")
+	}
+	if d.SiteURL == "" {
+		missing = append(missing, "site_url")
+	}
+	if d.ListURL == "" && len(d.FeedURLs) == 0 && len(d.SitemapURLs) == 0 {
+		missing = append(missing, "list_url, feed_urls или sitemap_urls")
+	}
+	if d.ListURL != "" && d.LinkSelector == "" {
+		missing = append(missing, "link_selector")
+	}
+	if d.ArchiveURL != "" && d.ArchiveLinkSelector == "" && d.LinkSelector == "" {
+		missing = append(missing, "archive_link_selector")
+	}
+	if len(d.TitleSelectors) == 0 {
+		missing = append(missing, "title_selectors")
+	}
+	if d.BodySelector == "" {
+		missing = append(missing, "body_selector")
+	}
+	if d.DateSelector == "" {
+		missing = append(missing, "date_selector")
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("не заданы обязательные поля: %s", strings.Join(missing, ", "))
+	}
+	return nil
+}
+
+// metadata возвращает селекторы необязательных полей статьи
+func (d *SiteDefinition) metadata() metadataSelectors {
+	return metadataSelectors{
+		Body:     d.RichBodySelector,
+		Authors:  d.AuthorSelector,
+		Lead:     d.LeadSelector,
+		Rubric:   d.RubricSelector,
+		Image:    d.ImageSelector,
+		Modified: d.ModifiedSelector,
+	}
+}
+
+// LoadDefinitions читает все определения сайтов из каталога. Файлы, начинающиеся с "_", пропускаются.
+func LoadDefinitions(dir string) ([]*SiteDefinition, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("чтение каталога определений %s: %w", dir, err)
+	}
+
+	var definitions []*SiteDefinition
+	for _, entry := range entries {
+		if entry.IsDir() || strings.HasPrefix(entry.Name(), "_") {
+			continue
+		}
+		ext := strings.ToLower(filepath.Ext(entry.Name()))
+		if ext != ".yaml" && ext != ".yml" && ext != ".json" {
+			continue
+		}
+
+		path := filepath.Join(dir, entry.Name())
+		definition, err := LoadDefinition(path)
+		if err != nil {
+			return nil, err
+		}
+		definitions = append(definitions, definition)
+	}
+	return definitions, nil
+}
+
+// LoadDefinition читает одно определение сайта из YAML или JSON файла
+func LoadDefinition(path string) (*SiteDefinition, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("чтение определения %s: %w", path, err)
+	}
+
+	var definition SiteDefinition
+	if strings.ToLower(filepath.Ext(path)) == ".json" {
+		err = json.Unmarshal(raw, &definition)
+	} else {
+		err = yaml.Unmarshal(raw, &definition)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("разбор определения %s: %w", path, err)
+	}
+	if err := definition.Validate(); err != nil {
+		return nil, fmt.Errorf("определение %s: %w", path, err)
+	}
+	return &definition, nil
+}
+
+// RegisterDefinitions загружает определения из каталога и регистрирует парсеры по ним.
+// Определение с именем существующего парсера заменяет его.
+func RegisterDefinitions(dir string) (int, error) {
+	definitions, err := LoadDefinitions(dir)
+	if err != nil {
+		return 0, err
+	}
+	for _, definition := range definitions {
+		parser, err := NewDeclarativeParser(definition)
+		if err != nil {
+			return 0, err
+		}
+		Register(parser)
+	}
+	return len(definitions), nil
+}
+
+type declarativeParser struct {
+	baseParser
+	def   *SiteDefinition
+	dates *rudate.Parser
+	rules *CompiledRules // body_remove и skip_paragraph_* определения
+}
+
+// NewDeclarativeParser создаёт парсер по определению сайта
+func NewDeclarativeParser(def *SiteDefinition) (Parser, error) {
+	if err := def.Validate(); err != nil {
+		return nil, fmt.Errorf("определение %s: %w", def.Name, err)
+	}
+
+	location := rudate.Moscow
+	if def.Timezone != "" {
+		loaded, err := time.LoadLocation(def.Timezone)
+		if err != nil {
+			return nil, fmt.Errorf("определение %s: неизвестный часовой пояс '%s': %w", def.Name, def.Timezone, err)
+		}
+		location = loaded
+	}
+
+	rules, err := compileDefinitionRules(def)
+	if err != nil {
+		return nil, err
+	}
+
+	workers := def.Workers
+	if workers <= 0 {
+		workers = 10
+	}
+
+	return &declarativeParser{
+		baseParser: newBaseParser(def.Name, def.SiteURL, workers).withFeeds(def.FeedURLs...).withSitemaps(def.SitemapURLs...),
+		def:        def,
+		dates:      rudate.New(location, nil),
+		rules:      rules,
+	}, nil
+}
+
+func (p *declarativeParser) tag() string {
+	return strings.ToUpper(p.def.Name)
+}
+
+func (p *declarativeParser) Links(ctx context.Context) ([]LinkItem, error) {
+	var listing func(context.Context) ([]LinkItem, error)
+	if p.def.ListURL != "" {
+		listing = p.htmlLinks
+	}
+	foundLinks, err := p.links(ctx, listing, p.acceptLink)
+	if err != nil {
+		return nil, err
+	}
+
+	if p.def.MaxLinks > 0 && len(foundLinks) > p.def.MaxLinks {
+		foundLinks = foundLinks[:p.def.MaxLinks]
+	}
+	return foundLinks, nil
+}
+
+// htmlLinks собирает ссылки со страницы-списка list_url
+func (p *declarativeParser) htmlLinks(ctx context.Context) ([]LinkItem, error) {
+	foundLinks, err := p.listLinks(ctx, p.def.ListURL, p.def.LinkSelector)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(foundLinks) == 0 {
+		fmt.Printf("%s[%s]%s[WARNING] Не найдено ссылок с селектором '%s' на странице %s.%s\n", ColorBlue, p.tag(), ColorYellow, p.def.LinkSelector, p.def.ListURL, ColorReset)
+	}
+	return foundLinks, nil
+}
+
+// acceptLink проверяет абсолютную ссылку по link_prefixes (по умолчанию site_url) и link_exclude
+func (p *declarativeParser) acceptLink(href string) bool {
+	prefixes := p.def.LinkPrefixes
+	if len(prefixes) == 0 {
+		prefixes = []string{p.def.SiteURL}
+	}
+	return hasAnyPrefix(href, prefixes) && !containsAny(href, p.def.LinkExclude)
+}
+
+func (p *declarativeParser) hasArchive() bool {
+	return p.def.ArchiveURL != ""
+}
+
+func (p *declarativeParser) ArchiveLinks(ctx context.Context, day time.Time, page int) ([]LinkItem, error) {
+	if !p.hasArchive() {
+		return nil, fmt.Errorf("в определении %s не задан archive_url", p.def.Name)
+	}
+	archivePage, ok := archivePageURL(p.def.ArchiveURL, p.def.ArchivePagesURL, day.In(p.dates.Location()), page)
+	if !ok {
+		return nil, nil
+	}
+	return p.listLinks(ctx, archivePage, cmp.Or(p.def.ArchiveLinkSelector, p.def.LinkSelector))
+}
+
+// listLinks собирает ссылки на статьи со страницы-списка listURL по селектору linkSelector
+func (p *declarativeParser) listLinks(ctx context.Context, listURL, linkSelector string) ([]LinkItem, error) {
+	var foundLinks []LinkItem
+	seenLinks := make(map[string]bool)
+
+	doc, err := GetHTMLForClient(ctx, p.client, listURL)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при получении HTML со страницы %s: %w", listURL, err)
+	}
+
+	doc.Find(linkSelector).Each(func(i int, s *goquery.Selection) {
+		href, exists := s.Attr("href")
+		if !exists {
+			return
+		}
+
+		fullHref := href
+		if strings.HasPrefix(href, "/") && !strings.HasPrefix(href, "//") {
+			fullHref = strings.TrimSuffix(p.def.SiteURL, "/") + href
+		}
+		if p.def.StripQuery {
+			if idx := strings.Index(fullHref, "?"); idx != -1 {
+				fullHref = fullHref[:idx]
+			}
+		}
+
+		if !p.acceptLink(fullHref) {
+			return
+		}
+		if !seenLinks[fullHref] {
+			seenLinks[fullHref] = true
+			foundLinks = append(foundLinks, LinkItem{Href: fullHref})
+		}
+	})
+
+	return foundLinks, nil
+}
+
+func (p *declarativeParser) ParsePage(ctx context.Context, item LinkItem) PageResult {
+	pageURL := item.Href
+
+	var title, body string
+	var tags []string
+	var parsDate time.Time
+
+	doc, err := p.article(ctx, pageURL)
+	if err != nil {
+		return PageResult{PageURL: pageURL, Error: fmt.Errorf("ошибка GET: %w", err)}
+	}
+	p.rules.Clean(doc.Document, doc.cleaning)
+
+	for _, selector := range p.def.TitleSelectors {
+		title = strings.TrimSpace(doc.Find(selector).First().Text())
+		if title != "" {
+			break
+		}
+	}
+
+	var bodyBuilder strings.Builder
+	doc.Find(p.def.BodySelector).Each(func(_ int, s *goquery.Selection) {
+		partText := strings.TrimSpace(s.Text())
+		if partText == "" {
+			return
+		}
+		if bodyBuilder.Len() > 0 {
+			bodyBuilder.WriteString("\n\n")
+		}
+		bodyBuilder.WriteString(partText)
+	})
+	body = bodyBuilder.String()
+
+	dateNode := doc.Find(p.def.DateSelector).First()
+	dateToParse := strings.TrimSpace(dateNode.Text())
+	if p.def.DateAttr != "" {
+		dateToParse = strings.TrimSpace(dateNode.AttrOr(p.def.DateAttr, ""))
+	}
+
+	var dateParseError error
+	if dateToParse != "" {
+		parsDate, dateParseError = p.parseDate(dateToParse)
+		if dateParseError != nil {
+			fmt.Printf("%s[%s]%s[WARNING] Ошибка парсинга даты: '%s' на %s: %v%s\n", ColorBlue, p.tag(), ColorYellow, dateToParse, pageURL, dateParseError, ColorReset)
+		}
+	}
+
+	tags = append(tags, item.Tags...)
+	if p.def.TagSelector != "" {
+		doc.Find(p.def.TagSelector).Each(func(_ int, s *goquery.Selection) {
+			tagText := strings.TrimSpace(s.Text())
+			if tagText != "" {
+				tags = append(tags, tagText)
+			}
+		})
+	}
+
+	return finishPage(doc, item, p.def.metadata(), Data{
+		Site:  p.def.SiteURL,
+		Href:  pageURL,
+		Title: title,
+		Body:  body,
+		Date:  parsDate,
+		Tags:  tags,
+	}, p.def.TagsMandatory, dateReason(dateParseError, dateToParse, "empty_str"))
+}
+
+// parseDate пробует форматы из определения по очереди, а если ни один не подошёл - общий разбор русских дат
+func (p *declarativeParser) parseDate(dateToParse string) (time.Time, error) {
+	processedStr := dateToParse
+	if p.def.RussianMonths {
+		processedStr = rudate.ReplaceMonths(processedStr)
+	}
+
+	var errs []string
+	for _, layout := range p.def.DateLayouts {
+		if layout == "RFC3339" {
+			parsedTime, err := time.Parse(time.RFC3339, processedStr)
+			if err == nil {
+				return parsedTime.In(p.dates.Location()), nil
+			}
+			errs = append(errs, err.Error())
+			continue
+		}
+		parsedTime, err := time.ParseInLocation(layout, processedStr, p.dates.Location())
+		if err == nil {
+			return parsedTime, nil
+		}
+		errs = append(errs, err.Error())
+	}
+
+	parsedTime, err := p.dates.Parse(dateToParse)
+	if err == nil {
+		return parsedTime, nil
+	}
+	errs = append(errs, err.Error())
+	return time.Time{}, fmt.Errorf("ни один формат не подошёл для '%s': %s", dateToParse, strings.Join(errs, "; "))
+}
+
+func hasAnyPrefix(s string, prefixes []string) bool {
+	for _, prefix := range prefixes {
+		if strings.HasPrefix(s, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+func containsAny(s string, substrings []string) bool {
+	for _, sub := range substrings {
+		if strings.Contains(s, sub) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,132 @@
+package parsers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"parsing_media/rudate"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+)
+
+const declarativeListFixture = `<html><body><div class="feed">
+<a class="item" href="/news/1">Первая</a>
+<a class="item" href="/news/2?from=main">Вторая</a>
+<a class="item" href="/news/1">Повтор</a>
+<a class="item" href="/news/video/3">Видео</a>
+<a class="item" href="/about">О сайте</a>
+<a class="item" href="https://other.ru/news/4">Чужой сайт</a>
+<a class="item">Без ссылки</a>
+</div></body></html>`
+
+const declarativeArticleFixture = `<html><body>
+<h1 class="title">Заголовок статьи</h1>
+<div class="meta">Опубликовано: 7 октября 2025 в 10ч15м</div>
+<div class="text">
+  <p>Первый абзац.</p>
+  <div class="banner">Реклама</div>
+  <p>Реклама: подпишитесь на канал</p>
+  <p>Второй абзац.</p>
+</div>
+<div class="tags"><a>Политика</a><a>Госдума</a></div>
+</body></html>`
+
+// declarativeDefinition - определение сайта для тестового сервера; %s заменяется адресом сервера
+const declarativeDefinition = `
+name: TestSite
+site_url: %[1]s
+list_url: %[1]s/list
+link_selector: a.item
+link_prefixes: ["%[1]s/news/"]
+link_exclude: ["/video/"]
+strip_query: true
+title_selectors: ["h1.absent", "h1.title"]
+body_selector: div.text p
+body_remove: [div.banner]
+skip_paragraph_prefixes: ["Реклама:"]
+date_selector: div.meta
+date_layouts: ["Опубликовано: 2 01 2006 в 15ч04м"]
+russian_months: true
+tag_selector: div.tags a
+tags_mandatory: true
+`
+
+func TestDeclarativeParser(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		switch r.URL.Path {
+		case "/list":
+			w.Write([]byte(declarativeListFixture))
+		case "/news/1":
+			w.Write([]byte(declarativeArticleFixture))
+		case "/news/2":
+			// Статья без тегов: при tags_mandatory она не сохраняется
+			w.Write([]byte(`<html><body><h1 class="title">Без тегов</h1><div class="meta">8 октября 2025 | 09:00</div>
+<div class="text"><p>Текст.</p></div></body></html>`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	files := map[string]string{
+		"site.yaml":   fmt.Sprintf(declarativeDefinition, server.URL),
+		"_draft.yaml": "name: Черновик\n", // без обязательных полей: загрузка упала бы, если бы файл не пропускался
+		"notes.txt":   "не определение",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	definitions, err := LoadDefinitions(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(definitions) != 1 || definitions[0].Name != "TestSite" {
+		t.Fatalf("загружены определения %+v, want только TestSite", definitions)
+	}
+	p, err := NewDeclarativeParser(definitions[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	links, err := p.Links(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var hrefs []string
+	for _, link := range links {
+		hrefs = append(hrefs, link.Href)
+	}
+	if want := []string{server.URL + "/news/1", server.URL + "/news/2"}; !slices.Equal(hrefs, want) {
+		t.Errorf("Links = %q, want %q", hrefs, want)
+	}
+
+	result := p.ParsePage(ctx, LinkItem{Href: server.URL + "/news/1"})
+	if result.Error != nil || result.IsEmpty {
+		t.Fatalf("ParsePage: ошибка %v, причины %v", result.Error, result.Reasons)
+	}
+	got := result.Data
+	if got.Title != "Заголовок статьи" || got.Body != "Первый абзац.\n\nВторой абзац." {
+		t.Errorf("Title, Body = %q, %q", got.Title, got.Body)
+	}
+	if want := time.Date(2025, time.October, 7, 10, 15, 0, 0, rudate.Moscow); !got.Date.Equal(want) {
+		t.Errorf("Date = %v, want %v", got.Date, want)
+	}
+	if !slices.Equal(got.Tags, []string{"Политика", "Госдума"}) {
+		t.Errorf("Tags = %q", got.Tags)
+	}
+
+	result = p.ParsePage(ctx, LinkItem{Href: server.URL + "/news/2"})
+	if result.Error != nil || !result.IsEmpty || !slices.Contains(result.Reasons, "Tags:false") {
+		t.Errorf("статья без тегов: ошибка %v, пустая %v, причины %v; want пустую из-за тегов", result.Error, result.IsEmpty, result.Reasons)
+	}
+}
@@ -0,0 +1,44 @@
+package parsers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	. "parsing_media/utils"
+	"strings"
+)
+
+// feedLinks собирает ссылки из всех лент сайта. Ссылки приводятся к каноническому виду, чтобы метки utm_*
+// из лент не давали повторов. Ошибка возвращается, только если не удалось прочитать ни одну ленту.
+func (b *baseParser) feedLinks(ctx context.Context, accept func(href string) bool) ([]LinkItem, error) {
+	var foundLinks []LinkItem
+	var failures []error
+	seenLinks := make(map[string]bool)
+
+	for _, feedURL := range b.feeds {
+		items, err := GetFeedForClient(ctx, b.client, feedURL)
+		if err != nil {
+			failures = append(failures, err)
+			continue
+		}
+		for _, item := range items {
+			href := CanonicalURL(item.Link)
+			if !accept(href) || seenLinks[href] {
+				continue
+			}
+			seenLinks[href] = true
+			foundLinks = append(foundLinks, LinkItem{Href: href, Tags: item.Categories, Title: item.Title, Date: item.Published})
+		}
+	}
+
+	if len(failures) == len(b.feeds) {
+		return nil, errors.Join(failures...)
+	}
+	for _, err := range failures {
+		fmt.Printf("%s[%s]%s[WARNING] %v%s\n", ColorBlue, strings.ToUpper(b.name), ColorYellow, err, ColorReset)
+	}
+	if len(foundLinks) == 0 {
+		fmt.Printf("%s[%s]%s[WARNING] В лентах %s не найдено подходящих ссылок.%s\n", ColorBlue, strings.ToUpper(b.name), ColorYellow, strings.Join(b.feeds, ", "), ColorReset)
+	}
+	return foundLinks, nil
+}
@@ -1,11 +1,10 @@
 package parsers
 
 import (
+	"context"
 	"fmt"
-	"net/http"
 	. "parsing_media/utils"
 	"strings"
-	"sync"
 	"time"
 
 	"github.com/PuerkitoBio/goquery"
@@ -17,31 +16,31 @@ const (
 	numWorkersFontanka = 10
 )
 
-func FontankaMain() {
-	totalStartTime := time.Now()
-	articles, links := getLinksFontanka()
-	SaveData(articles)
-	totalElapsedTime := time.Since(totalStartTime)
-	fmt.Printf("%s[FONTANKA]%s[INFO] Парсер Fontanka.ru заверщил работу собрав (%d/%d): (%s)%s\n", ColorBlue, ColorYellow, len(articles), len(links), FormatDuration(totalElapsedTime), ColorReset)
+// fontankaMetadata - у fontanka.ru нет устойчивых селекторов для авторов, вводки и иллюстрации, они берутся из структурированной разметки
+var fontankaMetadata = metadataSelectors{
+	Body: "div.uiArticleBlockText_5xJo1",
 }
 
-func getLinksFontanka() ([]Data, []string) {
-	var foundLinks []string
-	seenLinks := make(map[string]bool)
+type fontankaParser struct {
+	baseParser
+}
 
-	client := &http.Client{
-		Timeout: 30 * time.Second,
-		Transport: &http.Transport{
-			MaxIdleConns:        100,
-			MaxIdleConnsPerHost: 10,
-			IdleConnTimeout:     90 * time.Second,
-		},
-	}
+func newFontankaParser() *fontankaParser {
+	return &fontankaParser{baseParser: newBaseParser("Fontanka", fontankaURL, numWorkersFontanka)}
+}
+
+func (p *fontankaParser) Links(ctx context.Context) ([]LinkItem, error) {
+	return p.links(ctx, p.htmlLinks, nil)
+}
+
+// htmlLinks собирает ссылки со страницы-списка сайта
+func (p *fontankaParser) htmlLinks(ctx context.Context) ([]LinkItem, error) {
+	var foundLinks []LinkItem
+	seenLinks := make(map[string]bool)
 
-	doc, err := GetHTMLForClient(client, fontankaURLNews)
+	doc, err := GetHTMLForClient(ctx, p.client, fontankaURLNews)
 	if err != nil {
-		fmt.Printf("%s[FONTANKA]%s[ERROR] Ошибка при получении HTML со страницы %s: %v%s\n", ColorBlue, ColorRed, fontankaURLNews, err, ColorReset)
-		return getPageFontanka(foundLinks)
+		return nil, fmt.Errorf("ошибка при получении HTML со страницы %s: %w", fontankaURLNews, err)
 	}
 
 	doc.Find("a.header_RL97A").Each(func(i int, s *goquery.Selection) {
@@ -56,7 +55,7 @@ func getLinksFontanka() ([]Data, []string) {
 
 			if fullURL != "" && !seenLinks[fullURL] {
 				seenLinks[fullURL] = true
-				foundLinks = append(foundLinks, fullURL)
+				foundLinks = append(foundLinks, LinkItem{Href: fullURL})
 			}
 		}
 	})
@@ -65,173 +64,64 @@ func getLinksFontanka() ([]Data, []string) {
 		fmt.Printf("%s[FONTANKA]%s[WARNING] Не найдено ссылок с селектором 'a.header_RL97A' на странице %s.%s\n", ColorBlue, ColorYellow, fontankaURLNews, ColorReset)
 	}
 
-	return getPageFontanka(foundLinks)
-}
-
-type pageParseResultFontanka struct {
-	Data    Data
-	Error   error
-	PageURL string
-	IsEmpty bool
-	Reasons []string
+	return foundLinks, nil
 }
 
-func getPageFontanka(links []string) ([]Data, []string) {
-	var products []Data
-	var errItems []string
-	totalLinks := len(links)
-
-	if totalLinks == 0 {
-		return products, links
-	}
-
-	httpClient := &http.Client{
-		Timeout: 30 * time.Second,
-		Transport: &http.Transport{
-			MaxIdleConns:        100,
-			MaxIdleConnsPerHost: numWorkersFontanka + 5,
-			IdleConnTimeout:     90 * time.Second,
-			MaxConnsPerHost:     numWorkersFontanka,
-		},
-	}
+func (p *fontankaParser) ParsePage(ctx context.Context, item LinkItem) PageResult {
+	pageURL := item.Href
 
-	resultsChan := make(chan pageParseResultFontanka, totalLinks)
-	linkChan := make(chan string, totalLinks)
+	var title, body string
+	var tags []string
+	var parsDate time.Time
 
-	for _, link := range links {
-		linkChan <- link
+	doc, err := p.article(ctx, pageURL)
+	if err != nil {
+		return PageResult{PageURL: pageURL, Error: fmt.Errorf("ошибка GET: %w", err)}
 	}
-	close(linkChan)
-
-	var wg sync.WaitGroup
 
-	actualNumWorkers := numWorkersFontanka
-	if totalLinks < numWorkersFontanka {
-		actualNumWorkers = totalLinks
-	}
+	// ===== ИСПРАВЛЕНО ЗДЕСЬ (Заголовок) =====
+	title = strings.TrimSpace(doc.Find("h1.title_5PHHQ").First().Text())
 
-	for i := 0; i < actualNumWorkers; i++ {
-		wg.Add(1)
-		go func() {
-			defer wg.Done()
-			for pageURL := range linkChan {
-				var title, body string
-				var tags []string
-				var parsDate time.Time
-
-				doc, err := GetHTMLForClient(httpClient, pageURL)
-				if err != nil {
-					resultsChan <- pageParseResultFontanka{PageURL: pageURL, Error: fmt.Errorf("ошибка GET: %w", err)}
-					continue
-				}
-
-				// ===== ИСПРАВЛЕНО ЗДЕСЬ (Заголовок) =====
-				title = strings.TrimSpace(doc.Find("h1.title_5PHHQ").First().Text())
-
-				var bodyBuilder strings.Builder
-				doc.Find("div.uiArticleBlockText_5xJo1.text-style-body-1.c-text.block_0DdLJ").Find("p, li, blockquote").Each(func(_ int, s *goquery.Selection) {
-					partText := strings.TrimSpace(s.Text())
-					if partText != "" {
-						if bodyBuilder.Len() > 0 {
-							bodyBuilder.WriteString("\n\n")
-						}
-						bodyBuilder.WriteString(partText)
-					}
-				})
-				body = bodyBuilder.String()
-
-				dateStr, exists := doc.Find("time.item_psvU3").Attr("datetime")
-				var dateParseError error
-				if exists {
-					parsedTime, err := time.Parse(time.RFC3339, dateStr)
-					if err != nil {
-						dateParseError = err
-						fmt.Printf("%s[FONTANKA]%s[WARNING] Ошибка парсинга даты из атрибута 'datetime': '%s' на %s: %v%s\n", ColorBlue, ColorYellow, dateStr, pageURL, err, ColorReset)
-					} else {
-						parsDate = parsedTime
-					}
-				} else {
-					fmt.Printf("%s[FONTANKA]%s[WARNING] Атрибут 'datetime' не найден у тега 'time.item_psvU3' на %s%s\n", ColorBlue, ColorYellow, pageURL, ColorReset)
-				}
-
-				// ===== ИСПРАВЛЕНО ЗДЕСЬ (Теги) =====
-				doc.Find("div.uiArticleHeaderTaxonomies_tpGPu a.taxonomy_tpGPu").Each(func(_ int, s *goquery.Selection) {
-					tagText := strings.TrimSpace(s.Text())
-					if tagText != "" {
-						tags = append(tags, tagText)
-					}
-				})
-
-				if title != "" && body != "" && !parsDate.IsZero() {
-					dataItem := Data{
-						Site:  fontankaURL,
-						Href:  pageURL,
-						Title: title,
-						Body:  body,
-						Date:  parsDate,
-						Tags:  tags,
-					}
-					hash, err := dataItem.Hashing()
-					if err != nil {
-						resultsChan <- pageParseResultFontanka{PageURL: pageURL, Error: fmt.Errorf("ошибка генерации хеша: %w", err)}
-						continue
-					}
-					dataItem.Hash = hash
-					resultsChan <- pageParseResultFontanka{Data: dataItem}
-				} else {
-					var reasons []string
-					if title == "" {
-						reasons = append(reasons, "T:false")
-					}
-					if body == "" {
-						reasons = append(reasons, "B:false")
-					}
-					if parsDate.IsZero() {
-						reasonDate := "D:false"
-						if dateParseError != nil {
-							reasonDate = fmt.Sprintf("D:false (err: %v, str: '%s')", dateParseError, dateStr)
-						} else if !exists {
-							reasonDate = "D:false (attr_missing)"
-						}
-						reasons = append(reasons, reasonDate)
-					}
-					resultsChan <- pageParseResultFontanka{PageURL: pageURL, IsEmpty: true, Reasons: reasons}
-				}
+	var bodyBuilder strings.Builder
+	doc.Find("div.uiArticleBlockText_5xJo1.text-style-body-1.c-text.block_0DdLJ").Find("p, li, blockquote").Each(func(_ int, s *goquery.Selection) {
+		partText := strings.TrimSpace(s.Text())
+		if partText != "" {
+			if bodyBuilder.Len() > 0 {
+				bodyBuilder.WriteString("\n\n")
 			}
-		}()
-	}
-
-	go func() {
-		wg.Wait()
-		close(resultsChan)
-	}()
-
-	for result := range resultsChan {
-		if result.Error != nil {
-			errItems = append(errItems, fmt.Sprintf("%s (%s)", result.PageURL, result.Error.Error()))
-		} else if result.IsEmpty {
-			errItems = append(errItems, fmt.Sprintf("%s (нет данных: %s)", result.PageURL, strings.Join(result.Reasons, ", ")))
+			bodyBuilder.WriteString(partText)
+		}
+	})
+	body = bodyBuilder.String()
+
+	dateStr, exists := doc.Find("time.item_psvU3").Attr("datetime")
+	var dateParseError error
+	if exists {
+		parsedTime, err := dates.ParseISO(dateStr)
+		if err != nil {
+			dateParseError = err
+			fmt.Printf("%s[FONTANKA]%s[WARNING] Ошибка парсинга даты из атрибута 'datetime': '%s' на %s: %v%s\n", ColorBlue, ColorYellow, dateStr, pageURL, err, ColorReset)
 		} else {
-			products = append(products, result.Data)
+			parsDate = parsedTime
 		}
+	} else {
+		fmt.Printf("%s[FONTANKA]%s[WARNING] Атрибут 'datetime' не найден у тега 'time.item_psvU3' на %s%s\n", ColorBlue, ColorYellow, pageURL, ColorReset)
 	}
 
-	if len(products) > 0 {
-		if len(errItems) > 0 {
-			fmt.Printf("%s[FONTANKA]%s[WARNING] Не удалось обработать %d из %d страниц (или отсутствовали данные):%s\n", ColorBlue, ColorYellow, len(errItems), totalLinks, ColorReset)
-			for idx, itemMessage := range errItems {
-				fmt.Printf("%s  %d. %s%s\n", ColorYellow, idx+1, itemMessage, ColorReset)
-			}
-		}
-	} else if totalLinks > 0 {
-		fmt.Printf("%s[FONTANKA]%s[ERROR] Парсинг статей Fontanka.ru завершен, но не удалось собрать данные ни с одной из %d страниц.%s\n", ColorBlue, ColorRed, totalLinks, ColorReset)
-		if len(errItems) > 0 {
-			fmt.Printf("%s[FONTANKA]%s[INFO] Список страниц с ошибками или без данных:%s\n", ColorBlue, ColorYellow, ColorReset)
-			for idx, itemMessage := range errItems {
-				fmt.Printf("%s  %d. %s%s\n", ColorYellow, idx+1, itemMessage, ColorReset)
-			}
+	// ===== ИСПРАВЛЕНО ЗДЕСЬ (Теги) =====
+	doc.Find("div.uiArticleHeaderTaxonomies_tpGPu a.taxonomy_tpGPu").Each(func(_ int, s *goquery.Selection) {
+		tagText := strings.TrimSpace(s.Text())
+		if tagText != "" {
+			tags = append(tags, tagText)
 		}
-	}
+	})
 
-	return products, links
+	return finishPage(doc, item, fontankaMetadata, Data{
+		Site:  fontankaURL,
+		Href:  pageURL,
+		Title: title,
+		Body:  body,
+		Date:  parsDate,
+		Tags:  tags,
+	}, false, dateReason(dateParseError, dateStr, "attr_missing"))
 }
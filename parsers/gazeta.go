@@ -1,11 +1,10 @@
 package parsers
 
 import (
+	"context"
 	"fmt"
-	"net/http"
 	. "parsing_media/utils"
 	"strings"
-	"sync"
 	"time"
 
 	"github.com/PuerkitoBio/goquery"
@@ -17,31 +16,35 @@ const (
 	numWorkersGazeta = 10
 )
 
-func GazetaMain() {
-	totalStartTime := time.Now()
-	articles, links := getLinksGazeta()
-	SaveData(articles)
-	totalElapsedTime := time.Since(totalStartTime)
-	fmt.Printf("%s[GAZETA]%s[INFO] Парсер Gazeta.ru заверщил работу собрав (%d/%d): (%s)%s\n", ColorBlue, ColorYellow, len(articles), len(links), FormatDuration(totalElapsedTime), ColorReset)
+// gazetaMetadata - необязательные поля статьи на страницах gazeta.ru
+var gazetaMetadata = metadataSelectors{
+	Body:    "div.b_article-text",
+	Authors: "div.b_article-authors a.author-name",
+	Lead:    "div.b_article-header .subheader",
+	Rubric:  "div.b_article-breadcrumb-item a.rubric",
+	Image:   "div.b_article-media img",
 }
 
-func getLinksGazeta() ([]Data, []string) {
-	var foundLinks []string
-	seenLinks := make(map[string]bool)
+type gazetaParser struct {
+	baseParser
+}
 
-	client := &http.Client{
-		Timeout: 30 * time.Second,
-		Transport: &http.Transport{
-			MaxIdleConns:        100,
-			MaxIdleConnsPerHost: 10,
-			IdleConnTimeout:     90 * time.Second,
-		},
-	}
+func newGazetaParser() *gazetaParser {
+	return &gazetaParser{baseParser: newBaseParser("Gazeta", gazetaURL, numWorkersGazeta)}
+}
 
-	doc, err := GetHTMLForClient(client, gazetaURLNews)
+func (p *gazetaParser) Links(ctx context.Context) ([]LinkItem, error) {
+	return p.links(ctx, p.htmlLinks, nil)
+}
+
+// htmlLinks собирает ссылки со страницы-списка сайта
+func (p *gazetaParser) htmlLinks(ctx context.Context) ([]LinkItem, error) {
+	var foundLinks []LinkItem
+	seenLinks := make(map[string]bool)
+
+	doc, err := GetHTMLForClient(ctx, p.client, gazetaURLNews)
 	if err != nil {
-		fmt.Printf("%s[GAZETA]%s[ERROR] Не удалось загрузить основную страницу новостей %s после всех попыток. Сбор ссылок прерван.%s\n", ColorBlue, ColorRed, gazetaURLNews, ColorReset)
-		return getPageGazeta(foundLinks)
+		return nil, fmt.Errorf("не удалось загрузить основную страницу новостей %s после всех попыток: %w", gazetaURLNews, err)
 	}
 
 	doc.Find("a.b_ear.m_techlisting").Each(func(i int, s *goquery.Selection) {
@@ -55,7 +58,7 @@ func getLinksGazeta() ([]Data, []string) {
 			}
 			if fullHref != "" && !seenLinks[fullHref] {
 				seenLinks[fullHref] = true
-				foundLinks = append(foundLinks, fullHref)
+				foundLinks = append(foundLinks, LinkItem{Href: fullHref})
 			}
 		}
 	})
@@ -63,179 +66,65 @@ func getLinksGazeta() ([]Data, []string) {
 	if len(foundLinks) == 0 {
 		fmt.Printf("%s[GAZETA]%s[WARNING] Не найдено ссылок с селектором 'a.b_ear.m_techlisting' на странице %s.%s\n", ColorBlue, ColorYellow, gazetaURLNews, ColorReset)
 	}
-	return getPageGazeta(foundLinks)
-}
-
-type pageParseResultGazeta struct {
-	Data    Data
-	Error   error
-	PageURL string
-	IsEmpty bool
-	Reasons []string
+	return foundLinks, nil
 }
 
-func getPageGazeta(links []string) ([]Data, []string) {
-	var products []Data
-	var errItems []string
-	totalLinks := len(links)
-
-	if totalLinks == 0 {
-		return products, links
-	}
-
+func (p *gazetaParser) ParsePage(ctx context.Context, item LinkItem) PageResult {
+	pageURL := item.Href
 	tagsAreMandatoryForThisParser := false
 
-	httpClient := &http.Client{
-		Timeout: 30 * time.Second,
-		Transport: &http.Transport{
-			MaxIdleConns:        100,
-			MaxIdleConnsPerHost: numWorkersGazeta + 5,
-			IdleConnTimeout:     90 * time.Second,
-			MaxConnsPerHost:     numWorkersGazeta,
-		},
-	}
-
-	resultsChan := make(chan pageParseResultGazeta, totalLinks)
-	linkChan := make(chan string, totalLinks)
+	var title, body string
+	var tags []string
+	var parsDate time.Time
 
-	for _, link := range links {
-		linkChan <- link
+	doc, err := p.article(ctx, pageURL)
+	if err != nil {
+		return PageResult{PageURL: pageURL, Error: fmt.Errorf("ошибка GET: %w", err)}
 	}
-	close(linkChan)
 
-	var wg sync.WaitGroup
+	title = strings.TrimSpace(doc.Find("h1.headline").First().Text())
 
-	actualNumWorkers := numWorkersGazeta
-	if totalLinks < numWorkersGazeta {
-		actualNumWorkers = totalLinks
-	}
+	var accumulatedBodyParts []string
+	doc.Find("div.b_article-text p").Each(func(_ int, pSelection *goquery.Selection) {
+		paragraphText := strings.TrimSpace(pSelection.Text())
+		if paragraphText != "" {
+			accumulatedBodyParts = append(accumulatedBodyParts, paragraphText)
+		}
+	})
+	body = strings.Join(accumulatedBodyParts, "\n\n")
 
-	for i := 0; i < actualNumWorkers; i++ {
-		wg.Add(1)
-		go func() {
-			defer wg.Done()
-			for pageURL := range linkChan {
-				var title, body string
-				var tags []string
-				var parsDate time.Time
-
-				doc, err := GetHTMLForClient(httpClient, pageURL)
-				if err != nil {
-					resultsChan <- pageParseResultGazeta{PageURL: pageURL, Error: fmt.Errorf("ошибка GET: %w", err)}
-					continue
-				}
-
-				title = strings.TrimSpace(doc.Find("h1.headline").First().Text())
-
-				var accumulatedBodyParts []string
-				doc.Find("div.b_article-text p").Each(func(_ int, pSelection *goquery.Selection) {
-					paragraphText := strings.TrimSpace(pSelection.Text())
-					if paragraphText != "" &&
-						!strings.Contains(paragraphText, "Что думаешь?") &&
-						!strings.HasPrefix(paragraphText, "Ранее ") {
-						accumulatedBodyParts = append(accumulatedBodyParts, paragraphText)
-					}
-				})
-				body = strings.Join(accumulatedBodyParts, "\n\n")
-
-				if title != "" && body != "" && strings.HasPrefix(body, title) {
-					body = strings.TrimPrefix(body, title)
-					body = strings.TrimSpace(body)
-				}
-
-				dateSelector := `time.time[itemprop="datePublished"]`
-				dateStr, exists := doc.Find(dateSelector).Attr("datetime")
-				var dateParseError error
-				if exists {
-					parsedTime, err := time.Parse(time.RFC3339, dateStr)
-					if err != nil {
-						dateParseError = err
-						fmt.Printf("%s[GAZETA]%s[WARNING] Ошибка парсинга даты из атрибута 'datetime': '%s' (селектор: '%s') на %s: %v%s\n", ColorBlue, ColorYellow, dateStr, dateSelector, pageURL, err, ColorReset)
-					} else {
-						parsDate = parsedTime
-					}
-				} else {
-					fmt.Printf("%s[GAZETA]%s[INFO] Атрибут 'datetime' с датой не найден (селектор: '%s') на %s%s\n", ColorBlue, ColorYellow, dateSelector, pageURL, ColorReset)
-				}
-
-				rubricSelector := `div.b_article-breadcrumb-item a.rubric`
-				rubricText := strings.TrimSpace(doc.Find(rubricSelector).First().Text())
-				if rubricText != "" {
-					tags = append(tags, rubricText)
-				}
-
-				if title != "" && body != "" && !parsDate.IsZero() && (!tagsAreMandatoryForThisParser || len(tags) != 0) {
-					dataItem := Data{
-						Site:  gazetaURL,
-						Href:  pageURL,
-						Title: title,
-						Body:  body,
-						Date:  parsDate,
-						Tags:  tags,
-					}
-					hash, err := dataItem.Hashing()
-					if err != nil {
-						resultsChan <- pageParseResultGazeta{PageURL: pageURL, Error: fmt.Errorf("ошибка генерации хеша: %w", err)}
-						continue
-					}
-					dataItem.Hash = hash
-					resultsChan <- pageParseResultGazeta{Data: dataItem}
-				} else {
-					var reasons []string
-					if title == "" {
-						reasons = append(reasons, "T:false")
-					}
-					if body == "" {
-						reasons = append(reasons, "B:false")
-					}
-					if parsDate.IsZero() {
-						reasonDate := "D:false"
-						if dateParseError != nil {
-							reasonDate = fmt.Sprintf("D:false (err: %v, str: '%s')", dateParseError, dateStr)
-						} else if !exists {
-							reasonDate = "D:false (attr_missing)"
-						}
-						reasons = append(reasons, reasonDate)
-					}
-					if tagsAreMandatoryForThisParser && len(tags) == 0 {
-						reasons = append(reasons, "Tags:false")
-					}
-					resultsChan <- pageParseResultGazeta{PageURL: pageURL, IsEmpty: true, Reasons: reasons}
-				}
-			}
-		}()
+	if title != "" && body != "" && strings.HasPrefix(body, title) {
+		body = strings.TrimPrefix(body, title)
+		body = strings.TrimSpace(body)
 	}
 
-	go func() {
-		wg.Wait()
-		close(resultsChan)
-	}()
-
-	for result := range resultsChan {
-		if result.Error != nil {
-			errItems = append(errItems, fmt.Sprintf("%s (%s)", result.PageURL, result.Error.Error()))
-		} else if result.IsEmpty {
-			errItems = append(errItems, fmt.Sprintf("%s (нет данных: %s)", result.PageURL, strings.Join(result.Reasons, ", ")))
+	dateSelector := `time.time[itemprop="datePublished"]`
+	dateStr, exists := doc.Find(dateSelector).Attr("datetime")
+	var dateParseError error
+	if exists {
+		parsedTime, err := dates.ParseISO(dateStr)
+		if err != nil {
+			dateParseError = err
+			fmt.Printf("%s[GAZETA]%s[WARNING] Ошибка парсинга даты из атрибута 'datetime': '%s' (селектор: '%s') на %s: %v%s\n", ColorBlue, ColorYellow, dateStr, dateSelector, pageURL, err, ColorReset)
 		} else {
-			products = append(products, result.Data)
+			parsDate = parsedTime
 		}
+	} else {
+		fmt.Printf("%s[GAZETA]%s[INFO] Атрибут 'datetime' с датой не найден (селектор: '%s') на %s%s\n", ColorBlue, ColorYellow, dateSelector, pageURL, ColorReset)
 	}
 
-	if len(products) > 0 {
-		if len(errItems) > 0 {
-			fmt.Printf("%s[GAZETA]%s[WARNING] Не удалось обработать %d из %d страниц (или отсутствовали данные):%s\n", ColorBlue, ColorYellow, len(errItems), totalLinks, ColorReset)
-			for idx, itemMessage := range errItems {
-				fmt.Printf("%s  %d. %s%s\n", ColorYellow, idx+1, itemMessage, ColorReset)
-			}
-		}
-	} else if totalLinks > 0 {
-		fmt.Printf("%s[GAZETA]%s[ERROR] Парсинг статей Gazeta.ru завершен, но не удалось собрать данные ни с одной из %d страниц.%s\n", ColorBlue, ColorRed, totalLinks, ColorReset)
-		if len(errItems) > 0 {
-			fmt.Printf("%s[GAZETA]%s[INFO] Список страниц с ошибками или без данных:%s\n", ColorBlue, ColorYellow, ColorReset)
-			for idx, itemMessage := range errItems {
-				fmt.Printf("%s  %d. %s%s\n", ColorYellow, idx+1, itemMessage, ColorReset)
-			}
-		}
+	rubricSelector := `div.b_article-breadcrumb-item a.rubric`
+	rubricText := strings.TrimSpace(doc.Find(rubricSelector).First().Text())
+	if rubricText != "" {
+		tags = append(tags, rubricText)
 	}
-	return products, links
+
+	return finishPage(doc, item, gazetaMetadata, Data{
+		Site:  gazetaURL,
+		Href:  pageURL,
+		Title: title,
+		Body:  body,
+		Date:  parsDate,
+		Tags:  tags,
+	}, tagsAreMandatoryForThisParser, dateReason(dateParseError, dateStr, "attr_missing"))
 }
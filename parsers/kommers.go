@@ -1,11 +1,10 @@
 package parsers
 
 import (
+	"context"
 	"fmt"
-	"net/http"
 	. "parsing_media/utils"
 	"strings"
-	"sync"
 	"time"
 
 	"github.com/PuerkitoBio/goquery"
@@ -14,39 +13,38 @@ import (
 const (
 	kommersURL        = "https://www.kommersant.ru"
 	kommersURLNews    = "https://www.kommersant.ru/lenta"
+	kommersFeedURL    = "https://www.kommersant.ru/RSS/news.xml"
 	numWorkersKommers = 10
 )
 
-type LinkItem struct {
-	Href string
-	Tags []string
+// kommersMetadata - необязательные поля статьи на страницах kommersant.ru
+var kommersMetadata = metadataSelectors{
+	Body:    "div.article_text_wrapper",
+	Authors: "p.document_authors",
+	Lead:    "h2.doc_header__subheader",
+	Image:   "figure.doc_media img",
 }
 
-func KommersMain() {
-	totalStartTime := time.Now()
-	articles, links := getLinksKommers()
-	SaveData(articles)
-	totalElapsedTime := time.Since(totalStartTime)
-	fmt.Printf("%s[KOMMERSANT]%s[INFO] Парсер Kommersant.ru заверщил работу собрав (%d/%d): (%s)%s\n", ColorBlue, ColorYellow, len(articles), len(links), FormatDuration(totalElapsedTime), ColorReset)
+type kommersParser struct {
+	baseParser
 }
 
-func getLinksKommers() ([]Data, []LinkItem) {
+func newKommersParser() *kommersParser {
+	return &kommersParser{baseParser: newBaseParser("Kommersant", kommersURL, numWorkersKommers).withFeeds(kommersFeedURL)}
+}
+
+func (p *kommersParser) Links(ctx context.Context) ([]LinkItem, error) {
+	return p.links(ctx, p.htmlLinks, func(href string) bool { return strings.HasPrefix(href, kommersURL+"/doc/") })
+}
+
+// htmlLinks собирает ссылки со страницы-списка сайта
+func (p *kommersParser) htmlLinks(ctx context.Context) ([]LinkItem, error) {
 	var foundLinkItems []LinkItem
 	seenLinks := make(map[string]bool)
 
-	client := &http.Client{
-		Timeout: 30 * time.Second,
-		Transport: &http.Transport{
-			MaxIdleConns:        100,
-			MaxIdleConnsPerHost: 10,
-			IdleConnTimeout:     90 * time.Second,
-		},
-	}
-
-	doc, err := GetHTMLForClient(client, kommersURLNews)
+	doc, err := GetHTMLForClient(ctx, p.client, kommersURLNews)
 	if err != nil {
-		fmt.Printf("%s[KOMMERSANT]%s[ERROR] Ошибка при получении HTML со страницы %s: %v%s\n", ColorBlue, ColorRed, kommersURLNews, err, ColorReset)
-		return nil, foundLinkItems
+		return nil, fmt.Errorf("ошибка при получении HTML со страницы %s: %w", kommersURLNews, err)
 	}
 
 	articleSelector := "article.uho.rubric_lenta__item.js-article"
@@ -94,180 +92,58 @@ func getLinksKommers() ([]Data, []LinkItem) {
 		fmt.Printf("%s[KOMMERSANT]%s[WARNING] Не найдено ссылок с тегами на странице %s (селектор статьи: '%s').%s\n", ColorBlue, ColorYellow, kommersURLNews, articleSelector, ColorReset)
 	}
 
-	return getPageKommers(foundLinkItems)
-}
-
-type pageParseResultKommers struct {
-	Data          Data
-	Error         error
-	PageURL       string
-	PreloadedTags []string
-	IsEmpty       bool
-	Reasons       []string
+	return foundLinkItems, nil
 }
 
-func getPageKommers(linkItems []LinkItem) ([]Data, []LinkItem) {
-	var products []Data
-	var errItems []string
-	totalLinks := len(linkItems)
-
-	if totalLinks == 0 {
-		return products, linkItems
-	}
-
+func (p *kommersParser) ParsePage(ctx context.Context, item LinkItem) PageResult {
+	pageURL := item.Href
+	preloadedTags := item.Tags
 	tagsAreMandatoryForThisParser := true
 
-	httpClient := &http.Client{
-		Timeout: 30 * time.Second,
-		Transport: &http.Transport{
-			MaxIdleConns:        100,
-			MaxIdleConnsPerHost: numWorkersKommers + 5,
-			IdleConnTimeout:     90 * time.Second,
-			MaxConnsPerHost:     numWorkersKommers,
-		},
-	}
-
-	resultsChan := make(chan pageParseResultKommers, totalLinks)
-	linkItemChan := make(chan LinkItem, totalLinks)
-
-	for _, item := range linkItems {
-		linkItemChan <- item
-	}
-	close(linkItemChan)
-
-	var wg sync.WaitGroup
-
-	actualNumWorkers := numWorkersKommers
-	if totalLinks < numWorkersKommers {
-		actualNumWorkers = totalLinks
-	}
+	var title, body string
+	var parsDate time.Time
 
-	for i := 0; i < actualNumWorkers; i++ {
-		wg.Add(1)
-		go func() {
-			defer wg.Done()
-			for item := range linkItemChan {
-				pageURL := item.Href
-				preloadedTags := item.Tags
-				var title, body string
-				var parsDate time.Time
-
-				doc, err := GetHTMLForClient(httpClient, pageURL)
-				if err != nil {
-					resultsChan <- pageParseResultKommers{PageURL: pageURL, PreloadedTags: preloadedTags, Error: fmt.Errorf("ошибка GET: %w", err)}
-					continue
-				}
-
-				title = strings.TrimSpace(doc.Find("h1.doc_header__name.js-search-mark").First().Text())
-
-				var bodyBuilder strings.Builder
-				doc.Find("div.article_text_wrapper.js-search-mark p.doc__text").Not(".document_authors").Each(func(_ int, s *goquery.Selection) {
-					paragraphText := strings.TrimSpace(s.Text())
-					if strings.Contains(paragraphText, "Материал дополняется") ||
-						strings.HasPrefix(paragraphText, "Читайте также:") ||
-						strings.HasPrefix(paragraphText, "Фото:") ||
-						paragraphText == "" {
-						return
-					}
-					if bodyBuilder.Len() > 0 {
-						bodyBuilder.WriteString("\n\n")
-					}
-					bodyBuilder.WriteString(paragraphText)
-				})
-				body = bodyBuilder.String()
-
-				dateSelector := `time.doc_header__publish_time`
-				dateStr, exists := doc.Find(dateSelector).Attr("datetime")
-				var dateParseError error
-				if exists {
-					parsedTime, err := time.Parse(time.RFC3339, dateStr)
-					if err != nil {
-						dateParseError = err
-						fmt.Printf("%s[KOMMERSANT]%s[WARNING] Ошибка парсинга даты: '%s' (селектор: '%s') на %s: %v%s\n", ColorBlue, ColorYellow, dateStr, dateSelector, pageURL, err, ColorReset)
-					} else {
-						parsDate = parsedTime
-					}
-				} else {
-					fmt.Printf("%s[KOMMERSANT]%s[INFO] Атрибут 'datetime' с датой не найден (селектор: '%s') на %s%s\n", ColorBlue, ColorYellow, dateSelector, pageURL, ColorReset)
-				}
-
-				allMandatoryFieldsPresent := title != "" && body != "" && !parsDate.IsZero()
-				if tagsAreMandatoryForThisParser {
-					allMandatoryFieldsPresent = allMandatoryFieldsPresent && len(preloadedTags) > 0
-				}
-
-				if allMandatoryFieldsPresent {
-					dataItem := Data{
-						Site:  kommersURL,
-						Href:  pageURL,
-						Title: title,
-						Body:  body,
-						Date:  parsDate,
-						Tags:  preloadedTags,
-					}
-					hash, err := dataItem.Hashing()
-					if err != nil {
-						resultsChan <- pageParseResultKommers{PageURL: pageURL, Error: fmt.Errorf("ошибка генерации хеша: %w", err)}
-						continue
-					}
-					dataItem.Hash = hash
-					resultsChan <- pageParseResultKommers{Data: dataItem}
-				} else {
-					var reasons []string
-					if title == "" {
-						reasons = append(reasons, "T:false")
-					}
-					if body == "" {
-						reasons = append(reasons, "B:false")
-					}
-					if parsDate.IsZero() {
-						reasonDate := "D:false"
-						if dateParseError != nil {
-							reasonDate = fmt.Sprintf("D:false (err: %v, str: '%s')", dateParseError, dateStr)
-						} else if !exists {
-							reasonDate = "D:false (attr_missing)"
-						}
-						reasons = append(reasons, reasonDate)
-					}
-					if tagsAreMandatoryForThisParser && len(preloadedTags) == 0 {
-						reasons = append(reasons, "Tags:false(mandatory_on_feed)")
-					}
-					resultsChan <- pageParseResultKommers{PageURL: pageURL, PreloadedTags: preloadedTags, IsEmpty: true, Reasons: reasons}
-				}
-			}
-		}()
+	doc, err := p.article(ctx, pageURL)
+	if err != nil {
+		return PageResult{PageURL: pageURL, Error: fmt.Errorf("ошибка GET: %w", err)}
 	}
 
-	go func() {
-		wg.Wait()
-		close(resultsChan)
-	}()
+	title = strings.TrimSpace(doc.Find("h1.doc_header__name.js-search-mark").First().Text())
 
-	for result := range resultsChan {
-		if result.Error != nil {
-			errItems = append(errItems, fmt.Sprintf("%s (%s)", result.PageURL, result.Error.Error()))
-		} else if result.IsEmpty {
-			errItems = append(errItems, fmt.Sprintf("%s (нет данных: %s, теги с фида: %v)", result.PageURL, strings.Join(result.Reasons, ", "), result.PreloadedTags))
-		} else {
-			products = append(products, result.Data)
+	var bodyBuilder strings.Builder
+	doc.Find("div.article_text_wrapper.js-search-mark p.doc__text").Not(".document_authors").Each(func(_ int, s *goquery.Selection) {
+		paragraphText := strings.TrimSpace(s.Text())
+		if paragraphText == "" {
+			return
 		}
-	}
-
-	if len(products) > 0 {
-		if len(errItems) > 0 {
-			fmt.Printf("%s[KOMMERSANT]%s[WARNING] Не удалось обработать %d из %d страниц (или отсутствовали данные):%s\n", ColorBlue, ColorYellow, len(errItems), totalLinks, ColorReset)
-			for idx, itemMessage := range errItems {
-				fmt.Printf("%s  %d. %s%s\n", ColorYellow, idx+1, itemMessage, ColorReset)
-			}
+		if bodyBuilder.Len() > 0 {
+			bodyBuilder.WriteString("\n\n")
 		}
-	} else if totalLinks > 0 {
-		fmt.Printf("%s[KOMMERSANT]%s[ERROR] Парсинг статей Kommersant.ru завершен, но не удалось собрать данные ни с одной из %d страниц.%s\n", ColorBlue, ColorRed, totalLinks, ColorReset)
-		if len(errItems) > 0 {
-			fmt.Printf("%s[KOMMERSANT]%s[INFO] Список страниц с ошибками или без данных:%s\n", ColorBlue, ColorYellow, ColorReset)
-			for idx, itemMessage := range errItems {
-				fmt.Printf("%s  %d. %s%s\n", ColorYellow, idx+1, itemMessage, ColorReset)
-			}
+		bodyBuilder.WriteString(paragraphText)
+	})
+	body = bodyBuilder.String()
+
+	dateSelector := `time.doc_header__publish_time`
+	dateStr, exists := doc.Find(dateSelector).Attr("datetime")
+	var dateParseError error
+	if exists {
+		parsedTime, err := dates.ParseISO(dateStr)
+		if err != nil {
+			dateParseError = err
+			fmt.Printf("%s[KOMMERSANT]%s[WARNING] Ошибка парсинга даты: '%s' (селектор: '%s') на %s: %v%s\n", ColorBlue, ColorYellow, dateStr, dateSelector, pageURL, err, ColorReset)
+		} else {
+			parsDate = parsedTime
 		}
+	} else {
+		fmt.Printf("%s[KOMMERSANT]%s[INFO] Атрибут 'datetime' с датой не найден (селектор: '%s') на %s%s\n", ColorBlue, ColorYellow, dateSelector, pageURL, ColorReset)
 	}
-	return products, linkItems
+
+	return finishPage(doc, item, kommersMetadata, Data{
+		Site:  kommersURL,
+		Href:  pageURL,
+		Title: title,
+		Body:  body,
+		Date:  parsDate,
+		Tags:  preloadedTags,
+	}, tagsAreMandatoryForThisParser, dateReason(dateParseError, dateStr, "attr_missing"))
 }
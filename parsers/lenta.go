@@ -1,11 +1,10 @@
 package parsers
 
 import (
+	"context"
 	"fmt"
-	"net/http"
 	. "parsing_media/utils"
 	"strings"
-	"sync"
 	"time"
 
 	"github.com/PuerkitoBio/goquery"
@@ -14,37 +13,68 @@ import (
 const (
 	lentaURL        = "https://lenta.ru"
 	lentaURLPage    = "https://lenta.ru/parts/news/"
+	lentaFeedURL    = "https://lenta.ru/rss/news"
 	numWorkersLenta = 10
+
+	// Архив новостей по дням (см. archiveURL)
+	lentaArchiveURL      = "https://lenta.ru/news/{2006/01/02}/"
+	lentaArchivePagesURL = "https://lenta.ru/news/{2006/01/02}/page/{page}/"
 )
 
-func LentaMain() {
-	totalStartTime := time.Now()
-	articles, links := getLinksLenta()
-	SaveData(articles)
-	totalElapsedTime := time.Since(totalStartTime)
-	fmt.Printf("%s[LENTA]%s[INFO] Парсер Lenta.ru заверщил работу собрав (%d/%d): (%s)%s\n", ColorBlue, ColorYellow, len(articles), len(links), FormatDuration(totalElapsedTime), ColorReset)
+// lentaMetadata - необязательные поля статьи на страницах lenta.ru
+var lentaMetadata = metadataSelectors{
+	Body:    ".topic-body__content",
+	Authors: ".topic-authors__name",
+	Lead:    ".topic-body__title-yandex",
+	Rubric:  "a.topic-header__item.topic-header__rubric",
+	Image:   ".topic-body__title-image img.picture__image",
 }
 
-func getLinksLenta() ([]Data, []string) {
-	var foundLinks []string
-	seenLinks := make(map[string]bool)
+type lentaParser struct {
+	baseParser
+}
+
+func newLentaParser() *lentaParser {
+	return &lentaParser{baseParser: newBaseParser("Lenta", lentaURL, numWorkersLenta).withFeeds(lentaFeedURL)}
+}
+
+func (p *lentaParser) Links(ctx context.Context) ([]LinkItem, error) {
+	return p.links(ctx, p.htmlLinks, func(href string) bool { return strings.HasPrefix(href, lentaURL+"/news/") })
+}
+
+// htmlLinks собирает ссылки со страницы-списка сайта
+func (p *lentaParser) htmlLinks(ctx context.Context) ([]LinkItem, error) {
+	linkSelector := "a.card-full-news._parts-news"
+	foundLinks, err := p.listLinks(ctx, lentaURLPage, linkSelector)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(foundLinks) == 0 {
+		fmt.Printf("%s[LENTA]%s[WARNING] Не найдено ссылок с селектором '%s' на странице %s.%s\n", ColorBlue, ColorYellow, linkSelector, lentaURLPage, ColorReset)
+	}
+
+	return foundLinks, nil
+}
 
-	client := &http.Client{
-		Timeout: 30 * time.Second,
-		Transport: &http.Transport{
-			MaxIdleConns:        100,
-			MaxIdleConnsPerHost: 10,
-			IdleConnTimeout:     90 * time.Second,
-		},
+func (p *lentaParser) ArchiveLinks(ctx context.Context, day time.Time, page int) ([]LinkItem, error) {
+	archivePage, ok := archivePageURL(lentaArchiveURL, lentaArchivePagesURL, day, page)
+	if !ok {
+		return nil, nil
 	}
+	return p.listLinks(ctx, archivePage, "a.card-full-news")
+}
+
+// listLinks собирает ссылки на статьи lenta.ru со страницы-списка listURL
+func (p *lentaParser) listLinks(ctx context.Context, listURL, linkSelector string) ([]LinkItem, error) {
+	var foundLinks []LinkItem
+	seenLinks := make(map[string]bool)
 
-	doc, err := GetHTMLForClient(client, lentaURLPage)
+	doc, err := GetHTMLForClient(ctx, p.client, listURL)
 	if err != nil {
-		fmt.Printf("%s[LENTA]%s[ERROR] Ошибка при получении HTML со страницы %s: %v%s\n", ColorBlue, ColorRed, lentaURLPage, err, ColorReset)
-		return getPageLenta(foundLinks)
+		return nil, fmt.Errorf("ошибка при получении HTML со страницы %s: %w", listURL, err)
 	}
 
-	linkSelector := "a.card-full-news._parts-news"
 	doc.Find(linkSelector).Each(func(i int, s *goquery.Selection) {
 		href, exists := s.Attr("href")
 		if exists {
@@ -57,207 +87,68 @@ func getLinksLenta() ([]Data, []string) {
 
 			if fullHref != "" && !seenLinks[fullHref] {
 				seenLinks[fullHref] = true
-				foundLinks = append(foundLinks, fullHref)
+				foundLinks = append(foundLinks, LinkItem{Href: fullHref})
 			}
 		}
 	})
 
-	if len(foundLinks) == 0 {
-		fmt.Printf("%s[LENTA]%s[WARNING] Не найдено ссылок с селектором '%s' на странице %s.%s\n", ColorBlue, ColorYellow, linkSelector, lentaURLPage, ColorReset)
-	}
-
-	return getPageLenta(foundLinks)
-}
-
-type pageParseResultLenta struct {
-	Data    Data
-	Error   error
-	PageURL string
-	IsEmpty bool
-	Reasons []string
+	return foundLinks, nil
 }
 
-func getPageLenta(links []string) ([]Data, []string) {
-	var products []Data
-	var errItems []string
-	totalLinks := len(links)
-
-	if totalLinks == 0 {
-		return products, links
-	}
-
-	locationPlus3 := time.FixedZone("UTC+3", 3*60*60)
-	dateLayout := "15:04, 2 01 2006"
+func (p *lentaParser) ParsePage(ctx context.Context, item LinkItem) PageResult {
+	pageURL := item.Href
 	tagsAreMandatory := true
 
-	httpClient := &http.Client{
-		Timeout: 30 * time.Second,
-		Transport: &http.Transport{
-			MaxIdleConns:        100,
-			MaxIdleConnsPerHost: numWorkersLenta + 5,
-			IdleConnTimeout:     90 * time.Second,
-			MaxConnsPerHost:     numWorkersLenta,
-		},
-	}
-
-	resultsChan := make(chan pageParseResultLenta, totalLinks)
-	linkChan := make(chan string, totalLinks)
+	var title, body string
+	var tags []string
+	var parsDate time.Time
 
-	for _, link := range links {
-		linkChan <- link
-	}
-	close(linkChan)
-
-	var wg sync.WaitGroup
-
-	actualNumWorkers := numWorkersLenta
-	if totalLinks < numWorkersLenta {
-		actualNumWorkers = totalLinks
+	doc, err := p.article(ctx, pageURL)
+	if err != nil {
+		return PageResult{PageURL: pageURL, Error: fmt.Errorf("ошибка GET: %w", err)}
 	}
 
-	for i := 0; i < actualNumWorkers; i++ {
-		wg.Add(1)
-		go func() {
-			defer wg.Done()
-			for pageURL := range linkChan {
-				var title, body string
-				var tags []string
-				var parsDate time.Time
-
-				doc, err := GetHTMLForClient(httpClient, pageURL)
-				if err != nil {
-					resultsChan <- pageParseResultLenta{PageURL: pageURL, Error: fmt.Errorf("ошибка GET: %w", err)}
-					continue
-				}
-
-				title = strings.TrimSpace(doc.Find(".topic-body__title").First().Text())
-
-				var bodyBuilder strings.Builder
-				doc.Find(".topic-body__content > p").Each(func(i int, s *goquery.Selection) {
-					paragraphText := strings.TrimSpace(s.Text())
-					if paragraphText != "" {
-						if bodyBuilder.Len() > 0 {
-							bodyBuilder.WriteString("\n\n")
-						}
-						bodyBuilder.WriteString(paragraphText)
-					}
-				})
-				body = bodyBuilder.String()
+	title = strings.TrimSpace(doc.Find(".topic-body__title").First().Text())
 
-				dateTextRaw := doc.Find("a.topic-header__item.topic-header__time").First().Text()
-				dateToParse := strings.TrimSpace(dateTextRaw)
-				processedDateStr := dateToParse
-				var dateParseError error
-
-				if dateToParse != "" {
-					foundMonth := false
-					lowerDateToParse := strings.ToLower(dateToParse)
-					tempProcessedStr := dateToParse
-
-					for rusMonth, numMonth := range RussianMonths {
-						lowerRusMonth := strings.ToLower(rusMonth)
-						if strings.Contains(lowerDateToParse, lowerRusMonth) {
-							startIndex := strings.Index(lowerDateToParse, lowerRusMonth)
-							if startIndex != -1 {
-								tempProcessedStr = dateToParse[:startIndex] + numMonth + dateToParse[startIndex+len(rusMonth):]
-								foundMonth = true
-								break
-							}
-						}
-					}
-					if foundMonth {
-						processedDateStr = tempProcessedStr
-					}
-
-					parsedTime, parseErr := time.ParseInLocation(dateLayout, processedDateStr, locationPlus3)
-					if parseErr != nil {
-						dateParseError = parseErr
-						fmt.Printf("%s[LENTA]%s[WARNING] Ошибка парсинга даты: '%s' (попытка с '%s') на %s: %v%s\n", ColorBlue, ColorYellow, dateToParse, processedDateStr, pageURL, parseErr, ColorReset)
-					} else {
-						parsDate = parsedTime
-					}
-				}
-
-				doc.Find("a.topic-header__item.topic-header__rubric").Each(func(_ int, s *goquery.Selection) {
-					tagText := strings.TrimSpace(s.Text())
-					if tagText != "" {
-						tags = append(tags, tagText)
-					}
-				})
-
-				if title != "" && body != "" && !parsDate.IsZero() && (!tagsAreMandatory || len(tags) > 0) {
-					dataItem := Data{
-						Site:  lentaURL,
-						Href:  pageURL,
-						Title: title,
-						Body:  body,
-						Date:  parsDate,
-						Tags:  tags,
-					}
-					hash, err := dataItem.Hashing()
-					if err != nil {
-						resultsChan <- pageParseResultLenta{PageURL: pageURL, Error: fmt.Errorf("ошибка генерации хеша: %w", err)}
-						continue
-					}
-					dataItem.Hash = hash
-					resultsChan <- pageParseResultLenta{Data: dataItem}
-				} else {
-					var reasons []string
-					if title == "" {
-						reasons = append(reasons, "T:false")
-					}
-					if body == "" {
-						reasons = append(reasons, "B:false")
-					}
-					if parsDate.IsZero() {
-						reasonDate := "D:false"
-						if dateParseError != nil {
-							reasonDate = fmt.Sprintf("D:false (err: %v, original_str: '%s', processed_str: '%s')", dateParseError, dateToParse, processedDateStr)
-						} else if dateToParse == "" {
-							reasonDate = "D:false (empty_str)"
-						}
-						reasons = append(reasons, reasonDate)
-					}
-					if tagsAreMandatory && len(tags) == 0 {
-						reasons = append(reasons, "Tags:false")
-					}
-					resultsChan <- pageParseResultLenta{PageURL: pageURL, IsEmpty: true, Reasons: reasons}
-				}
+	var bodyBuilder strings.Builder
+	doc.Find(".topic-body__content > p").Each(func(i int, s *goquery.Selection) {
+		paragraphText := strings.TrimSpace(s.Text())
+		if paragraphText != "" {
+			if bodyBuilder.Len() > 0 {
+				bodyBuilder.WriteString("\n\n")
 			}
-		}()
-	}
+			bodyBuilder.WriteString(paragraphText)
+		}
+	})
+	body = bodyBuilder.String()
 
-	go func() {
-		wg.Wait()
-		close(resultsChan)
-	}()
+	dateTextRaw := doc.Find("a.topic-header__item.topic-header__time").First().Text()
+	dateToParse := strings.TrimSpace(dateTextRaw)
+	var dateParseError error
 
-	for result := range resultsChan {
-		if result.Error != nil {
-			errItems = append(errItems, fmt.Sprintf("%s (%s)", result.PageURL, result.Error.Error()))
-		} else if result.IsEmpty {
-			errItems = append(errItems, fmt.Sprintf("%s (нет данных: %s)", result.PageURL, strings.Join(result.Reasons, ", ")))
+	if dateToParse != "" {
+		parsedTime, parseErr := dates.Parse(dateToParse)
+		if parseErr != nil {
+			dateParseError = parseErr
+			fmt.Printf("%s[LENTA]%s[WARNING] Ошибка парсинга даты: '%s' на %s: %v%s\n", ColorBlue, ColorYellow, dateToParse, pageURL, parseErr, ColorReset)
 		} else {
-			products = append(products, result.Data)
+			parsDate = parsedTime
 		}
 	}
 
-	if len(products) > 0 {
-		if len(errItems) > 0 {
-			fmt.Printf("%s[LENTA]%s[WARNING] Не удалось обработать %d из %d страниц (или отсутствовали данные):%s\n", ColorBlue, ColorYellow, len(errItems), totalLinks, ColorReset)
-			for idx, itemMessage := range errItems {
-				fmt.Printf("%s  %d. %s%s\n", ColorYellow, idx+1, itemMessage, ColorReset)
-			}
-		}
-	} else if totalLinks > 0 {
-		fmt.Printf("%s[LENTA]%s[ERROR] Парсинг статей Lenta.ru завершен, но не удалось собрать данные ни с одной из %d страниц.%s\n", ColorBlue, ColorRed, totalLinks, ColorReset)
-		if len(errItems) > 0 {
-			fmt.Printf("%s[LENTA]%s[INFO] Список страниц с ошибками или без данных:%s\n", ColorBlue, ColorYellow, ColorReset)
-			for idx, itemMessage := range errItems {
-				fmt.Printf("%s  %d. %s%s\n", ColorYellow, idx+1, itemMessage, ColorReset)
-			}
+	doc.Find("a.topic-header__item.topic-header__rubric").Each(func(_ int, s *goquery.Selection) {
+		tagText := strings.TrimSpace(s.Text())
+		if tagText != "" {
+			tags = append(tags, tagText)
 		}
-	}
+	})
 
-	return products, links
+	return finishPage(doc, item, lentaMetadata, Data{
+		Site:  lentaURL,
+		Href:  pageURL,
+		Title: title,
+		Body:  body,
+		Date:  parsDate,
+		Tags:  tags,
+	}, tagsAreMandatory, dateReason(dateParseError, dateToParse, "empty_str"))
 }
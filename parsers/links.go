@@ -0,0 +1,108 @@
+package parsers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	. "parsing_media/utils"
+	"slices"
+	"strings"
+)
+
+// linkSource - один источник ссылок сайта для links
+type linkSource struct {
+	name  string
+	fetch func(context.Context) ([]LinkItem, error)
+}
+
+// links собирает ссылки на статьи из источников сайта согласно parsers.link_sources: страницы-списка listing,
+// RSS/Atom-лент и карт сайта. Без настройки используются страница-список и ленты, а карты - если они заданы явно.
+// accept отбирает ссылки лент и карт, которые парсер умеет разбирать (nil - любые ссылки сайта), listing может быть nil.
+// Ошибка одного источника только печатается, ошибка возвращается, если не сработал ни один.
+func (b *baseParser) links(ctx context.Context, listing func(context.Context) ([]LinkItem, error), accept func(href string) bool) ([]LinkItem, error) {
+	if accept == nil {
+		accept = b.sameSite
+	}
+	wanted := b.linkSources
+	if wanted == (LinkSources{}) {
+		wanted = LinkSources{HTML: true, Feed: true, Sitemap: len(b.sitemaps) > 0}
+	}
+
+	candidates := []struct {
+		source           linkSource
+		enabled, present bool
+	}{
+		{linkSource{"страница-список", listing}, wanted.HTML, listing != nil},
+		{linkSource{"ленты", func(ctx context.Context) ([]LinkItem, error) { return b.feedLinks(ctx, accept) }}, wanted.Feed, len(b.feeds) > 0},
+		{linkSource{"карты сайта", func(ctx context.Context) ([]LinkItem, error) { return b.sitemapLinks(ctx, accept) }}, wanted.Sitemap, true},
+	}
+	var sources []linkSource
+	for _, candidate := range candidates {
+		if candidate.enabled && candidate.present {
+			sources = append(sources, candidate.source)
+		}
+	}
+	if len(sources) == 0 {
+		// Выбранных источников у сайта нет: берём первый имеющийся, как до появления parsers.link_sources
+		for _, candidate := range candidates {
+			if candidate.present {
+				sources = append(sources, candidate.source)
+				break
+			}
+		}
+	}
+	if len(sources) == 1 {
+		return sources[0].fetch(ctx)
+	}
+
+	var found [][]LinkItem
+	var failures []error
+	for _, source := range sources {
+		links, err := source.fetch(ctx)
+		if ctx.Err() != nil {
+			return mergeLinks(append(found, links)...), ctx.Err()
+		}
+		if err != nil {
+			failures = append(failures, err)
+			fmt.Printf("%s[%s]%s[WARNING] Источник ссылок '%s' недоступен, используются остальные: %v%s\n", ColorBlue, strings.ToUpper(b.name), ColorYellow, source.name, err, ColorReset)
+			continue
+		}
+		found = append(found, links)
+	}
+	if len(failures) == len(sources) {
+		return nil, errors.Join(failures...)
+	}
+	return mergeLinks(found...), nil
+}
+
+// sameSite проверяет, что ссылка ведёт на сайт парсера
+func (b *baseParser) sameSite(href string) bool {
+	return strings.HasPrefix(href, strings.TrimSuffix(b.siteURL, "/")+"/")
+}
+
+// mergeLinks объединяет списки ссылок из разных источников без повторов (сравниваются канонические адреса).
+// Порядок и адреса первого источника, где встретилась ссылка, сохраняются, а пустые заголовок, дата и теги
+// дополняются из следующих.
+func mergeLinks(lists ...[]LinkItem) []LinkItem {
+	var merged []LinkItem
+	index := make(map[string]int)
+	for _, link := range slices.Concat(lists...) {
+		key := CanonicalURL(link.Href)
+		i, seen := index[key]
+		if !seen {
+			index[key] = len(merged)
+			merged = append(merged, link)
+			continue
+		}
+		if merged[i].Title == "" {
+			merged[i].Title = link.Title
+		}
+		if merged[i].Date.IsZero() {
+			merged[i].Date = link.Date
+		}
+		if len(merged[i].Tags) == 0 {
+			merged[i].Tags = link.Tags
+		}
+	}
+	return merged
+}
@@ -0,0 +1,157 @@
+package parsers
+
+import (
+	"cmp"
+	"fmt"
+	"net/url"
+	. "parsing_media/utils"
+	"slices"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// metadataSelectors - где на странице статьи искать необязательные поля Data. Пустой селектор - поле не ищется.
+type metadataSelectors struct {
+	Body     string // контейнер текста статьи, из которого берётся санированный HTML для RichBody
+	Authors  string // каждый найденный элемент - отдельный автор
+	Lead     string
+	Rubric   string
+	Image    string // адрес берётся из src, data-src, content или href
+	Modified string // дата из datetime или content в формате RFC3339
+}
+
+// fill дополняет статью полями, которые парсер не заполнил сам: сначала по селекторам сайта,
+// затем из структурированной разметки meta. Возвращает дополненную статью.
+func (m metadataSelectors) fill(doc *goquery.Document, meta PageMetadata, item Data) Data {
+	if keepRichBody && item.RichBody == "" && m.Body != "" && !item.BodyFallback {
+		if node := doc.Find(m.Body).First(); node.Length() > 0 {
+			item.RichBody, item.RichFormat = SanitizeHTML(node), RichFormatHTML
+		}
+	}
+	if len(item.Authors) == 0 && m.Authors != "" {
+		doc.Find(m.Authors).Each(func(_ int, s *goquery.Selection) {
+			author := strings.Join(strings.Fields(s.Text()), " ")
+			if author != "" && !slices.Contains(item.Authors, author) {
+				item.Authors = append(item.Authors, author)
+			}
+		})
+	}
+	if item.Lead == "" && m.Lead != "" {
+		item.Lead = strings.Join(strings.Fields(doc.Find(m.Lead).First().Text()), " ")
+	}
+	if item.Rubric == "" && m.Rubric != "" {
+		item.Rubric = strings.TrimSpace(doc.Find(m.Rubric).First().Text())
+	}
+	if item.Image == "" && m.Image != "" {
+		node := doc.Find(m.Image).First()
+		for _, attr := range []string{"src", "data-src", "content", "href"} {
+			if value := strings.TrimSpace(node.AttrOr(attr, "")); value != "" {
+				item.Image = value
+				break
+			}
+		}
+	}
+	if item.Modified.IsZero() && m.Modified != "" {
+		node := doc.Find(m.Modified).First()
+		item.Modified, _ = dates.ParseISO(node.AttrOr("datetime", node.AttrOr("content", "")))
+	}
+
+	if len(item.Authors) == 0 {
+		item.Authors = meta.Authors
+	}
+	if item.Lead == "" {
+		item.Lead = meta.Description
+	}
+	if item.Rubric == "" {
+		item.Rubric = meta.Section
+	}
+	if item.Image == "" {
+		item.Image = meta.Image
+	}
+	if item.Modified.IsZero() {
+		item.Modified = meta.Modified
+	}
+	item.Image = resolveURL(item.Href, item.Image)
+	return item
+}
+
+// resolveURL превращает относительную ссылку ref (в том числе "//host/...") в абсолютную относительно страницы base
+func resolveURL(base, ref string) string {
+	if ref == "" {
+		return ""
+	}
+	baseURL, err := url.Parse(base)
+	if err != nil {
+		return ref
+	}
+	refURL, err := url.Parse(ref)
+	if err != nil {
+		return ref
+	}
+	return baseURL.ResolveReference(refURL).String()
+}
+
+// finishPage завершает разбор страницы статьи. Поля, которые селекторы сайта не нашли, берутся из резервных источников
+// в одном порядке для всех сайтов: заголовок, дата и теги - из структурированной разметки страницы, затем из данных
+// ссылки (RSS-лента, карта сайта); текст - из ExtractMainText. Затем проверяются обязательные поля: статья без
+// заголовка, текста, даты (и тегов, если tagsMandatory) возвращается пустой с причинами, а dateReason поясняет,
+// почему дату не нашли селекторы сайта. К результату прикладывается статистика очистки страницы.
+func finishPage(page *articlePage, link LinkItem, selectors metadataSelectors, item Data, tagsMandatory bool, dateReason string) PageResult {
+	meta := ExtractMetadata(page.Document)
+	if item.Title == "" {
+		item.Title = cmp.Or(meta.Headline, link.Title)
+	}
+	if item.Date.IsZero() {
+		item.Date = meta.Published
+	}
+	if item.Date.IsZero() {
+		item.Date = link.Date
+	}
+	if len(item.Tags) == 0 {
+		item.Tags = meta.Keywords
+	}
+	if len(item.Tags) == 0 {
+		item.Tags = link.Tags
+	}
+	if item.Body == "" {
+		item.Body = ExtractMainText(page.Document)
+		item.BodyFallback = item.Body != ""
+	}
+
+	if item.Title != "" && item.Body != "" && !item.Date.IsZero() && (!tagsMandatory || len(item.Tags) > 0) {
+		result := completePage(selectors.fill(page.Document, meta, item))
+		result.Cleaning = page.cleaning
+		return result
+	}
+
+	var reasons []string
+	if item.Title == "" {
+		reasons = append(reasons, "T:false")
+	}
+	if item.Body == "" {
+		reasons = append(reasons, "B:false")
+	}
+	if item.Date.IsZero() {
+		reasonDate := "D:false"
+		if dateReason != "" {
+			reasonDate += " (" + dateReason + ")"
+		}
+		reasons = append(reasons, reasonDate)
+	}
+	if tagsMandatory && len(item.Tags) == 0 {
+		reasons = append(reasons, "Tags:false")
+	}
+	return PageResult{PageURL: item.Href, IsEmpty: true, Reasons: reasons, Cleaning: page.cleaning}
+}
+
+// dateReason поясняет, почему не разобрана дата из строки raw: ошибка err или missing, если строка не найдена
+func dateReason(err error, raw, missing string) string {
+	if err != nil {
+		return fmt.Sprintf("err: %v, str: '%s'", err, raw)
+	}
+	if raw == "" {
+		return missing
+	}
+	return ""
+}
@@ -0,0 +1,89 @@
+package parsers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"parsing_media/rudate"
+	"parsing_media/storage"
+	. "parsing_media/utils"
+	"slices"
+	"testing"
+	"time"
+)
+
+const mkArticleFixture = `<!DOCTYPE html>
+<html><head><meta charset="utf-8"><title>МК</title></head>
+<body>
+<h1 class="article__title">  Заголовок статьи МК  </h1>
+<div class="article__subtitle">Вводный абзац статьи</div>
+<div class="article__authors"><span class="article__author-name">Иван Иванов</span></div>
+<time class="meta__text" datetime="2025-10-07T10:15:00+0300">7 октября 2025</time>
+<div class="article__body">
+  <p>Первый абзац текста.</p>
+  <p>   </p>
+  <p>Второй абзац текста.</p>
+</div>
+</body></html>`
+
+func TestMKParsePageSavedToMemory(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/politics/2025/10/07/article.html" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Write([]byte(mkArticleFixture))
+	}))
+	defer server.Close()
+
+	pageURL := server.URL + "/politics/2025/10/07/article.html"
+	result := newMKParser().ParsePage(context.Background(), LinkItem{Href: pageURL})
+	if result.Error != nil || result.IsEmpty {
+		t.Fatalf("ParsePage: error %v, reasons %v", result.Error, result.Reasons)
+	}
+
+	store := storage.NewMemory()
+	saved, err := store.Save(context.Background(), []Data{result.Data})
+	if err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	if len(saved.Inserted) != 1 {
+		t.Fatalf("Save: вставлено %d статей, want 1", len(saved.Inserted))
+	}
+
+	articles := store.Articles()
+	if len(articles) != 1 {
+		t.Fatalf("в хранилище %d статей, want 1", len(articles))
+	}
+	got := articles[0]
+	wantDate := time.Date(2025, time.October, 7, 10, 15, 0, 0, rudate.Moscow)
+	if got.Site != mkURL || got.Href != pageURL {
+		t.Errorf("Site, Href = %q, %q, want %q, %q", got.Site, got.Href, mkURL, pageURL)
+	}
+	if got.Title != "Заголовок статьи МК" {
+		t.Errorf("Title = %q", got.Title)
+	}
+	if got.Body != "Первый абзац текста.\n\nВторой абзац текста." {
+		t.Errorf("Body = %q", got.Body)
+	}
+	if !got.Date.Equal(wantDate) {
+		t.Errorf("Date = %v, want %v", got.Date, wantDate)
+	}
+	if got.Lead != "Вводный абзац статьи" || !slices.Equal(got.Authors, []string{"Иван Иванов"}) {
+		t.Errorf("Lead, Authors = %q, %v", got.Lead, got.Authors)
+	}
+	if got.BodyFallback {
+		t.Error("BodyFallback = true, текст должен найтись селекторами сайта")
+	}
+	if want, _ := got.Hashing(); got.Hash != want {
+		t.Errorf("Hash = %q, want %q", got.Hash, want)
+	}
+
+	// Та же статья при повторном разборе - повтор, а не новая редакция
+	again := newMKParser().ParsePage(context.Background(), LinkItem{Href: pageURL})
+	saved, err = store.Save(context.Background(), []Data{again.Data})
+	if err != nil || len(saved.Duplicates) != 1 {
+		t.Errorf("повторное сохранение: %d повторов, ошибка %v, want 1 повтор", len(saved.Duplicates), err)
+	}
+}
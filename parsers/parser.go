@@ -0,0 +1,211 @@
+package parsers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"parsing_media/rudate"
+	. "parsing_media/utils"
+	"strings"
+	"time"
+)
+
+// dates разбирает даты публикации во всех парсерах: московское время и системные часы
+var dates = rudate.Default
+
+// Parser описывает один новостной сайт: где искать ссылки на статьи и как разобрать одну статью.
+// Управление потоком (загрузка, параллелизм, сохранение, отчёты) остаётся на стороне Run и вызывающего кода.
+// Все сетевые запросы выполняются с переданным ctx, чтобы остановка прерывала их сразу.
+type Parser interface {
+	Name() string
+	SiteURL() string
+	Workers() int
+	Links(ctx context.Context) ([]LinkItem, error)
+	ParsePage(ctx context.Context, item LinkItem) PageResult
+}
+
+// LinkItem - ссылка на статью, найденная на странице-списке, с данными, которые удалось собрать прямо там
+type LinkItem struct {
+	Href  string
+	Tags  []string
+	Title string    // заголовок из RSS/Atom-ленты: запасной, если на странице статьи его не нашли
+	Date  time.Time // дата публикации из ленты, тоже запасная
+}
+
+// LinkFilter отбирает из найденных ссылок те, которые нужно загружать (например, ещё не сохранённые в БД)
+type LinkFilter interface {
+	FilterNew(ctx context.Context, hrefs []string) ([]string, error)
+}
+
+// RunOptions - необязательные параметры Run
+type RunOptions struct {
+	Filter LinkFilter // nil - загружать все найденные ссылки
+}
+
+// RunResult - результат полного прохода парсера по сайту
+type RunResult struct {
+	Parser   Parser
+	Links    []LinkItem // все ссылки со страницы-списка
+	Known    int        // ссылки, отброшенные фильтром как уже сохранённые
+	Articles []Data
+	Report   CrawlReport
+	Err      error
+	Elapsed  time.Duration
+}
+
+// baseParser содержит общие для всех сайтов поля и реализует простые методы интерфейса Parser
+type baseParser struct {
+	name        string
+	siteURL     string
+	workers     int
+	client      *http.Client
+	feeds       []string    // RSS/Atom-ленты сайта (см. links)
+	sitemaps    []string    // карты сайта; пусто - адреса берутся из robots.txt
+	linkSources LinkSources // parsers.link_sources; нулевое значение - источники по умолчанию
+}
+
+func newBaseParser(name, siteURL string, workers int) baseParser {
+	return baseParser{
+		name:    name,
+		siteURL: siteURL,
+		workers: workers,
+		client: &http.Client{
+			Timeout: 30 * time.Second,
+			Transport: &http.Transport{
+				MaxIdleConns:        100,
+				MaxIdleConnsPerHost: workers + 5,
+				IdleConnTimeout:     90 * time.Second,
+				MaxConnsPerHost:     workers,
+			},
+		},
+	}
+}
+
+// withFeeds добавляет сайту RSS/Atom-ленты, из которых links берёт ссылки вместе со страницей-списком
+func (b baseParser) withFeeds(feeds ...string) baseParser {
+	b.feeds = feeds
+	return b
+}
+
+// withSitemaps задаёт карты сайта вместо найденных в robots.txt. Заданные карты включаются в источники по умолчанию.
+func (b baseParser) withSitemaps(sitemaps ...string) baseParser {
+	b.sitemaps = sitemaps
+	return b
+}
+
+// configure пересоздаёт HTTP-клиент под новое количество потоков и таймаут и задаёт источники ссылок
+func (b *baseParser) configure(workers int, timeout time.Duration, linkSources LinkSources) {
+	fresh := newBaseParser(b.name, b.siteURL, workers)
+	fresh.client.Timeout = timeout
+	fresh.feeds = b.feeds
+	fresh.sitemaps = b.sitemaps
+	fresh.linkSources = linkSources
+	*b = fresh
+}
+
+func (b *baseParser) Name() string    { return b.name }
+func (b *baseParser) SiteURL() string { return b.siteURL }
+func (b *baseParser) Workers() int    { return b.workers }
+
+// configurable реализуется всеми парсерами, встраивающими baseParser
+type configurable interface {
+	configure(workers int, timeout time.Duration, linkSources LinkSources)
+}
+
+// keepRichBody - сохранять ли в Data.RichBody исходный текст с разметкой (parsers.keep_rich_body)
+var keepRichBody bool
+
+// sitemapMaxAge - статьи из карт сайта, опубликованные раньше, пропускаются (parsers.sitemap_max_age)
+var sitemapMaxAge = 48 * time.Hour
+
+// ApplyConfig применяет к зарегистрированным парсерам количество потоков, HTTP-таймаут и источник ссылок из конфигурации.
+// Вызывается после регистрации декларативных определений, чтобы настройки действовали и на них.
+func ApplyConfig(cfg *Config) {
+	keepRichBody = cfg.Parsers.KeepRichBody
+	sitemapMaxAge = cfg.Parsers.SitemapMaxAge
+	for _, p := range registry {
+		if c, ok := p.(configurable); ok {
+			c.configure(cfg.WorkersFor(p.Name(), p.Workers()), cfg.HTTP.Timeout, cfg.LinkSourcesFor(p.Name()))
+		}
+	}
+}
+
+// completePage вычисляет хеш собранной статьи и упаковывает её в PageResult
+func completePage(item Data) PageResult {
+	hash, err := item.Hashing()
+	if err != nil {
+		return PageResult{PageURL: item.Href, Error: fmt.Errorf("ошибка генерации хеша: %w", err)}
+	}
+	item.Hash = hash
+	if !keepRichBody {
+		item.RichBody, item.RichFormat = "", ""
+	}
+	if item.FetchedAt.IsZero() {
+		item.FetchedAt = time.Now()
+	}
+	return PageResult{PageURL: item.Href, Data: item}
+}
+
+// Run собирает ссылки парсера и разбирает найденные статьи общим пулом Crawl. Ничего не сохраняет и не печатает.
+// Отмена ctx прерывает загрузку ссылок и текущие запросы; уже собранные статьи остаются в результате.
+// Если задан opts.Filter, загружаются только отобранные им ссылки; ошибка фильтра не мешает загрузить все.
+func Run(ctx context.Context, p Parser, opts RunOptions) RunResult {
+	startTime := time.Now()
+	links, err := p.Links(ctx)
+	if err != nil {
+		return RunResult{Parser: p, Links: links, Err: err, Elapsed: time.Since(startTime)}
+	}
+	result := RunLinks(ctx, p, links, opts)
+	result.Elapsed = time.Since(startTime)
+	return result
+}
+
+// RunLinks разбирает статьи по уже найденным ссылкам так же, как Run: с фильтром opts.Filter и общим пулом Crawl.
+// Используется, когда ссылки получены не со страницы-списка Links, например из архива сайта.
+func RunLinks(ctx context.Context, p Parser, links []LinkItem, opts RunOptions) RunResult {
+	startTime := time.Now()
+	result := RunResult{Parser: p, Links: links}
+
+	itemsByURL := make(map[string]LinkItem, len(links))
+	urls := make([]string, 0, len(links))
+	for _, link := range links {
+		itemsByURL[link.Href] = link
+		urls = append(urls, link.Href)
+	}
+
+	if opts.Filter != nil && len(urls) > 0 {
+		fresh, err := opts.Filter.FilterNew(ctx, urls)
+		if err != nil {
+			fmt.Printf("%s[%s]%s[WARNING] Не удалось отфильтровать сохранённые ссылки, загружаем все: %v%s\n", ColorBlue, strings.ToUpper(p.Name()), ColorYellow, err, ColorReset)
+		} else {
+			result.Known = len(urls) - len(fresh)
+			urls = fresh
+		}
+	}
+
+	result.Report = Crawl(ctx, urls, CrawlOptions{Workers: p.Workers()}, func(ctx context.Context, pageURL string) PageResult {
+		return p.ParsePage(ctx, itemsByURL[pageURL])
+	})
+	result.Articles = result.Report.Data
+	result.Elapsed = time.Since(startTime)
+	return result
+}
+
+// PrintReport выводит в консоль сводку по результату Run в привычном для парсеров формате
+func PrintReport(r RunResult) {
+	tag := strings.ToUpper(r.Parser.Name())
+	name := r.Parser.Name()
+
+	if r.Err != nil && (errors.Is(r.Err, context.Canceled) || errors.Is(r.Err, context.DeadlineExceeded)) {
+		fmt.Printf("%s[%s]%s[WARNING] Получение списка ссылок прервано: %v%s\n", ColorBlue, tag, ColorYellow, r.Err, ColorReset)
+	} else if r.Err != nil {
+		fmt.Printf("%s[%s]%s[ERROR] Ошибка при получении списка ссылок: %v%s\n", ColorBlue, tag, ColorRed, r.Err, ColorReset)
+	}
+	r.Report.PrintSummary(tag, name)
+
+	if r.Known > 0 {
+		fmt.Printf("%s[%s]%s[INFO] Пропущено уже сохранённых статей: %d%s\n", ColorBlue, tag, ColorYellow, r.Known, ColorReset)
+	}
+	fmt.Printf("%s[%s]%s[INFO] Парсер %s заверщил работу собрав (%d/%d): (%s)%s\n", ColorBlue, tag, ColorYellow, name, len(r.Articles), len(r.Links)-r.Known, FormatDuration(r.Elapsed), ColorReset)
+}
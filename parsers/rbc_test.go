@@ -0,0 +1,11 @@
+package parsers
+
+import "testing"
+
+func TestRBCBodyText(t *testing.T) {
+	md := "Глава ЦБ заявила: «ставка **останется** высокой».\n\nПо данным [Росстата](https://rosstat.gov.ru), цены выросли на 0,5%."
+	want := "Глава ЦБ заявила: \"ставка останется высокой\".\n\nПо данным Росстата, цены выросли на 0,5%."
+	if got := rbcBodyText(md); got != want {
+		t.Errorf("rbcBodyText =\n%q\nwant\n%q", got, want)
+	}
+}
@@ -0,0 +1,53 @@
+package parsers
+
+import "strings"
+
+// registry хранит все парсеры в порядке отображения в меню
+var registry = []Parser{
+	newRiaParser(),
+	newGazetaParser(),
+	newLentaParser(),
+	newVestiParser(),
+	newKommersParser(),
+	newMKParser(),
+	newFontankaParser(),
+	newSmotrimParser(),
+	newDumaTVParser(),
+	newRbcParser(),
+	newIzParser(),
+	newInterfaxParser(),
+	newRGParser(),
+	newKPParser(),
+	newUraParser(),
+	newLifeParser(),
+	newRegnumParser(),
+	newAifParser(),
+}
+
+// Register добавляет парсер в реестр. Парсер с уже существующим именем заменяет прежний на его месте в меню.
+func Register(p Parser) {
+	for i, existing := range registry {
+		if strings.EqualFold(existing.Name(), p.Name()) {
+			registry[i] = p
+			return
+		}
+	}
+	registry = append(registry, p)
+}
+
+// All возвращает все зарегистрированные парсеры
+func All() []Parser {
+	parsers := make([]Parser, len(registry))
+	copy(parsers, registry)
+	return parsers
+}
+
+// Find ищет парсер по имени без учёта регистра
+func Find(name string) (Parser, bool) {
+	for _, p := range registry {
+		if strings.EqualFold(p.Name(), name) {
+			return p, true
+		}
+	}
+	return nil, false
+}
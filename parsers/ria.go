@@ -1,11 +1,10 @@
 package parsers
 
 import (
+	"context"
 	"fmt"
-	"net/http"
 	. "parsing_media/utils"
 	"strings"
-	"sync"
 	"time"
 
 	"github.com/PuerkitoBio/goquery"
@@ -14,35 +13,39 @@ import (
 const (
 	riaURL         = "https://ria.ru"
 	riaNewsPageURL = "https://ria.ru/lenta/"
+	riaFeedURL     = "https://ria.ru/export/rss2/archive/index.xml"
 	numWorkersRia  = 10
 )
 
-func RiaMain() {
-	totalStartTime := time.Now()
-	articles, links := getLinksRia()
-	SaveData(articles)
-	totalElapsedTime := time.Since(totalStartTime)
-	fmt.Printf("%s[RIA]%s[INFO] Парсер RIA.ru заверщил работу собрав (%d/%d): (%s)%s\n", ColorBlue, ColorYellow, len(articles), len(links), FormatDuration(totalElapsedTime), ColorReset)
+// riaMetadata - необязательные поля статьи на страницах ria.ru
+var riaMetadata = metadataSelectors{
+	Body:    ".article__body",
+	Authors: ".article__author-name",
+	Lead:    ".article__second-title",
+	Image:   ".article__announce .photoview__open img",
 }
 
-func getLinksRia() ([]Data, []string) {
-	var foundLinks []string
+type riaParser struct {
+	baseParser
+}
+
+func newRiaParser() *riaParser {
+	return &riaParser{baseParser: newBaseParser("RIA", riaURL, numWorkersRia).withFeeds(riaFeedURL)}
+}
+
+func (p *riaParser) Links(ctx context.Context) ([]LinkItem, error) {
+	return p.links(ctx, p.htmlLinks, func(href string) bool { return strings.HasPrefix(href, riaURL+"/") })
+}
+
+// htmlLinks собирает ссылки со страницы-списка сайта
+func (p *riaParser) htmlLinks(ctx context.Context) ([]LinkItem, error) {
+	var foundLinks []LinkItem
 	seenLinks := make(map[string]bool)
 	linkSelector := "a.list-item__title.color-font-hover-only"
 
-	client := &http.Client{
-		Timeout: 30 * time.Second,
-		Transport: &http.Transport{
-			MaxIdleConns:        100,
-			MaxIdleConnsPerHost: 10,
-			IdleConnTimeout:     90 * time.Second,
-		},
-	}
-
-	doc, err := GetHTMLForClient(client, riaNewsPageURL)
+	doc, err := GetHTMLForClient(ctx, p.client, riaNewsPageURL)
 	if err != nil {
-		fmt.Printf("%s[RIA]%s[ERROR] Ошибка при получении HTML со страницы %s: %v%s\n", ColorBlue, ColorRed, riaNewsPageURL, err, ColorReset)
-		return getPageRia(foundLinks)
+		return nil, fmt.Errorf("ошибка при получении HTML со страницы %s: %w", riaNewsPageURL, err)
 	}
 
 	doc.Find(linkSelector).Each(func(i int, s *goquery.Selection) {
@@ -51,7 +54,7 @@ func getLinksRia() ([]Data, []string) {
 			if strings.HasPrefix(href, "https://ria.ru") {
 				if !seenLinks[href] {
 					seenLinks[href] = true
-					foundLinks = append(foundLinks, href)
+					foundLinks = append(foundLinks, LinkItem{Href: href})
 				}
 			}
 		}
@@ -61,185 +64,71 @@ func getLinksRia() ([]Data, []string) {
 		fmt.Printf("%s[RIA]%s[WARNING] Не найдено ссылок с селектором '%s' на странице %s.%s\n", ColorBlue, ColorYellow, linkSelector, riaNewsPageURL, ColorReset)
 	}
 
-	return getPageRia(foundLinks)
+	return foundLinks, nil
 }
 
-type pageParseResultRia struct {
-	Data    Data
-	Error   error
-	PageURL string
-	IsEmpty bool
-	Reasons []string
-}
-
-func getPageRia(links []string) ([]Data, []string) {
-	var products []Data
-	var errItems []string
-	totalLinks := len(links)
-
-	if totalLinks == 0 {
-		return products, links
-	}
-
-	locationPlus3 := time.FixedZone("UTC+3", 3*60*60)
-	dateLayout := "15:04 02.01.2006"
+func (p *riaParser) ParsePage(ctx context.Context, item LinkItem) PageResult {
+	pageURL := item.Href
 	tagsAreMandatory := true
 
-	httpClient := &http.Client{
-		Timeout: 30 * time.Second,
-		Transport: &http.Transport{
-			MaxIdleConns:        100,
-			MaxIdleConnsPerHost: numWorkersRia + 5,
-			IdleConnTimeout:     90 * time.Second,
-			MaxConnsPerHost:     numWorkersRia,
-		},
-	}
-
-	resultsChan := make(chan pageParseResultRia, totalLinks)
-	linkChan := make(chan string, totalLinks)
+	var title, body string
+	var tags []string
+	var parsDate time.Time
 
-	for _, link := range links {
-		linkChan <- link
+	doc, err := p.article(ctx, pageURL)
+	if err != nil {
+		return PageResult{PageURL: pageURL, Error: fmt.Errorf("ошибка GET: %w", err)}
 	}
-	close(linkChan)
 
-	var wg sync.WaitGroup
+	title = strings.TrimSpace(doc.Find(".article__title").First().Text())
 
-	actualNumWorkers := numWorkersRia
-	if totalLinks < numWorkersRia {
-		actualNumWorkers = totalLinks
+	var bodyBuilder strings.Builder
+	var targetNodes *goquery.Selection
+	articleBodyNode := doc.Find(".article__body")
+	if articleBodyNode.Length() > 0 {
+		targetNodes = articleBodyNode.Find(".article__text, .article__quote-text")
+	} else {
+		targetNodes = doc.Find(".article__text, .article__quote-text")
 	}
 
-	for i := 0; i < actualNumWorkers; i++ {
-		wg.Add(1)
-		go func() {
-			defer wg.Done()
-			for pageURL := range linkChan {
-				var title, body string
-				var tags []string
-				var parsDate time.Time
-
-				doc, err := GetHTMLForClient(httpClient, pageURL)
-				if err != nil {
-					resultsChan <- pageParseResultRia{PageURL: pageURL, Error: fmt.Errorf("ошибка GET: %w", err)}
-					continue
-				}
-
-				title = strings.TrimSpace(doc.Find(".article__title").First().Text())
-
-				var bodyBuilder strings.Builder
-				var targetNodes *goquery.Selection
-				articleBodyNode := doc.Find(".article__body")
-				if articleBodyNode.Length() > 0 {
-					targetNodes = articleBodyNode.Find(".article__text, .article__quote-text")
-				} else {
-					targetNodes = doc.Find(".article__text, .article__quote-text")
-				}
-
-				targetNodes.Each(func(j int, s *goquery.Selection) {
-					currentTextPart := strings.TrimSpace(s.Text())
-					if currentTextPart != "" {
-						if bodyBuilder.Len() > 0 {
-							bodyBuilder.WriteString("\n\n")
-						}
-						bodyBuilder.WriteString(currentTextPart)
-					}
-				})
-				body = bodyBuilder.String()
-
-				dateTextRaw := doc.Find("div.article__info-date > a").First().Text()
-				dateToParse := strings.TrimSpace(dateTextRaw)
-				var dateParseError error
-
-				if dateToParse != "" {
-					parsedTime, parseErr := time.ParseInLocation(dateLayout, dateToParse, locationPlus3)
-					if parseErr != nil {
-						dateParseError = parseErr
-						fmt.Printf("%s[RIA]%s[WARNING] Ошибка парсинга даты: '%s' (формат '%s') на %s: %v%s\n", ColorBlue, ColorYellow, dateToParse, dateLayout, pageURL, parseErr, ColorReset)
-					} else {
-						parsDate = parsedTime
-					}
-				}
-
-				doc.Find("div.article__tags a.article__tags-item").Each(func(_ int, s *goquery.Selection) {
-					tagText := strings.TrimSpace(s.Text())
-					if tagText != "" {
-						tags = append(tags, tagText)
-					}
-				})
-
-				if title != "" && body != "" && !parsDate.IsZero() && (!tagsAreMandatory || len(tags) > 0) {
-					dataItem := Data{
-						Site:  riaURL,
-						Href:  pageURL,
-						Title: title,
-						Body:  body,
-						Date:  parsDate,
-						Tags:  tags,
-					}
-					hash, err := dataItem.Hashing()
-					if err != nil {
-						resultsChan <- pageParseResultRia{PageURL: pageURL, Error: fmt.Errorf("ошибка генерации хеша: %w", err)}
-						continue
-					}
-					dataItem.Hash = hash
-					resultsChan <- pageParseResultRia{Data: dataItem}
-				} else {
-					var reasons []string
-					if title == "" {
-						reasons = append(reasons, "T:false")
-					}
-					if body == "" {
-						reasons = append(reasons, "B:false")
-					}
-					if parsDate.IsZero() {
-						reasonDate := "D:false"
-						if dateParseError != nil {
-							reasonDate = fmt.Sprintf("D:false (err: %v, str: '%s')", dateParseError, dateToParse)
-						} else if dateToParse == "" {
-							reasonDate = "D:false (empty_str)"
-						}
-						reasons = append(reasons, reasonDate)
-					}
-					if tagsAreMandatory && len(tags) == 0 {
-						reasons = append(reasons, "Tags:false")
-					}
-					resultsChan <- pageParseResultRia{PageURL: pageURL, IsEmpty: true, Reasons: reasons}
-				}
+	targetNodes.Each(func(j int, s *goquery.Selection) {
+		currentTextPart := strings.TrimSpace(s.Text())
+		if currentTextPart != "" {
+			if bodyBuilder.Len() > 0 {
+				bodyBuilder.WriteString("\n\n")
 			}
-		}()
-	}
+			bodyBuilder.WriteString(currentTextPart)
+		}
+	})
+	body = bodyBuilder.String()
 
-	go func() {
-		wg.Wait()
-		close(resultsChan)
-	}()
+	dateTextRaw := doc.Find("div.article__info-date > a").First().Text()
+	dateToParse := strings.TrimSpace(dateTextRaw)
+	var dateParseError error
 
-	for result := range resultsChan {
-		if result.Error != nil {
-			errItems = append(errItems, fmt.Sprintf("%s (%s)", result.PageURL, result.Error.Error()))
-		} else if result.IsEmpty {
-			errItems = append(errItems, fmt.Sprintf("%s (нет данных: %s)", result.PageURL, strings.Join(result.Reasons, ", ")))
+	if dateToParse != "" {
+		parsedTime, parseErr := dates.Parse(dateToParse)
+		if parseErr != nil {
+			dateParseError = parseErr
+			fmt.Printf("%s[RIA]%s[WARNING] Ошибка парсинга даты: '%s' на %s: %v%s\n", ColorBlue, ColorYellow, dateToParse, pageURL, parseErr, ColorReset)
 		} else {
-			products = append(products, result.Data)
+			parsDate = parsedTime
 		}
 	}
 
-	if len(products) > 0 {
-		if len(errItems) > 0 {
-			fmt.Printf("%s[RIA]%s[WARNING] Не удалось обработать %d из %d страниц (или отсутствовали данные):%s\n", ColorBlue, ColorYellow, len(errItems), totalLinks, ColorReset)
-			for idx, itemMessage := range errItems {
-				fmt.Printf("%s  %d. %s%s\n", ColorYellow, idx+1, itemMessage, ColorReset)
-			}
-		}
-	} else if totalLinks > 0 {
-		fmt.Printf("%s[RIA]%s[ERROR] Парсинг статей RIA.ru завершен, но не удалось собрать данные ни с одной из %d страниц.%s\n", ColorBlue, ColorRed, totalLinks, ColorReset)
-		if len(errItems) > 0 {
-			fmt.Printf("%s[RIA]%s[INFO] Список страниц с ошибками или без данных:%s\n", ColorBlue, ColorYellow, ColorReset)
-			for idx, itemMessage := range errItems {
-				fmt.Printf("%s  %d. %s%s\n", ColorYellow, idx+1, itemMessage, ColorReset)
-			}
+	doc.Find("div.article__tags a.article__tags-item").Each(func(_ int, s *goquery.Selection) {
+		tagText := strings.TrimSpace(s.Text())
+		if tagText != "" {
+			tags = append(tags, tagText)
 		}
-	}
-	return products, links
+	})
+
+	return finishPage(doc, item, riaMetadata, Data{
+		Site:  riaURL,
+		Href:  pageURL,
+		Title: title,
+		Body:  body,
+		Date:  parsDate,
+		Tags:  tags,
+	}, tagsAreMandatory, dateReason(dateParseError, dateToParse, "empty_str"))
 }
@@ -0,0 +1,99 @@
+package parsers
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	. "parsing_media/utils"
+	"slices"
+	"strings"
+	"time"
+)
+
+// maxSitemapFetches - предел загружаемых карт за один сбор ссылок: индексы крупных сайтов ссылаются на сотни карт
+const maxSitemapFetches = 20
+
+// sitemapLinks собирает ссылки на статьи из карт сайта: заданных парсером или перечисленных в robots.txt.
+// Индексы карт раскрываются, вложенные карты и статьи старше sitemapMaxAge (по дате публикации Google News
+// или lastmod) пропускаются, как и статьи без даты. Ошибка возвращается, только если не прочитана ни одна карта.
+func (b *baseParser) sitemapLinks(ctx context.Context, accept func(href string) bool) ([]LinkItem, error) {
+	tag := strings.ToUpper(b.name)
+	queue := slices.Clone(b.sitemaps)
+	if len(queue) == 0 {
+		discovered, err := GetRobotsSitemapsForClient(ctx, b.client, b.siteURL)
+		if err != nil {
+			return nil, fmt.Errorf("поиск карт сайта: %w", err)
+		}
+		// Карты новостей обычно невелики и содержат даты публикации, поэтому читаются первыми
+		queue = discovered
+		slices.SortStableFunc(queue, func(x, y string) int {
+			return cmp.Compare(sitemapRank(x), sitemapRank(y))
+		})
+	}
+
+	cutoff := time.Now().Add(-sitemapMaxAge)
+	var foundLinks []LinkItem
+	var failures []error
+	seenLinks := make(map[string]bool)
+	visited := make(map[string]bool)
+	fetched := 0
+
+	for len(queue) > 0 && fetched < maxSitemapFetches {
+		sitemapURL := queue[0]
+		queue = queue[1:]
+		if visited[sitemapURL] {
+			continue
+		}
+		visited[sitemapURL] = true
+
+		fetched++
+		sitemap, err := GetSitemapForClient(ctx, b.client, sitemapURL)
+		if err != nil {
+			if ctx.Err() != nil {
+				return foundLinks, ctx.Err()
+			}
+			failures = append(failures, err)
+			continue
+		}
+		for _, child := range sitemap.Sitemaps {
+			if child.LastMod.IsZero() || !child.LastMod.Before(cutoff) {
+				queue = append(queue, child.Loc)
+			}
+		}
+		for _, entry := range sitemap.URLs {
+			published := entry.Published
+			if published.IsZero() {
+				published = entry.LastMod
+			}
+			href := CanonicalURL(entry.Loc)
+			if published.IsZero() || published.Before(cutoff) || !accept(href) || seenLinks[href] {
+				continue
+			}
+			seenLinks[href] = true
+			foundLinks = append(foundLinks, LinkItem{Href: href, Tags: entry.Keywords, Title: entry.Title, Date: published})
+		}
+	}
+
+	if fetched > 0 && len(failures) == fetched {
+		return nil, errors.Join(failures...)
+	}
+	for _, err := range failures {
+		fmt.Printf("%s[%s]%s[WARNING] %v%s\n", ColorBlue, tag, ColorYellow, err, ColorReset)
+	}
+	if len(queue) > 0 {
+		fmt.Printf("%s[%s]%s[WARNING] Прочитано карт сайта: %d (предел), ещё %d в очереди пропущены%s\n", ColorBlue, tag, ColorYellow, fetched, len(queue), ColorReset)
+	}
+	if len(foundLinks) == 0 {
+		fmt.Printf("%s[%s]%s[WARNING] В картах сайта не найдено статей за последние %s.%s\n", ColorBlue, tag, ColorYellow, sitemapMaxAge, ColorReset)
+	}
+	return foundLinks, nil
+}
+
+// sitemapRank ставит карты новостей (sitemap-news.xml и подобные) перед остальными
+func sitemapRank(sitemapURL string) int {
+	if strings.Contains(strings.ToLower(sitemapURL), "news") {
+		return 0
+	}
+	return 1
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"parsing_media/parsers"
+	"parsing_media/storage"
+	. "parsing_media/utils"
+	"sync"
+	"time"
+)
+
+// recrawlGrace - сколько статья остаётся в выборке после последнего срока проверки, если обход запаздывал
+const recrawlGrace = time.Hour
+
+// recrawlStats - итог одного прохода повторного обхода
+type recrawlStats struct {
+	Checked   int
+	Revised   int
+	Retracted int
+	Failed    int
+}
+
+// recrawlOnce проверяет статьи, для которых наступил очередной срок из cfg.Recrawl.Offsets.
+// Изменённое содержимое сохраняется новой редакцией, а статьи, которые отвечают 404/410
+// или перенаправляют на главную страницу, отмечаются удалёнными. Неудачные проверки повторяются в следующем проходе.
+func recrawlOnce(ctx context.Context) (recrawlStats, error) {
+	var stats recrawlStats
+	rechecker, ok := store.(storage.Rechecker)
+	if !ok {
+		return stats, fmt.Errorf("хранилище '%s' не поддерживает повторный обход", cfg.Storage.Backend)
+	}
+
+	now := time.Now()
+	candidates, err := rechecker.RecentArticles(ctx, now.Add(-cfg.Recrawl.MaxOffset()-recrawlGrace))
+	if err != nil {
+		return stats, err
+	}
+	due := make(map[string]storage.RecrawlCandidate)
+	var urls []string
+	for _, candidate := range candidates {
+		if candidate.Due(cfg.Recrawl.Offsets, now) {
+			due[candidate.Href] = candidate
+			urls = append(urls, candidate.Href)
+		}
+	}
+	if len(urls) == 0 {
+		return stats, nil
+	}
+	fmt.Printf("%s[RECRAWL]%s[INFO] Повторная проверка статей: %d%s\n", ColorBlue, ColorYellow, len(urls), ColorReset)
+
+	var mu sync.Mutex
+	report := Crawl(ctx, urls, CrawlOptions{Workers: cfg.Recrawl.Workers}, func(ctx context.Context, pageURL string) PageResult {
+		p, ok := findParserByURL(pageURL)
+		if !ok {
+			return PageResult{PageURL: pageURL, Error: fmt.Errorf("не найден парсер для %s", pageURL)}
+		}
+		result := p.ParsePage(ctx, parsers.LinkItem{Href: pageURL, Tags: due[pageURL].Tags})
+
+		var gone *PageGoneError
+		if errors.As(result.Error, &gone) {
+			if err := rechecker.MarkRetracted(ctx, pageURL, time.Now(), gone.Reason()); err != nil {
+				return PageResult{PageURL: pageURL, Error: err}
+			}
+			fmt.Printf("%s[RECRAWL]%s[WARNING] Статья удалена (%s): %s%s\n", ColorBlue, ColorYellow, gone.Reason(), pageURL, ColorReset)
+			mu.Lock()
+			stats.Retracted++
+			mu.Unlock()
+		}
+		return result
+	})
+
+	saved, err := store.Save(ctx, report.Data)
+	if err != nil {
+		return stats, fmt.Errorf("сохранение редакций: %w", err)
+	}
+	for _, article := range saved.Revised {
+		fmt.Printf("%s[RECRAWL]%s[INFO] Статья изменена: %s (%s)%s\n", ColorBlue, ColorGreen, LimitString(article.Title, 60), article.Href, ColorReset)
+	}
+	// Новые редакции уже отмечены как проверенные при сохранении
+	for _, article := range append(saved.Duplicates, saved.Inserted...) {
+		if err := rechecker.MarkChecked(ctx, article.Href, article.FetchedAt); err != nil {
+			return stats, err
+		}
+	}
+
+	stats.Checked = len(report.Data) + stats.Retracted
+	stats.Revised = len(saved.Revised)
+	stats.Failed = len(report.Failed) - stats.Retracted + len(saved.Failed)
+	fmt.Printf("%s[RECRAWL]%s[INFO] Проверено: %d, новых редакций: %d, удалено: %d, ошибок: %d (%s)%s\n",
+		ColorBlue, ColorYellow, stats.Checked, stats.Revised, stats.Retracted, stats.Failed, FormatDuration(report.Elapsed), ColorReset)
+	return stats, nil
+}
+
+// runRecrawlLoop выполняет recrawlOnce каждые cfg.Recrawl.Interval, пока не отменён ctx
+func runRecrawlLoop(ctx context.Context) {
+	next := time.Now()
+	for {
+		if !sleepUntil(ctx, next) {
+			return
+		}
+		startTime := time.Now()
+		if _, err := recrawlOnce(ctx); err != nil && ctx.Err() == nil {
+			fmt.Printf("%s[RECRAWL]%s[ERROR] %v%s\n", ColorBlue, ColorRed, err, ColorReset)
+		}
+		next = startTime.Add(cfg.Recrawl.Interval)
+	}
+}
@@ -0,0 +1,59 @@
+// rudate/months.go
+package rudate
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// monthNames - формы названий месяцев: именительный и родительный падежи и сокращения
+var monthNames = map[string]time.Month{}
+
+func init() {
+	forms := [][]string{
+		{"январь", "января", "янв"},
+		{"февраль", "февраля", "фев", "февр"},
+		{"март", "марта", "мар"},
+		{"апрель", "апреля", "апр"},
+		{"май", "мая"},
+		{"июнь", "июня", "июн"},
+		{"июль", "июля", "июл"},
+		{"август", "августа", "авг"},
+		{"сентябрь", "сентября", "сен", "сент"},
+		{"октябрь", "октября", "окт"},
+		{"ноябрь", "ноября", "ноя", "нояб"},
+		{"декабрь", "декабря", "дек"},
+	}
+	for i, names := range forms {
+		for _, name := range names {
+			monthNames[name] = time.Month(i + 1)
+		}
+	}
+}
+
+// Month возвращает месяц по русскому названию в любом регистре: "января", "Январь", "янв."
+func Month(word string) (time.Month, bool) {
+	month, ok := monthNames[strings.TrimSuffix(normalize(word), ".")]
+	return month, ok
+}
+
+var wordRegex = regexp.MustCompile(`[а-яёА-ЯЁ]+`)
+
+// ReplaceMonths заменяет первое русское название месяца в строке его номером ("2 января 2006" -> "2 01 2006"),
+// чтобы строку можно было разобрать форматом Go
+func ReplaceMonths(s string) string {
+	replaced := false
+	return wordRegex.ReplaceAllStringFunc(s, func(word string) string {
+		if replaced {
+			return word
+		}
+		month, ok := Month(word)
+		if !ok {
+			return word
+		}
+		replaced = true
+		return fmt.Sprintf("%02d", int(month))
+	})
+}
@@ -0,0 +1,200 @@
+// rudate/rudate.go
+
+// Package rudate разбирает даты публикации в том виде, в каком их пишут русскоязычные сайты:
+// "сегодня, 14:05", "вчера в 14:05", "5 минут назад", "2 января, 15:04", "15:04, 2 января 2006",
+// "02.01.2006 15:04", а также ISO 8601 / RFC3339 из атрибутов и разметки.
+package rudate
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Moscow - часовой пояс, в котором сайты указывают время без смещения.
+// Если в системе нет базы часовых поясов, используется фиксированное смещение UTC+3.
+var Moscow = loadMoscow()
+
+func loadMoscow() *time.Location {
+	if loc, err := time.LoadLocation("Europe/Moscow"); err == nil {
+		return loc
+	}
+	return time.FixedZone("MSK", 3*60*60)
+}
+
+// Parser разбирает даты в часовом поясе loc; относительные даты ("вчера", "5 минут назад")
+// и даты без года отсчитываются от часов now
+type Parser struct {
+	loc *time.Location
+	now func() time.Time
+}
+
+// New создаёт парсер с часовым поясом loc (nil - Moscow) и часами now (nil - time.Now)
+func New(loc *time.Location, now func() time.Time) *Parser {
+	if loc == nil {
+		loc = Moscow
+	}
+	if now == nil {
+		now = time.Now
+	}
+	return &Parser{loc: loc, now: now}
+}
+
+// Default - парсер с московским временем и системными часами
+var Default = New(Moscow, time.Now)
+
+// Parse разбирает дату парсером Default
+func Parse(s string) (time.Time, error) { return Default.Parse(s) }
+
+// ParseISO разбирает дату ISO 8601 парсером Default
+func ParseISO(s string) (time.Time, error) { return Default.ParseISO(s) }
+
+// Location возвращает часовой пояс парсера
+func (p *Parser) Location() *time.Location { return p.loc }
+
+// relativeRegex начинается с границы слова: \b в regexp работает только для латиницы,
+// а без границы "полчаса назад" разбиралось бы как "час назад"
+var (
+	isoPrefix     = regexp.MustCompile(`^\d{4}-\d{2}-\d{2}`)
+	relativeRegex = regexp.MustCompile(`(?:^|[^а-я])(?:(\d+)\s+)?(секунд[а-я]*|сек|минут[а-я]*|мин|час[а-я]*|ч|дн[а-я]*|день)\.?\s+назад`)
+	clockRegex    = regexp.MustCompile(`(?:^|[^\d:])(\d{1,2}):(\d{2})(?::(\d{2}))?(?:$|[^\d:])`)
+	numericRegex  = regexp.MustCompile(`(?:^|[^\d.])(\d{1,2})\.(\d{1,2})\.(\d{4}|\d{2})(?:$|[^\d.])`)
+	textualRegex  = regexp.MustCompile(`(\d{1,2})\s+([а-я]+)\.?(?:\s*,?\s*(\d{4})(?:\s*г(?:ода|\.)?)?(?:$|[^\d:]))?`)
+	dayWordRegex  = regexp.MustCompile(`позавчера|вчера|сегодня`)
+)
+
+// Parse разбирает дату в любом из известных форматов. Результат - в часовом поясе парсера.
+// Время без даты относится к сегодняшнему дню, дата без времени - к полуночи. Если в дате нет года,
+// берётся текущий, а если дата при этом оказывается больше чем на сутки в будущем - прошлый.
+func (p *Parser) Parse(s string) (time.Time, error) {
+	original := strings.TrimSpace(s)
+	if isoPrefix.MatchString(original) {
+		return p.ParseISO(original)
+	}
+	s = normalize(s)
+	if s == "" {
+		return time.Time{}, fmt.Errorf("пустая строка даты")
+	}
+
+	now := p.now().In(p.loc)
+	if s == "только что" {
+		return now, nil
+	}
+	if m := relativeRegex.FindStringSubmatch(s); m != nil {
+		return relative(now, m[1], m[2])
+	}
+
+	hour, minute, second := 0, 0, 0
+	hasClock := false
+	if m := clockRegex.FindStringSubmatch(s); m != nil {
+		hour, _ = strconv.Atoi(m[1])
+		minute, _ = strconv.Atoi(m[2])
+		second, _ = strconv.Atoi(m[3])
+		if hour > 23 || minute > 59 || second > 59 {
+			return time.Time{}, fmt.Errorf("неверное время в дате '%s'", original)
+		}
+		hasClock = true
+	}
+
+	year, month, day := 0, time.Month(0), 0
+	switch {
+	case dayWordRegex.MatchString(s):
+		offset := map[string]int{"сегодня": 0, "вчера": -1, "позавчера": -2}[dayWordRegex.FindString(s)]
+		year, month, day = now.AddDate(0, 0, offset).Date()
+	case numericRegex.MatchString(s):
+		m := numericRegex.FindStringSubmatch(s)
+		day, _ = strconv.Atoi(m[1])
+		monthNum, _ := strconv.Atoi(m[2])
+		month = time.Month(monthNum)
+		year, _ = strconv.Atoi(m[3])
+		if year < 100 {
+			year += 2000
+		}
+	default:
+		for _, m := range textualRegex.FindAllStringSubmatch(s, -1) {
+			if found, ok := Month(m[2]); ok {
+				day, _ = strconv.Atoi(m[1])
+				month = found
+				year, _ = strconv.Atoi(m[3])
+				break
+			}
+		}
+	}
+
+	if month == 0 {
+		if !hasClock {
+			return time.Time{}, fmt.Errorf("не удалось распознать дату '%s'", original)
+		}
+		year, month, day = now.Date()
+	}
+	if month < time.January || month > time.December {
+		return time.Time{}, fmt.Errorf("неверный месяц в дате '%s'", original)
+	}
+
+	inferYear := year == 0
+	if inferYear {
+		year = now.Year()
+	}
+	t := time.Date(year, month, day, hour, minute, second, 0, p.loc)
+	if t.Day() != day {
+		return time.Time{}, fmt.Errorf("неверный день в дате '%s'", original)
+	}
+	if inferYear && t.After(now.Add(24*time.Hour)) {
+		t = t.AddDate(-1, 0, 0)
+	}
+	return t, nil
+}
+
+// relative вычисляет дату вида "5 минут назад" от момента now
+func relative(now time.Time, count, unit string) (time.Time, error) {
+	n := 1
+	if count != "" {
+		var err error
+		if n, err = strconv.Atoi(count); err != nil {
+			return time.Time{}, fmt.Errorf("неверное число в относительной дате '%s': %w", count, err)
+		}
+	}
+	var step time.Duration
+	switch {
+	case strings.HasPrefix(unit, "сек"):
+		step = time.Second
+	case strings.HasPrefix(unit, "мин"):
+		step = time.Minute
+	case strings.HasPrefix(unit, "ч"):
+		step = time.Hour
+	default:
+		return now.AddDate(0, 0, -n), nil
+	}
+	return now.Add(-time.Duration(n) * step), nil
+}
+
+var isoLayouts = []string{time.RFC3339Nano, "2006-01-02T15:04:05Z0700", "2006-01-02T15:04Z07:00", "2006-01-02 15:04:05Z07:00", "2006-01-02 15:04:05 -0700"}
+
+var isoLocalLayouts = []string{"2006-01-02T15:04:05.999999999", "2006-01-02T15:04", "2006-01-02 15:04:05", "2006-01-02 15:04", "2006-01-02"}
+
+// ParseISO разбирает дату ISO 8601: RFC3339, смещение без двоеточия (+0300), без секунд
+// или без часового пояса (тогда время считается в поясе парсера). Результат - в часовом поясе парсера.
+func (p *Parser) ParseISO(s string) (time.Time, error) {
+	s = strings.TrimSpace(s)
+	for _, layout := range isoLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t.In(p.loc), nil
+		}
+	}
+	for _, layout := range isoLocalLayouts {
+		if t, err := time.ParseInLocation(layout, s, p.loc); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("не удалось распознать дату ISO 8601 '%s'", s)
+}
+
+// normalize приводит строку к нижнему регистру, заменяет "ё" и неразрывные пробелы
+// и схлопывает пробельные символы
+func normalize(s string) string {
+	s = strings.ToLower(strings.ReplaceAll(s, "\u00a0", " "))
+	s = strings.ReplaceAll(s, "ё", "е")
+	return strings.Join(strings.Fields(s), " ")
+}
@@ -0,0 +1,75 @@
+package rudate
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParse(t *testing.T) {
+	march := time.Date(2026, time.March, 10, 12, 0, 0, 0, Moscow)
+	newYear := time.Date(2026, time.January, 1, 10, 0, 0, 0, Moscow)
+
+	tests := []struct {
+		name  string
+		now   time.Time
+		input string
+		want  time.Time
+	}{
+		{"сегодня", march, "сегодня, 14:05", time.Date(2026, time.March, 10, 14, 5, 0, 0, Moscow)},
+		{"вчера", march, "вчера в 14:05", time.Date(2026, time.March, 9, 14, 5, 0, 0, Moscow)},
+		{"позавчера без времени", march, "позавчера", time.Date(2026, time.March, 8, 0, 0, 0, 0, Moscow)},
+		{"минуты назад", march, "5 минут назад", march.Add(-5 * time.Minute)},
+		{"час назад", march, "час назад", march.Add(-time.Hour)},
+		{"дни назад", march, "2 дня назад", march.AddDate(0, 0, -2)},
+		{"дата без года", march, "2 января, 15:04", time.Date(2026, time.January, 2, 15, 4, 0, 0, Moscow)},
+		{"без года под Новый год", newYear, "31 декабря, 15:04", time.Date(2025, time.December, 31, 15, 4, 0, 0, Moscow)},
+		{"без года завтра", newYear, "2 января, 15:04", time.Date(2025, time.January, 2, 15, 4, 0, 0, Moscow)},
+		{"время перед датой", march, "15:04, 2 января 2006", time.Date(2006, time.January, 2, 15, 4, 0, 0, Moscow)},
+		{"месяц в родительном падеже с годом", march, "2 Января 2006 года", time.Date(2006, time.January, 2, 0, 0, 0, 0, Moscow)},
+		{"числовая", march, "02.01.2006 15:04", time.Date(2006, time.January, 2, 15, 4, 0, 0, Moscow)},
+		{"числовая с коротким годом", march, "02.01.26", time.Date(2026, time.January, 2, 0, 0, 0, 0, Moscow)},
+		{"RFC3339", march, "2006-01-02T15:04:05+03:00", time.Date(2006, time.January, 2, 15, 4, 5, 0, Moscow)},
+		{"RFC3339 UTC", march, "2006-01-02T12:04:05Z", time.Date(2006, time.January, 2, 15, 4, 5, 0, Moscow)},
+		{"смещение без двоеточия", march, "2006-01-02T15:04:05+0300", time.Date(2006, time.January, 2, 15, 4, 5, 0, Moscow)},
+		{"ISO без пояса", march, "2006-01-02T15:04:05", time.Date(2006, time.January, 2, 15, 4, 5, 0, Moscow)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parser := New(Moscow, func() time.Time { return tt.now })
+			got, err := parser.Parse(tt.input)
+			if err != nil {
+				t.Fatalf("Parse(%q): %v", tt.input, err)
+			}
+			if !got.Equal(tt.want) {
+				t.Errorf("Parse(%q) = %v, want %v", tt.input, got, tt.want)
+			}
+			if got.Location() != Moscow {
+				t.Errorf("Parse(%q) вернул пояс %v, want %v", tt.input, got.Location(), Moscow)
+			}
+		})
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	now := time.Date(2026, time.March, 10, 12, 0, 0, 0, Moscow)
+	parser := New(Moscow, func() time.Time { return now })
+
+	tests := []struct {
+		name  string
+		input string
+	}{
+		{"пустая строка", ""},
+		{"пробелы", "   "},
+		{"несуществующий день", "31.02.2026"},
+		{"несуществующий час", "25:00"},
+		{"полчаса не равно часу", "полчаса назад"},
+		{"текст без даты", "недавно"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got, err := parser.Parse(tt.input); err == nil {
+				t.Errorf("Parse(%q) = %v, want error", tt.input, got)
+			}
+		})
+	}
+}
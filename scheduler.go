@@ -0,0 +1,221 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"parsing_media/parsers"
+	"parsing_media/storage"
+	. "parsing_media/utils"
+	"strings"
+	"sync"
+	"time"
+)
+
+// scheduledParser - парсер сайта вместе с его расписанием
+type scheduledParser struct {
+	parser   parsers.Parser
+	schedule Schedule
+}
+
+// seenLinksRuns - сколько последних запусков помнит linkTracker при определении новых ссылок
+const seenLinksRuns = 5
+
+// linkTracker запоминает ссылки последних запусков сайта, чтобы отличать действительно новые
+type linkTracker struct {
+	run  int
+	seen map[string]int // ссылка -> номер запуска, в котором она встречалась последний раз
+}
+
+// observe отмечает ссылки очередного запуска и возвращает количество ранее не встречавшихся
+func (t *linkTracker) observe(links []parsers.LinkItem) int {
+	if t.seen == nil {
+		t.seen = make(map[string]int)
+	}
+	t.run++
+
+	newLinks := 0
+	for _, link := range links {
+		if _, ok := t.seen[link.Href]; !ok {
+			newLinks++
+		}
+		t.seen[link.Href] = t.run
+	}
+	for href, run := range t.seen {
+		if t.run-run >= seenLinksRuns {
+			delete(t.seen, href)
+		}
+	}
+	return newLinks
+}
+
+// siteStatus - состояние расписания одного сайта для файла статуса
+type siteStatus struct {
+	Site        string    `json:"site"`
+	Schedule    string    `json:"schedule"`
+	Interval    string    `json:"interval,omitempty"` // текущий интервал адаптивного расписания
+	LastRun     time.Time `json:"last_run,omitzero"`
+	LastNew     int       `json:"last_new_links"`
+	AvgNew      float64   `json:"avg_new_links"`
+	NextRun     time.Time `json:"next_run"`
+	LastElapsed string    `json:"last_elapsed,omitempty"`
+}
+
+// schedulerStatus собирает состояние всех сайтов и сохраняет его в loop.status_file
+type schedulerStatus struct {
+	mu    sync.Mutex
+	sites map[string]siteStatus
+	order []string
+}
+
+func (s *schedulerStatus) update(status siteStatus) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.sites == nil {
+		s.sites = make(map[string]siteStatus)
+	}
+	if _, ok := s.sites[status.Site]; !ok {
+		s.order = append(s.order, status.Site)
+	}
+	s.sites[status.Site] = status
+
+	if cfg.Loop.StatusFile == "" {
+		return
+	}
+	snapshot := make([]siteStatus, 0, len(s.order))
+	for _, site := range s.order {
+		snapshot = append(snapshot, s.sites[site])
+	}
+	if err := writeJSONFile(cfg.Loop.StatusFile, snapshot); err != nil {
+		fmt.Printf("%s[WARNING] Не удалось записать файл статуса %s: %v%s\n", ColorYellow, cfg.Loop.StatusFile, err, ColorReset)
+	}
+}
+
+// writeJSONFile атомарно перезаписывает JSON-файл (статус, контрольная точка) через временный файл
+func writeJSONFile(path string, value any) error {
+	raw, err := json.MarshalIndent(value, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmpPath := path + ".tmp"
+	if err := os.WriteFile(tmpPath, raw, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmpPath, path)
+}
+
+// buildSchedules сопоставляет парсерам расписания из конфигурации (loop.schedules или общий loop.interval)
+func buildSchedules(parserList []parsers.Parser) ([]scheduledParser, error) {
+	jobs := make([]scheduledParser, 0, len(parserList))
+	for _, p := range parserList {
+		schedule, err := cfg.ScheduleFor(p.Name())
+		if err != nil {
+			return nil, fmt.Errorf("расписание %s: %w", p.Name(), err)
+		}
+		jobs = append(jobs, scheduledParser{parser: p, schedule: schedule})
+	}
+	return jobs, nil
+}
+
+// runScheduler запускает каждый парсер независимо по его расписанию, пока не отменён ctx.
+// Запуски одного сайта не перекрываются: следующий планируется только после завершения предыдущего.
+func runScheduler(ctx context.Context, jobs []scheduledParser) {
+	now := time.Now()
+	fmt.Printf("\n%s[INFO] Расписание запуска парсеров (%d):%s\n", ColorBlue, len(jobs), ColorReset)
+	for _, job := range jobs {
+		fmt.Printf("  %-15s %-30s первый запуск в %s\n", job.parser.Name(), job.schedule, job.schedule.First(now).Format("15:04:05"))
+	}
+
+	status := &schedulerStatus{}
+	var wg sync.WaitGroup
+	if cfg.Recrawl.Enabled {
+		if _, ok := store.(storage.Rechecker); ok {
+			fmt.Printf("  %-15s %-30s первый запуск в %s\n", "recrawl", "каждые "+cfg.Recrawl.Interval.String(), now.Format("15:04:05"))
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				runRecrawlLoop(ctx)
+			}()
+		} else {
+			fmt.Printf("%s[WARNING] Хранилище '%s' не поддерживает повторный обход, recrawl отключён%s\n", ColorYellow, cfg.Storage.Backend, ColorReset)
+		}
+	}
+	for _, job := range jobs {
+		status.update(siteStatus{Site: job.parser.Name(), Schedule: job.schedule.String(), NextRun: job.schedule.First(now)})
+		wg.Add(1)
+		go func(job scheduledParser) {
+			defer wg.Done()
+			runScheduled(ctx, job, status)
+		}(job)
+	}
+	wg.Wait()
+}
+
+// runScheduled - цикл одного сайта: ожидание времени запуска, запуск, вычисление следующего времени.
+// Адаптивное расписание получает число новых ссылок каждого запуска, кроме первого (он задаёт базу для сравнения).
+func runScheduled(ctx context.Context, job scheduledParser, status *schedulerStatus) {
+	tag := strings.ToUpper(job.parser.Name())
+	adaptive, isAdaptive := job.schedule.(*AdaptiveSchedule)
+	var tracker linkTracker
+	next := job.schedule.First(time.Now())
+
+	for runNumber := 1; ; runNumber++ {
+		if !sleepUntil(ctx, next) {
+			return
+		}
+
+		startTime := time.Now()
+		result := runParser(ctx, job.parser)
+		if ctx.Err() != nil {
+			return
+		}
+
+		newLinks := tracker.observe(result.Links)
+		if isAdaptive && runNumber > 1 && result.Err == nil {
+			adaptive.Observe(newLinks)
+		}
+
+		next = job.schedule.Next(startTime)
+		now := time.Now()
+		overran := next.Before(now)
+		if overran {
+			next = now
+		}
+
+		current := siteStatus{
+			Site:        job.parser.Name(),
+			Schedule:    job.schedule.String(),
+			LastRun:     startTime,
+			LastNew:     newLinks,
+			AvgNew:      float64(newLinks),
+			NextRun:     next,
+			LastElapsed: FormatDuration(now.Sub(startTime)),
+		}
+		if isAdaptive {
+			current.Interval = adaptive.Interval().String()
+			_, current.AvgNew = adaptive.Stats()
+		}
+		status.update(current)
+
+		if overran {
+			fmt.Printf("%s[%s]%s[WARN] Парсер работал дольше расписания (%s, %s). Немедленный перезапуск.%s\n", ColorBlue, tag, ColorYellow, current.LastElapsed, job.schedule, ColorReset)
+			continue
+		}
+		fmt.Printf("%s[%s]%s[INFO] Новых ссылок: %d. Следующий запуск в %s (%s)%s\n", ColorBlue, tag, ColorYellow, newLinks, next.Format("15:04:05"), job.schedule, ColorReset)
+	}
+}
+
+// sleepUntil ждёт наступления момента t. Возвращает false, если ожидание прервано отменой ctx.
+func sleepUntil(ctx context.Context, t time.Time) bool {
+	timer := time.NewTimer(time.Until(t))
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
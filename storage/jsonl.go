@@ -0,0 +1,214 @@
+// storage/jsonl.go
+package storage
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	. "parsing_media/utils"
+	"sync"
+	"time"
+)
+
+// jsonlRecord - одна строка файла JSON Lines: редакция статьи
+type jsonlRecord struct {
+	Hash         string    `json:"hash"`
+	Site         string    `json:"site"`
+	Href         string    `json:"href"`
+	CanonicalURL string    `json:"canonical_url,omitempty"`
+	Title        string    `json:"title"`
+	Body         string    `json:"body"`
+	Date         time.Time `json:"date"`
+	Tags         []string  `json:"tags"`
+	FetchedAt    time.Time `json:"fetched_at,omitzero"`
+	Authors      []string  `json:"authors,omitempty"`
+	Lead         string    `json:"lead,omitempty"`
+	Rubric       string    `json:"rubric,omitempty"`
+	Image        string    `json:"image,omitempty"`
+	Modified     time.Time `json:"modified,omitzero"`
+	BodyFallback bool      `json:"body_fallback,omitempty"`
+	RichBody     string    `json:"rich_body,omitempty"`
+	RichFormat   string    `json:"rich_format,omitempty"`
+}
+
+// revision восстанавливает редакцию из строки файла; в строках старого формата нет канонической ссылки и времени загрузки
+func (r jsonlRecord) revision() Revision {
+	canonical := r.CanonicalURL
+	if canonical == "" {
+		canonical = CanonicalURL(r.Href)
+	}
+	fetchedAt := r.FetchedAt
+	if fetchedAt.IsZero() {
+		fetchedAt = r.Date
+	}
+	return Revision{
+		CanonicalURL: canonical,
+		Data: Data{
+			Hash: r.Hash, Site: r.Site, Href: r.Href, Title: r.Title, Body: r.Body, Date: r.Date, Tags: r.Tags, FetchedAt: fetchedAt,
+			Authors: r.Authors, Lead: r.Lead, Rubric: r.Rubric, Image: r.Image, Modified: r.Modified, BodyFallback: r.BodyFallback,
+			RichBody: r.RichBody, RichFormat: r.RichFormat,
+		},
+	}
+}
+
+// JSONL - хранилище в файле JSON Lines: одна редакция статьи на строку, новые статьи и редакции дописываются в конец.
+// Последняя строка с данной канонической ссылкой - текущая редакция статьи.
+// Ссылки и хеши текущих редакций читаются при открытии, чтобы не дописывать дубликаты.
+type JSONL struct {
+	mu      sync.Mutex
+	path    string
+	file    *os.File
+	current map[string]string // каноническая ссылка -> хеш текущей редакции
+	hashes  map[string]bool   // хеши текущих редакций
+}
+
+// OpenJSONL открывает (или создаёт) файл path
+func OpenJSONL(path string) (*JSONL, error) {
+	s := &JSONL{path: path, current: make(map[string]string), hashes: make(map[string]bool)}
+
+	err := s.scan(func(r Revision) {
+		delete(s.hashes, s.current[r.CanonicalURL])
+		s.current[r.CanonicalURL] = r.Hash
+		s.hashes[r.Hash] = true
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("открытие файла %s: %w", path, err)
+	}
+	s.file = file
+	return s, nil
+}
+
+// scan передаёт в fn все редакции из файла по порядку
+func (s *JSONL) scan(fn func(Revision)) error {
+	file, err := os.Open(s.path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("открытие файла %s: %w", s.path, err)
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var record jsonlRecord
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			return fmt.Errorf("%s:%d: %w", s.path, lineNumber, err)
+		}
+		fn(record.revision())
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("чтение файла %s: %w", s.path, err)
+	}
+	return nil
+}
+
+func (s *JSONL) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.file.Close()
+}
+
+// Save дописывает в файл новые статьи и новые редакции уже сохранённых
+func (s *JSONL) Save(ctx context.Context, articles []Data) (SaveResult, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var result SaveResult
+	// Изменения этой пачки применяются к s.current и s.hashes только после успешной записи
+	batchCurrent := make(map[string]string)
+	batchHashes := make(map[string]bool)
+	writer := bufio.NewWriter(s.file)
+	for _, p := range articles {
+		if err := ctx.Err(); err != nil {
+			return SaveResult{}, err
+		}
+		if s.hashes[p.Hash] || batchHashes[p.Hash] {
+			result.add(p, savedDuplicate)
+			continue
+		}
+		if p.FetchedAt.IsZero() {
+			p.FetchedAt = time.Now()
+		}
+
+		canonical := CanonicalURL(p.Href)
+		line, err := json.Marshal(jsonlRecord{
+			Hash: p.Hash, Site: p.Site, Href: p.Href, CanonicalURL: canonical, Title: p.Title, Body: p.Body,
+			Date: p.Date, Tags: nonNilTags(p.Tags), FetchedAt: p.FetchedAt,
+			Authors: p.Authors, Lead: p.Lead, Rubric: p.Rubric, Image: p.Image, Modified: p.Modified, BodyFallback: p.BodyFallback,
+			RichBody: p.RichBody, RichFormat: p.RichFormat,
+		})
+		if err != nil {
+			result.fail(p, fmt.Errorf("сериализация: %w", err))
+			continue
+		}
+		writer.Write(line)
+		writer.WriteByte('\n')
+
+		_, known := s.current[canonical]
+		_, knownInBatch := batchCurrent[canonical]
+		if known || knownInBatch {
+			result.add(p, savedRevision)
+		} else {
+			result.add(p, savedNew)
+		}
+		batchCurrent[canonical] = p.Hash
+		batchHashes[p.Hash] = true
+	}
+
+	if err := writer.Flush(); err != nil {
+		return SaveResult{}, fmt.Errorf("запись в %s: %w", s.path, err)
+	}
+	if err := s.file.Sync(); err != nil {
+		return SaveResult{}, fmt.Errorf("запись в %s: %w", s.path, err)
+	}
+
+	for canonical, hash := range batchCurrent {
+		delete(s.hashes, s.current[canonical])
+		s.current[canonical] = hash
+		s.hashes[hash] = true
+	}
+	return result, nil
+}
+
+func (s *JSONL) KnownHrefs(_ context.Context, hrefs []string) (map[string]bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return knownByCanonical(hrefs, func(canonical []string) (map[string]bool, error) {
+		stored := make(map[string]bool)
+		for _, c := range canonical {
+			if _, ok := s.current[c]; ok {
+				stored[c] = true
+			}
+		}
+		return stored, nil
+	})
+}
+
+// Revisions читает редакции статьи из файла
+func (s *JSONL) Revisions(_ context.Context, href string) ([]Revision, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	canonical := CanonicalURL(href)
+	var revisions []Revision
+	err := s.scan(func(r Revision) {
+		if r.CanonicalURL == canonical {
+			revisions = append(revisions, r)
+		}
+	})
+	return revisions, err
+}
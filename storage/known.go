@@ -0,0 +1,93 @@
+// storage/known.go
+package storage
+
+import (
+	"container/list"
+	"context"
+	"sync"
+)
+
+// KnownURLs - LRU-кеш ссылок на уже сохранённые статьи с проверкой по хранилищу.
+// Кешируются только найденные ссылки: отсутствие в БД не запоминается, статью может сохранить другой процесс.
+type KnownURLs struct {
+	store    Storage
+	mu       sync.Mutex
+	capacity int
+	order    *list.List               // от недавно использованных к давно использованным
+	items    map[string]*list.Element // ссылка -> элемент order
+}
+
+// NewKnownURLs создаёт кеш на capacity ссылок поверх хранилища store
+func NewKnownURLs(store Storage, capacity int) *KnownURLs {
+	if capacity < 1 {
+		capacity = 1
+	}
+	return &KnownURLs{
+		store:    store,
+		capacity: capacity,
+		order:    list.New(),
+		items:    make(map[string]*list.Element, capacity),
+	}
+}
+
+// FilterNew возвращает ссылки, которых нет ни в кеше, ни в хранилище, сохраняя их порядок.
+// Ссылки, найденные в хранилище, добавляются в кеш.
+func (k *KnownURLs) FilterNew(ctx context.Context, hrefs []string) ([]string, error) {
+	var misses []string
+	k.mu.Lock()
+	for _, href := range hrefs {
+		if element, ok := k.items[href]; ok {
+			k.order.MoveToFront(element)
+			continue
+		}
+		misses = append(misses, href)
+	}
+	k.mu.Unlock()
+
+	if len(misses) == 0 {
+		return nil, nil
+	}
+
+	stored, err := k.store.KnownHrefs(ctx, misses)
+	if err != nil {
+		return nil, err
+	}
+
+	var fresh []string
+	var found []string
+	for _, href := range misses {
+		if stored[href] {
+			found = append(found, href)
+		} else {
+			fresh = append(fresh, href)
+		}
+	}
+	k.Add(found...)
+	return fresh, nil
+}
+
+// Add отмечает ссылки как сохранённые
+func (k *KnownURLs) Add(hrefs ...string) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	for _, href := range hrefs {
+		if element, ok := k.items[href]; ok {
+			k.order.MoveToFront(element)
+			continue
+		}
+		k.items[href] = k.order.PushFront(href)
+		if k.order.Len() > k.capacity {
+			oldest := k.order.Back()
+			k.order.Remove(oldest)
+			delete(k.items, oldest.Value.(string))
+		}
+	}
+}
+
+// Len возвращает количество ссылок в кеше
+func (k *KnownURLs) Len() int {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	return k.order.Len()
+}
@@ -0,0 +1,113 @@
+package storage
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+
+	. "parsing_media/utils"
+)
+
+// testArticle собирает статью с посчитанным хешем
+func testArticle(t *testing.T, href, title string, tags ...string) Data {
+	t.Helper()
+	p := Data{Site: "https://site.ru", Href: href, Title: title, Body: "Текст статьи " + title, Tags: tags,
+		Date: time.Date(2025, time.October, 7, 10, 0, 0, 0, time.UTC)}
+	hash, err := p.Hashing()
+	if err != nil {
+		t.Fatal(err)
+	}
+	p.Hash = hash
+	return p
+}
+
+// countingStore запоминает ссылки, с которыми KnownURLs обращался к хранилищу
+type countingStore struct {
+	*Memory
+	asked [][]string
+}
+
+func (s *countingStore) KnownHrefs(ctx context.Context, hrefs []string) (map[string]bool, error) {
+	s.asked = append(s.asked, hrefs)
+	return s.Memory.KnownHrefs(ctx, hrefs)
+}
+
+func TestKnownURLsFilterNew(t *testing.T) {
+	ctx := context.Background()
+	store := &countingStore{Memory: NewMemory()}
+	saved := testArticle(t, "https://site.ru/news/1", "Сохранённая")
+	if _, err := store.Save(ctx, []Data{saved}); err != nil {
+		t.Fatal(err)
+	}
+	known := NewKnownURLs(store, 10)
+
+	// Метки utm_*, фрагмент и регистр домена не делают ссылку новой
+	hrefs := []string{
+		"https://site.ru/news/1",
+		"https://Site.ru/news/1?utm_source=telegram#comments",
+		"https://site.ru/news/2",
+	}
+	fresh, err := known.FilterNew(ctx, hrefs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"https://site.ru/news/2"}; !slices.Equal(fresh, want) {
+		t.Errorf("FilterNew = %q, want %q", fresh, want)
+	}
+	if known.Len() != 2 {
+		t.Errorf("в кеше %d ссылок, want 2 найденные в хранилище", known.Len())
+	}
+
+	// Найденные ссылки берутся из кеша, в хранилище уходит только то, чего в нём нет
+	store.asked = nil
+	fresh, err = known.FilterNew(ctx, hrefs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(fresh, []string{"https://site.ru/news/2"}) {
+		t.Errorf("повторный FilterNew = %q", fresh)
+	}
+	if want := [][]string{{"https://site.ru/news/2"}}; len(store.asked) != 1 || !slices.Equal(store.asked[0], want[0]) {
+		t.Errorf("запросы к хранилищу %q, want %q", store.asked, want)
+	}
+
+	store.asked = nil
+	if fresh, err := known.FilterNew(ctx, hrefs[:2]); err != nil || fresh != nil {
+		t.Errorf("FilterNew известных ссылок = %q, %v; want nil", fresh, err)
+	}
+	if len(store.asked) != 0 {
+		t.Errorf("ссылки из кеша запрошены у хранилища: %q", store.asked)
+	}
+}
+
+func TestKnownURLsEviction(t *testing.T) {
+	ctx := context.Background()
+	store := &countingStore{Memory: NewMemory()}
+	known := NewKnownURLs(store, 3)
+	known.Add("a", "b", "c")
+	// Обращение к "a" делает её недавней, поэтому следующая ссылка вытесняет "b"
+	if fresh, err := known.FilterNew(ctx, []string{"a"}); err != nil || fresh != nil {
+		t.Fatalf("FilterNew(a) = %q, %v", fresh, err)
+	}
+	known.Add("d")
+	if known.Len() != 3 {
+		t.Errorf("в кеше %d ссылок, want 3", known.Len())
+	}
+
+	store.asked = nil
+	fresh, err := known.FilterNew(ctx, []string{"a", "b", "c", "d"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(fresh, []string{"b"}) {
+		t.Errorf("FilterNew после вытеснения = %q, want [b]", fresh)
+	}
+	if len(store.asked) != 1 || !slices.Equal(store.asked[0], []string{"b"}) {
+		t.Errorf("запросы к хранилищу %q, want только вытесненная b", store.asked)
+	}
+
+	if NewKnownURLs(store, 0).capacity != 1 {
+		t.Error("ёмкость кеша меньше 1 должна становиться 1")
+	}
+}
@@ -0,0 +1,98 @@
+// storage/memory.go
+package storage
+
+import (
+	"context"
+	. "parsing_media/utils"
+	"slices"
+	"sync"
+	"time"
+)
+
+// Memory - хранилище в памяти процесса: для тестов и пробных запусков, ничего не сохраняет между запусками
+type Memory struct {
+	mu        sync.Mutex
+	articles  []Data                // последняя редакция каждой статьи в порядке первого сохранения
+	index     map[string]int        // каноническая ссылка -> позиция в articles
+	hashes    map[string]bool       // хеши последних редакций
+	revisions map[string][]Revision // каноническая ссылка -> все редакции
+	checked   map[string]time.Time  // каноническая ссылка -> последняя загрузка или проверка
+	retracted map[string]bool       // канонические ссылки статей, удалённых с сайта
+}
+
+// NewMemory создаёт пустое хранилище в памяти
+func NewMemory() *Memory {
+	return &Memory{
+		index:     make(map[string]int),
+		hashes:    make(map[string]bool),
+		revisions: make(map[string][]Revision),
+		checked:   make(map[string]time.Time),
+		retracted: make(map[string]bool),
+	}
+}
+
+func (s *Memory) Close() error { return nil }
+
+// Save запоминает новые статьи и новые редакции уже известных
+func (s *Memory) Save(ctx context.Context, articles []Data) (SaveResult, error) {
+	var result SaveResult
+	if err := ctx.Err(); err != nil {
+		return result, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, p := range articles {
+		if s.hashes[p.Hash] {
+			result.add(p, savedDuplicate)
+			continue
+		}
+		if p.FetchedAt.IsZero() {
+			p.FetchedAt = time.Now()
+		}
+
+		canonical := CanonicalURL(p.Href)
+		if i, ok := s.index[canonical]; ok {
+			delete(s.hashes, s.articles[i].Hash)
+			s.articles[i] = p
+			result.add(p, savedRevision)
+		} else {
+			s.index[canonical] = len(s.articles)
+			s.articles = append(s.articles, p)
+			result.add(p, savedNew)
+		}
+		s.hashes[p.Hash] = true
+		s.checked[canonical] = p.FetchedAt
+		s.revisions[canonical] = append(s.revisions[canonical], Revision{CanonicalURL: canonical, Data: p})
+	}
+	return result, nil
+}
+
+func (s *Memory) KnownHrefs(_ context.Context, hrefs []string) (map[string]bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return knownByCanonical(hrefs, func(canonical []string) (map[string]bool, error) {
+		stored := make(map[string]bool)
+		for _, c := range canonical {
+			if _, ok := s.index[c]; ok {
+				stored[c] = true
+			}
+		}
+		return stored, nil
+	})
+}
+
+func (s *Memory) Revisions(_ context.Context, href string) ([]Revision, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return slices.Clone(s.revisions[CanonicalURL(href)]), nil
+}
+
+// Articles возвращает копию последних редакций сохранённых статей в порядке первого сохранения
+func (s *Memory) Articles() []Data {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	articles := make([]Data, len(s.articles))
+	copy(articles, s.articles)
+	return articles
+}
@@ -0,0 +1,369 @@
+// storage/migrate.go
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"fmt"
+	"io/fs"
+	. "parsing_media/utils"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+//go:embed migrations
+var migrationFiles embed.FS
+
+// goStepMarker - строка скрипта миграции, на месте которой выполняется шаг на Go из goSteps
+const goStepMarker = "-- +go-step"
+
+// goSteps - шаги миграций, которые нельзя выразить в SQL обоих диалектов, по версии миграции.
+// Шаг выполняется в транзакции миграции между частями скрипта до и после goStepMarker.
+var goSteps = map[int]func(ctx context.Context, tx *sql.Tx, m *Migrator) error{
+	3: backfillCanonicalURL,
+}
+
+// migrationsLockKey - ключ advisory-блокировки Postgres, чтобы два процесса не применяли миграции одновременно
+const migrationsLockKey = 7231604
+
+// Migration - одна версия схемы: SQL для применения и отката
+type Migration struct {
+	Version int
+	Name    string
+	Up      string
+	Down    string
+}
+
+// MigrationStatus - миграция и время её применения (нулевое, если не применена)
+type MigrationStatus struct {
+	Migration
+	AppliedAt time.Time
+}
+
+// Applied сообщает, применена ли миграция
+func (s MigrationStatus) Applied() bool { return !s.AppliedAt.IsZero() }
+
+// Migrator применяет встроенные в бинарник миграции и ведёт их учёт в таблице schema_migrations
+type Migrator struct {
+	db         *sql.DB
+	dialect    string // postgres или sqlite
+	migrations []Migration
+}
+
+// Migratable реализуется SQL-хранилищами, схема которых ведётся миграциями
+type Migratable interface {
+	Migrator() (*Migrator, error)
+}
+
+// newMigrator загружает миграции каталога migrations/<dialect>
+func newMigrator(db *sql.DB, dialect string) (*Migrator, error) {
+	migrations, err := loadMigrations(migrationFiles, path.Join("migrations", dialect))
+	if err != nil {
+		return nil, err
+	}
+	return &Migrator{db: db, dialect: dialect, migrations: migrations}, nil
+}
+
+// loadMigrations читает пары файлов NNNN_имя.up.sql / NNNN_имя.down.sql и сортирует их по версии
+func loadMigrations(fsys fs.FS, dir string) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return nil, fmt.Errorf("чтение миграций %s: %w", dir, err)
+	}
+
+	byVersion := make(map[int]*Migration)
+	for _, entry := range entries {
+		fileName := entry.Name()
+		var direction string
+		switch {
+		case strings.HasSuffix(fileName, ".up.sql"):
+			direction = "up"
+		case strings.HasSuffix(fileName, ".down.sql"):
+			direction = "down"
+		default:
+			continue
+		}
+
+		base := strings.TrimSuffix(fileName, "."+direction+".sql")
+		versionStr, name, found := strings.Cut(base, "_")
+		version, err := strconv.Atoi(versionStr)
+		if !found || err != nil || version <= 0 {
+			return nil, fmt.Errorf("миграция %s: имя должно иметь вид NNNN_описание.%s.sql", fileName, direction)
+		}
+
+		raw, err := fs.ReadFile(fsys, path.Join(dir, fileName))
+		if err != nil {
+			return nil, fmt.Errorf("чтение миграции %s: %w", fileName, err)
+		}
+
+		m, ok := byVersion[version]
+		if !ok {
+			m = &Migration{Version: version, Name: name}
+			byVersion[version] = m
+		} else if m.Name != name {
+			return nil, fmt.Errorf("миграция %d: разные имена '%s' и '%s'", version, m.Name, name)
+		}
+		if direction == "up" {
+			m.Up = string(raw)
+		} else {
+			m.Down = string(raw)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.Up == "" {
+			return nil, fmt.Errorf("миграция %04d_%s: нет файла .up.sql", m.Version, m.Name)
+		}
+		migrations = append(migrations, *m)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	return migrations, nil
+}
+
+// placeholder возвращает параметр запроса с номером n в синтаксисе диалекта
+func (m *Migrator) placeholder(n int) string {
+	if m.dialect == "postgres" {
+		return "$" + strconv.Itoa(n)
+	}
+	return "?"
+}
+
+func (m *Migrator) ensureTable(ctx context.Context) error {
+	_, err := m.db.ExecContext(ctx, `
+    CREATE TABLE IF NOT EXISTS schema_migrations (
+        version    INTEGER PRIMARY KEY,
+        name       TEXT NOT NULL,
+        applied_at TIMESTAMP NOT NULL
+    )`)
+	if err != nil {
+		return fmt.Errorf("создание таблицы schema_migrations: %w", err)
+	}
+	return nil
+}
+
+// appliedVersions возвращает версии, записанные в schema_migrations, со временем применения
+func (m *Migrator) appliedVersions(ctx context.Context) (map[int]time.Time, error) {
+	if err := m.ensureTable(ctx); err != nil {
+		return nil, err
+	}
+	rows, err := m.db.QueryContext(ctx, `SELECT version, applied_at FROM schema_migrations`)
+	if err != nil {
+		return nil, fmt.Errorf("чтение schema_migrations: %w", err)
+	}
+	defer rows.Close()
+
+	applied := make(map[int]time.Time)
+	for rows.Next() {
+		var version int
+		var appliedAt time.Time
+		if err := rows.Scan(&version, &appliedAt); err != nil {
+			return nil, fmt.Errorf("чтение schema_migrations: %w", err)
+		}
+		applied[version] = appliedAt
+	}
+	return applied, rows.Err()
+}
+
+// Status возвращает все известные миграции с отметкой о применении
+func (m *Migrator) Status(ctx context.Context) ([]MigrationStatus, error) {
+	applied, err := m.appliedVersions(ctx)
+	if err != nil {
+		return nil, err
+	}
+	statuses := make([]MigrationStatus, 0, len(m.migrations))
+	for _, migration := range m.migrations {
+		statuses = append(statuses, MigrationStatus{Migration: migration, AppliedAt: applied[migration.Version]})
+	}
+	return statuses, nil
+}
+
+// Pending возвращает ещё не применённые миграции
+func (m *Migrator) Pending(ctx context.Context) ([]Migration, error) {
+	statuses, err := m.Status(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var pending []Migration
+	for _, status := range statuses {
+		if !status.Applied() {
+			pending = append(pending, status.Migration)
+		}
+	}
+	return pending, nil
+}
+
+// Up применяет все неприменённые миграции по возрастанию версии, каждую в своей транзакции
+func (m *Migrator) Up(ctx context.Context) ([]Migration, error) {
+	pending, err := m.Pending(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var done []Migration
+	for _, migration := range pending {
+		applied, err := m.apply(ctx, migration, true)
+		if err != nil {
+			return done, err
+		}
+		if applied {
+			done = append(done, migration)
+		}
+	}
+	return done, nil
+}
+
+// Down откатывает steps последних применённых миграций
+func (m *Migrator) Down(ctx context.Context, steps int) ([]Migration, error) {
+	statuses, err := m.Status(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var done []Migration
+	for i := len(statuses) - 1; i >= 0 && len(done) < steps; i-- {
+		if !statuses[i].Applied() {
+			continue
+		}
+		migration := statuses[i].Migration
+		if migration.Down == "" {
+			return done, fmt.Errorf("миграция %04d_%s: нет файла .down.sql, откат невозможен", migration.Version, migration.Name)
+		}
+		applied, err := m.apply(ctx, migration, false)
+		if err != nil {
+			return done, err
+		}
+		if applied {
+			done = append(done, migration)
+		}
+	}
+	return done, nil
+}
+
+// apply выполняет миграцию в одном направлении вместе с записью в schema_migrations.
+// Состояние перепроверяется под блокировкой: если другой процесс успел сделать то же самое, возвращается false.
+func (m *Migrator) apply(ctx context.Context, migration Migration, up bool) (bool, error) {
+	tx, err := m.db.BeginTx(ctx, nil)
+	if err != nil {
+		return false, fmt.Errorf("начало транзакции миграции %04d: %w", migration.Version, err)
+	}
+	defer tx.Rollback()
+
+	if m.dialect == "postgres" {
+		if _, err := tx.ExecContext(ctx, `SELECT pg_advisory_xact_lock($1)`, migrationsLockKey); err != nil {
+			return false, fmt.Errorf("блокировка миграций: %w", err)
+		}
+	}
+
+	var count int
+	err = tx.QueryRowContext(ctx, `SELECT COUNT(*) FROM schema_migrations WHERE version = `+m.placeholder(1), migration.Version).Scan(&count)
+	if err != nil {
+		return false, fmt.Errorf("проверка миграции %04d: %w", migration.Version, err)
+	}
+	if (count > 0) == up {
+		return false, nil
+	}
+
+	script := migration.Down
+	record := `DELETE FROM schema_migrations WHERE version = ` + m.placeholder(1)
+	args := []any{migration.Version}
+	if up {
+		script = migration.Up
+		record = fmt.Sprintf(`INSERT INTO schema_migrations (version, name, applied_at) VALUES (%s, %s, %s)`, m.placeholder(1), m.placeholder(2), m.placeholder(3))
+		args = []any{migration.Version, migration.Name, time.Now().UTC()}
+	}
+
+	if err := m.run(ctx, tx, migration, script); err != nil {
+		return false, fmt.Errorf("миграция %04d_%s: %w", migration.Version, migration.Name, err)
+	}
+	if _, err := tx.ExecContext(ctx, record, args...); err != nil {
+		return false, fmt.Errorf("запись миграции %04d в schema_migrations: %w", migration.Version, err)
+	}
+	if err := tx.Commit(); err != nil {
+		return false, fmt.Errorf("фиксация миграции %04d: %w", migration.Version, err)
+	}
+	return true, nil
+}
+
+// run выполняет скрипт миграции, вызывая на месте goStepMarker шаг миграции из goSteps
+func (m *Migrator) run(ctx context.Context, tx *sql.Tx, migration Migration, script string) error {
+	for i, part := range strings.Split(script, goStepMarker) {
+		if i > 0 {
+			step := goSteps[migration.Version]
+			if step == nil {
+				return fmt.Errorf("в скрипте есть %s, но шаг на Go для версии %d не задан", goStepMarker, migration.Version)
+			}
+			if err := step(ctx, tx, m); err != nil {
+				return err
+			}
+		}
+		if strings.TrimSpace(part) == "" {
+			continue
+		}
+		if _, err := tx.ExecContext(ctx, part); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// backfillCanonicalURL заполняет canonical_url статей, сохранённых до миграции 0003, тем же CanonicalURL,
+// что и при сохранении: иначе ссылка с utm_* или якорем не совпала бы с канонической при следующей загрузке статьи
+func backfillCanonicalURL(ctx context.Context, tx *sql.Tx, m *Migrator) error {
+	rows, err := tx.QueryContext(ctx, `SELECT DISTINCT href FROM articles`)
+	if err != nil {
+		return fmt.Errorf("чтение ссылок статей: %w", err)
+	}
+	// Ссылки читаются целиком до обновления: в транзакции нельзя выполнять запросы, пока не закрыт результат
+	var hrefs []string
+	for rows.Next() {
+		var href string
+		if err := rows.Scan(&href); err != nil {
+			rows.Close()
+			return fmt.Errorf("чтение ссылок статей: %w", err)
+		}
+		hrefs = append(hrefs, href)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("чтение ссылок статей: %w", err)
+	}
+
+	update, err := tx.PrepareContext(ctx, fmt.Sprintf(`UPDATE articles SET canonical_url = %s WHERE href = %s`, m.placeholder(1), m.placeholder(2)))
+	if err != nil {
+		return fmt.Errorf("подготовка заполнения canonical_url: %w", err)
+	}
+	defer update.Close()
+	for _, href := range hrefs {
+		if _, err := update.ExecContext(ctx, CanonicalURL(href), href); err != nil {
+			return fmt.Errorf("заполнение canonical_url для %s: %w", href, err)
+		}
+	}
+	return nil
+}
+
+// prepareSchema применяет или проверяет миграции при открытии хранилища в соответствии с mode
+func prepareSchema(ctx context.Context, migrator *Migrator, mode string) error {
+	switch mode {
+	case "auto":
+		_, err := migrator.Up(ctx)
+		return err
+	case "verify":
+		pending, err := migrator.Pending(ctx)
+		if err != nil {
+			return err
+		}
+		if len(pending) > 0 {
+			return fmt.Errorf("схема БД устарела: не применено миграций - %d, начиная с %04d_%s (выполните migrate up)", len(pending), pending[0].Version, pending[0].Name)
+		}
+		return nil
+	case "off":
+		return nil
+	default:
+		return fmt.Errorf("неизвестный режим миграций '%s' (auto, verify, off)", mode)
+	}
+}
@@ -0,0 +1,79 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestMigrationBackfillsCanonicalURL(t *testing.T) {
+	ctx := context.Background()
+	db, err := sql.Open("sqlite", filepath.Join(t.TempDir(), "articles.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	db.SetMaxOpenConns(1)
+
+	m, err := newMigrator(db, "sqlite")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := m.ensureTable(ctx); err != nil {
+		t.Fatal(err)
+	}
+	// База в состоянии до миграции 0003: статьи опознавались по href как есть
+	for _, migration := range m.migrations[:2] {
+		if _, err := m.apply(ctx, migration, true); err != nil {
+			t.Fatal(err)
+		}
+	}
+	published := time.Date(2025, time.October, 7, 10, 0, 0, 0, time.UTC)
+	for _, row := range []struct {
+		hash, href string
+		date       time.Time
+	}{
+		{"a", "https://Site.ru/news/1?utm_source=telegram#comments", published},
+		{"b", "https://site.ru/news/1", published.Add(time.Hour)},
+		{"c", "https://site.ru:443/news/2?id=5&fbclid=abc", published},
+	} {
+		_, err := db.ExecContext(ctx, `INSERT INTO articles (hash, site, href, title, body, date) VALUES (?, 'https://site.ru', ?, 'Заголовок', 'Текст', ?)`,
+			row.hash, row.href, row.date)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if _, err := m.Up(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	// Правка с меткой utm_* и поздняя редакция - одна статья: в articles остаётся последняя, обе - в истории
+	want := map[string]string{"b": "https://site.ru/news/1", "c": "https://site.ru/news/2?id=5"}
+	rows, err := db.QueryContext(ctx, `SELECT hash, canonical_url FROM articles`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+	got := make(map[string]string)
+	for rows.Next() {
+		var hash, canonical string
+		if err := rows.Scan(&hash, &canonical); err != nil {
+			t.Fatal(err)
+		}
+		got[hash] = canonical
+	}
+	if len(got) != len(want) || got["b"] != want["b"] || got["c"] != want["c"] {
+		t.Errorf("articles = %v, want %v", got, want)
+	}
+
+	var revisions int
+	if err := db.QueryRowContext(ctx, `SELECT COUNT(*) FROM article_revisions WHERE canonical_url = ?`, want["b"]).Scan(&revisions); err != nil {
+		t.Fatal(err)
+	}
+	if revisions != 2 {
+		t.Errorf("редакций %s: %d, want 2", want["b"], revisions)
+	}
+}
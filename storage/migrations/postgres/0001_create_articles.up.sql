@@ -0,0 +1,10 @@
+-- Таблица статей. IF NOT EXISTS позволяет принять под управление миграций базу, созданную вручную.
+CREATE TABLE IF NOT EXISTS articles (
+    hash  TEXT PRIMARY KEY,
+    site  TEXT NOT NULL,
+    href  TEXT NOT NULL,
+    title TEXT NOT NULL,
+    body  TEXT NOT NULL,
+    date  TIMESTAMPTZ NOT NULL,
+    tags  TEXT[] NOT NULL DEFAULT '{}'
+);
@@ -0,0 +1,4 @@
+-- Строки articles, удалённые при объединении правок, не восстанавливаются
+DROP TABLE IF EXISTS article_revisions;
+DROP INDEX IF EXISTS articles_canonical_url_key;
+ALTER TABLE articles DROP COLUMN IF EXISTS canonical_url;
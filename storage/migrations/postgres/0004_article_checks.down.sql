@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS articles_recrawl_idx;
+ALTER TABLE articles DROP COLUMN IF EXISTS retraction_reason;
+ALTER TABLE articles DROP COLUMN IF EXISTS retracted_at;
+ALTER TABLE articles DROP COLUMN IF EXISTS checked_at;
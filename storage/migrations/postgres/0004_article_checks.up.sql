@@ -0,0 +1,11 @@
+-- Повторный обход статей: время последней проверки и отметка об удалении статьи с сайта
+ALTER TABLE articles ADD COLUMN IF NOT EXISTS checked_at TIMESTAMPTZ;
+ALTER TABLE articles ADD COLUMN IF NOT EXISTS retracted_at TIMESTAMPTZ;
+ALTER TABLE articles ADD COLUMN IF NOT EXISTS retraction_reason TEXT;
+
+-- Последняя проверка уже сохранённых статей - загрузка их последней редакции
+UPDATE articles a
+SET checked_at = (SELECT MAX(r.fetched_at) FROM article_revisions r WHERE r.canonical_url = a.canonical_url)
+WHERE checked_at IS NULL;
+
+CREATE INDEX IF NOT EXISTS articles_recrawl_idx ON articles (date) WHERE retracted_at IS NULL;
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS article_categories;
+DROP TABLE IF EXISTS article_tags;
+DROP TABLE IF EXISTS tags;
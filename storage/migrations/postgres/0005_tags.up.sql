@@ -0,0 +1,21 @@
+-- Нормализованные теги (см. NormalizeTag) и связь статей с ними, а также общие категории статей по таксономии.
+-- Исходные теги сайта остаются в articles.tags. Для статей, сохранённых раньше, связи заполняет команда retag.
+CREATE TABLE IF NOT EXISTS tags (
+    id   SERIAL PRIMARY KEY,
+    name TEXT NOT NULL UNIQUE
+);
+
+CREATE TABLE IF NOT EXISTS article_tags (
+    canonical_url TEXT NOT NULL REFERENCES articles (canonical_url) ON DELETE CASCADE,
+    tag_id        INTEGER NOT NULL REFERENCES tags (id) ON DELETE CASCADE,
+    PRIMARY KEY (canonical_url, tag_id)
+);
+
+CREATE TABLE IF NOT EXISTS article_categories (
+    canonical_url TEXT NOT NULL REFERENCES articles (canonical_url) ON DELETE CASCADE,
+    category      TEXT NOT NULL,
+    PRIMARY KEY (canonical_url, category)
+);
+
+CREATE INDEX IF NOT EXISTS article_tags_tag_idx ON article_tags (tag_id);
+CREATE INDEX IF NOT EXISTS article_categories_category_idx ON article_categories (category);
@@ -0,0 +1,11 @@
+DROP INDEX IF EXISTS articles_rubric_idx;
+ALTER TABLE article_revisions DROP COLUMN IF EXISTS modified_at;
+ALTER TABLE article_revisions DROP COLUMN IF EXISTS image_url;
+ALTER TABLE article_revisions DROP COLUMN IF EXISTS rubric;
+ALTER TABLE article_revisions DROP COLUMN IF EXISTS lead;
+ALTER TABLE article_revisions DROP COLUMN IF EXISTS authors;
+ALTER TABLE articles DROP COLUMN IF EXISTS modified_at;
+ALTER TABLE articles DROP COLUMN IF EXISTS image_url;
+ALTER TABLE articles DROP COLUMN IF EXISTS rubric;
+ALTER TABLE articles DROP COLUMN IF EXISTS lead;
+ALTER TABLE articles DROP COLUMN IF EXISTS authors;
@@ -0,0 +1,14 @@
+-- Необязательные поля статьи: авторы, вводный абзац, рубрика сайта, главная иллюстрация и время изменения по данным сайта
+ALTER TABLE articles ADD COLUMN IF NOT EXISTS authors TEXT[] NOT NULL DEFAULT '{}';
+ALTER TABLE articles ADD COLUMN IF NOT EXISTS lead TEXT NOT NULL DEFAULT '';
+ALTER TABLE articles ADD COLUMN IF NOT EXISTS rubric TEXT NOT NULL DEFAULT '';
+ALTER TABLE articles ADD COLUMN IF NOT EXISTS image_url TEXT NOT NULL DEFAULT '';
+ALTER TABLE articles ADD COLUMN IF NOT EXISTS modified_at TIMESTAMPTZ;
+
+ALTER TABLE article_revisions ADD COLUMN IF NOT EXISTS authors TEXT[] NOT NULL DEFAULT '{}';
+ALTER TABLE article_revisions ADD COLUMN IF NOT EXISTS lead TEXT NOT NULL DEFAULT '';
+ALTER TABLE article_revisions ADD COLUMN IF NOT EXISTS rubric TEXT NOT NULL DEFAULT '';
+ALTER TABLE article_revisions ADD COLUMN IF NOT EXISTS image_url TEXT NOT NULL DEFAULT '';
+ALTER TABLE article_revisions ADD COLUMN IF NOT EXISTS modified_at TIMESTAMPTZ;
+
+CREATE INDEX IF NOT EXISTS articles_rubric_idx ON articles (site, rubric);
@@ -0,0 +1,3 @@
+-- Текст статьи не найден селекторами сайта и извлечён резервным алгоритмом по плотности текста
+ALTER TABLE articles ADD COLUMN IF NOT EXISTS body_fallback BOOLEAN NOT NULL DEFAULT false;
+ALTER TABLE article_revisions ADD COLUMN IF NOT EXISTS body_fallback BOOLEAN NOT NULL DEFAULT false;
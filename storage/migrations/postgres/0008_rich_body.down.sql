@@ -0,0 +1,4 @@
+ALTER TABLE article_revisions DROP COLUMN IF EXISTS rich_format;
+ALTER TABLE article_revisions DROP COLUMN IF EXISTS rich_body;
+ALTER TABLE articles DROP COLUMN IF EXISTS rich_format;
+ALTER TABLE articles DROP COLUMN IF EXISTS rich_body;
@@ -0,0 +1,10 @@
+-- Те же колонки, что и в Postgres; теги хранятся JSON-массивом
+CREATE TABLE IF NOT EXISTS articles (
+    hash  TEXT PRIMARY KEY,
+    site  TEXT NOT NULL,
+    href  TEXT NOT NULL,
+    title TEXT NOT NULL,
+    body  TEXT NOT NULL,
+    date  TIMESTAMP NOT NULL,
+    tags  TEXT NOT NULL DEFAULT '[]'
+);
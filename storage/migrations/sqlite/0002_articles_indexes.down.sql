@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS articles_site_date_idx;
+DROP INDEX IF EXISTS articles_href_idx;
@@ -0,0 +1,2 @@
+CREATE INDEX IF NOT EXISTS articles_href_idx ON articles (href);
+CREATE INDEX IF NOT EXISTS articles_site_date_idx ON articles (site, date DESC);
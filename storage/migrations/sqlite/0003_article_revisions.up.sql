@@ -0,0 +1,35 @@
+-- Статья опознаётся по канонической ссылке: в articles хранится её последняя редакция,
+-- а каждое новое содержимое (новый хеш) добавляется в article_revisions со временем загрузки.
+ALTER TABLE articles ADD COLUMN canonical_url TEXT NOT NULL DEFAULT '';
+-- Каноническая ссылка вычисляется на Go функцией CanonicalURL (storage/migrate.go, backfillCanonicalURL)
+-- +go-step
+
+CREATE TABLE IF NOT EXISTS article_revisions (
+    id            INTEGER PRIMARY KEY AUTOINCREMENT,
+    canonical_url TEXT NOT NULL,
+    hash          TEXT NOT NULL,
+    site          TEXT NOT NULL,
+    href          TEXT NOT NULL,
+    title         TEXT NOT NULL,
+    body          TEXT NOT NULL,
+    date          TIMESTAMP NOT NULL,
+    tags          TEXT NOT NULL DEFAULT '[]',
+    fetched_at    TIMESTAMP NOT NULL
+);
+
+-- Уже сохранённые статьи становятся первыми редакциями. Время загрузки неизвестно, вместо него - дата публикации.
+INSERT INTO article_revisions (canonical_url, hash, site, href, title, body, date, tags, fetched_at)
+SELECT canonical_url, hash, site, href, title, body, date, tags, date
+FROM articles
+ORDER BY date, hash;
+
+-- Правки, сохранённые раньше отдельными строками, остаются только в истории
+DELETE FROM articles
+WHERE EXISTS (
+    SELECT 1 FROM articles b
+    WHERE b.canonical_url = articles.canonical_url
+      AND (b.date > articles.date OR (b.date = articles.date AND b.hash > articles.hash))
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS articles_canonical_url_key ON articles (canonical_url);
+CREATE INDEX IF NOT EXISTS article_revisions_url_idx ON article_revisions (canonical_url, fetched_at);
@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS articles_recrawl_idx;
+ALTER TABLE articles DROP COLUMN retraction_reason;
+ALTER TABLE articles DROP COLUMN retracted_at;
+ALTER TABLE articles DROP COLUMN checked_at;
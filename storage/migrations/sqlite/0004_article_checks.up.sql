@@ -0,0 +1,11 @@
+-- Повторный обход статей: время последней проверки и отметка об удалении статьи с сайта
+ALTER TABLE articles ADD COLUMN checked_at TIMESTAMP;
+ALTER TABLE articles ADD COLUMN retracted_at TIMESTAMP;
+ALTER TABLE articles ADD COLUMN retraction_reason TEXT;
+
+-- Последняя проверка уже сохранённых статей - загрузка их последней редакции
+UPDATE articles
+SET checked_at = (SELECT MAX(r.fetched_at) FROM article_revisions r WHERE r.canonical_url = articles.canonical_url)
+WHERE checked_at IS NULL;
+
+CREATE INDEX IF NOT EXISTS articles_recrawl_idx ON articles (date) WHERE retracted_at IS NULL;
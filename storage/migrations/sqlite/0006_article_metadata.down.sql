@@ -0,0 +1,11 @@
+DROP INDEX IF EXISTS articles_rubric_idx;
+ALTER TABLE article_revisions DROP COLUMN modified_at;
+ALTER TABLE article_revisions DROP COLUMN image_url;
+ALTER TABLE article_revisions DROP COLUMN rubric;
+ALTER TABLE article_revisions DROP COLUMN lead;
+ALTER TABLE article_revisions DROP COLUMN authors;
+ALTER TABLE articles DROP COLUMN modified_at;
+ALTER TABLE articles DROP COLUMN image_url;
+ALTER TABLE articles DROP COLUMN rubric;
+ALTER TABLE articles DROP COLUMN lead;
+ALTER TABLE articles DROP COLUMN authors;
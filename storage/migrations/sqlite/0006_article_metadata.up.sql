@@ -0,0 +1,14 @@
+-- Необязательные поля статьи: авторы, вводный абзац, рубрика сайта, главная иллюстрация и время изменения по данным сайта
+ALTER TABLE articles ADD COLUMN authors TEXT NOT NULL DEFAULT '[]';
+ALTER TABLE articles ADD COLUMN lead TEXT NOT NULL DEFAULT '';
+ALTER TABLE articles ADD COLUMN rubric TEXT NOT NULL DEFAULT '';
+ALTER TABLE articles ADD COLUMN image_url TEXT NOT NULL DEFAULT '';
+ALTER TABLE articles ADD COLUMN modified_at TIMESTAMP;
+
+ALTER TABLE article_revisions ADD COLUMN authors TEXT NOT NULL DEFAULT '[]';
+ALTER TABLE article_revisions ADD COLUMN lead TEXT NOT NULL DEFAULT '';
+ALTER TABLE article_revisions ADD COLUMN rubric TEXT NOT NULL DEFAULT '';
+ALTER TABLE article_revisions ADD COLUMN image_url TEXT NOT NULL DEFAULT '';
+ALTER TABLE article_revisions ADD COLUMN modified_at TIMESTAMP;
+
+CREATE INDEX IF NOT EXISTS articles_rubric_idx ON articles (site, rubric);
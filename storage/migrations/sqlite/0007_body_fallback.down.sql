@@ -0,0 +1,2 @@
+ALTER TABLE article_revisions DROP COLUMN body_fallback;
+ALTER TABLE articles DROP COLUMN body_fallback;
@@ -0,0 +1,3 @@
+-- Текст статьи не найден селекторами сайта и извлечён резервным алгоритмом по плотности текста
+ALTER TABLE articles ADD COLUMN body_fallback INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE article_revisions ADD COLUMN body_fallback INTEGER NOT NULL DEFAULT 0;
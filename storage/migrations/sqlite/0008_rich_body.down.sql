@@ -0,0 +1,4 @@
+ALTER TABLE article_revisions DROP COLUMN rich_format;
+ALTER TABLE article_revisions DROP COLUMN rich_body;
+ALTER TABLE articles DROP COLUMN rich_format;
+ALTER TABLE articles DROP COLUMN rich_body;
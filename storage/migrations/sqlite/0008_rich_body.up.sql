@@ -0,0 +1,6 @@
+-- Исходный текст статьи с разметкой (Markdown или санированный HTML) при parsers.keep_rich_body
+ALTER TABLE articles ADD COLUMN rich_body TEXT NOT NULL DEFAULT '';
+ALTER TABLE articles ADD COLUMN rich_format TEXT NOT NULL DEFAULT '';
+
+ALTER TABLE article_revisions ADD COLUMN rich_body TEXT NOT NULL DEFAULT '';
+ALTER TABLE article_revisions ADD COLUMN rich_format TEXT NOT NULL DEFAULT '';
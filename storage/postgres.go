@@ -0,0 +1,260 @@
+// storage/postgres.go
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	. "parsing_media/utils"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// Postgres - основное хранилище: таблица articles в PostgreSQL
+type Postgres struct {
+	db            *sql.DB
+	bulkThreshold int
+	taxonomy      *Taxonomy
+}
+
+// OpenPostgres подключается к базе данных, проверяет соединение и готовит схему миграциями (migrateMode: auto, verify, off).
+// Категории сохраняемых статей назначаются по taxonomy.
+func OpenPostgres(ctx context.Context, cfg DatabaseConfig, migrateMode string, taxonomy *Taxonomy) (*Postgres, error) {
+	db, err := sql.Open("postgres", cfg.ConnectionString())
+	if err != nil {
+		return nil, fmt.Errorf("ошибка открытия БД: %w", err)
+	}
+
+	if err = db.PingContext(ctx); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("ошибка проверки соединения с БД: %w", err)
+	}
+
+	db.SetMaxOpenConns(cfg.MaxOpenConns)
+	db.SetMaxIdleConns(cfg.MaxIdleConns)
+	db.SetConnMaxLifetime(cfg.ConnMaxLifetime)
+
+	s := &Postgres{db: db, bulkThreshold: cfg.BulkThreshold, taxonomy: taxonomy}
+	migrator, err := s.Migrator()
+	if err == nil {
+		err = prepareSchema(ctx, migrator, migrateMode)
+	}
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	return s, nil
+}
+
+// DB возвращает пул соединений
+func (s *Postgres) DB() *sql.DB { return s.db }
+
+// Migrator возвращает мигратор схемы Postgres
+func (s *Postgres) Migrator() (*Migrator, error) { return newMigrator(s.db, "postgres") }
+
+func (s *Postgres) Close() error { return s.db.Close() }
+
+// Save сохраняет статьи с учётом редакций (см. articleWriter.save). Пачки от bulkThreshold статей
+// загружаются через COPY (saveBulk), а если это не удалось - повторяются построчно,
+// чтобы отделить проблемные статьи от остальных.
+func (s *Postgres) Save(ctx context.Context, products []Data) (SaveResult, error) {
+	if s.bulkThreshold > 0 && len(products) >= s.bulkThreshold {
+		result, err := s.saveBulk(ctx, products)
+		if err == nil || ctx.Err() != nil {
+			return result, err
+		}
+		if !errors.Is(err, errBulkAmbiguous) {
+			fmt.Printf("%s[DB][WARN] Пакетная вставка не удалась: %v. Повтор построчно.%s\n", ColorYellow, err, ColorReset)
+		}
+	}
+	return saveRows(ctx, s.db, "postgres", s.taxonomy, products)
+}
+
+// errBulkAmbiguous - в пачке несколько разных редакций одной статьи; их порядок сохраняет только построчная запись
+var errBulkAmbiguous = errors.New("несколько редакций одной статьи в пачке")
+
+// saveBulk загружает статьи командой COPY во временную таблицу и переносит их в articles и article_revisions
+// несколькими запросами с той же логикой, что и построчная запись. Ошибка любой строки отменяет всю пачку.
+func (s *Postgres) saveBulk(ctx context.Context, products []Data) (SaveResult, error) {
+	var result SaveResult
+
+	// Повторы хеша внутри пачки отсеиваются заранее: как и при построчной записи, сохраняется первая статья
+	batch := make([]Data, 0, len(products))
+	seenHashes := make(map[string]bool, len(products))
+	seenURLs := make(map[string]bool, len(products))
+	for _, p := range products {
+		if seenHashes[p.Hash] {
+			result.add(p, savedDuplicate)
+			continue
+		}
+		canonical := CanonicalURL(p.Href)
+		if seenURLs[canonical] {
+			return SaveResult{}, errBulkAmbiguous
+		}
+		seenHashes[p.Hash] = true
+		seenURLs[canonical] = true
+		batch = append(batch, p)
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return SaveResult{}, fmt.Errorf("начало транзакции: %w", err)
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx, `
+    CREATE TEMP TABLE articles_staging (
+        hash TEXT, site TEXT, href TEXT, canonical_url TEXT, title TEXT, body TEXT,
+        date TIMESTAMPTZ, tags TEXT[], authors TEXT[], lead TEXT, rubric TEXT, image_url TEXT, modified_at TIMESTAMPTZ,
+        body_fallback BOOLEAN, rich_body TEXT, rich_format TEXT, fetched_at TIMESTAMPTZ, norm_tags TEXT[], categories TEXT[], changed BOOLEAN
+    ) ON COMMIT DROP`)
+	if err != nil {
+		return SaveResult{}, fmt.Errorf("создание временной таблицы: %w", err)
+	}
+
+	stmt, err := tx.PrepareContext(ctx, pq.CopyIn("articles_staging", "hash", "site", "href", "canonical_url", "title", "body", "date", "tags",
+		"authors", "lead", "rubric", "image_url", "modified_at", "body_fallback", "rich_body", "rich_format", "fetched_at", "norm_tags", "categories"))
+	if err != nil {
+		return SaveResult{}, fmt.Errorf("подготовка COPY: %w", err)
+	}
+	for _, p := range batch {
+		fetchedAt := p.FetchedAt
+		if fetchedAt.IsZero() {
+			fetchedAt = time.Now()
+		}
+		enc, err := encodeArticle("postgres", p)
+		if err != nil {
+			stmt.Close()
+			return SaveResult{}, err
+		}
+		_, err = stmt.ExecContext(ctx, p.Hash, p.Site, p.Href, CanonicalURL(p.Href), p.Title, p.Body, enc.date, enc.tags,
+			enc.authors, p.Lead, p.Rubric, p.Image, enc.modified, p.BodyFallback, p.RichBody, p.RichFormat, fetchedAt,
+			pq.Array(nonNilTags(NormalizeTags(p.Tags))), pq.Array(nonNilTags(s.taxonomy.Categorize(p.Site, p.Tags))))
+		if err != nil {
+			stmt.Close()
+			return SaveResult{}, fmt.Errorf("COPY %s: %w", p.Href, err)
+		}
+	}
+	if _, err := stmt.ExecContext(ctx); err != nil {
+		stmt.Close()
+		return SaveResult{}, fmt.Errorf("завершение COPY: %w", err)
+	}
+	if err := stmt.Close(); err != nil {
+		return SaveResult{}, fmt.Errorf("завершение COPY: %w", err)
+	}
+
+	// Итог по каждой статье определяется до изменений: хеш уже сохранён - дубликат,
+	// ссылка уже есть с другим хешем - новая редакция, иначе - новая статья
+	rows, err := tx.QueryContext(ctx, `
+    SELECT s.hash,
+           EXISTS (SELECT 1 FROM articles h WHERE h.hash = s.hash),
+           EXISTS (SELECT 1 FROM articles a WHERE a.canonical_url = s.canonical_url)
+    FROM articles_staging s`)
+	if err != nil {
+		return SaveResult{}, fmt.Errorf("сверка с сохранёнными статьями: %w", err)
+	}
+	outcomes := make(map[string]saveOutcome, len(batch))
+	for rows.Next() {
+		var hash string
+		var hashExists, urlExists bool
+		if err := rows.Scan(&hash, &hashExists, &urlExists); err != nil {
+			rows.Close()
+			return SaveResult{}, fmt.Errorf("сверка с сохранёнными статьями: %w", err)
+		}
+		switch {
+		case hashExists:
+			outcomes[hash] = savedDuplicate
+		case urlExists:
+			outcomes[hash] = savedRevision
+		default:
+			outcomes[hash] = savedNew
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return SaveResult{}, fmt.Errorf("сверка с сохранёнными статьями: %w", err)
+	}
+
+	// Связи с тегами и категориями заменяются у новых статей и новых редакций (changed), как и при построчной записи
+	for _, query := range []string{`
+    UPDATE articles_staging s SET changed = NOT EXISTS (SELECT 1 FROM articles h WHERE h.hash = s.hash)`, `
+    INSERT INTO article_revisions (canonical_url, hash, site, href, title, body, date, tags,
+                                   authors, lead, rubric, image_url, modified_at, body_fallback, rich_body, rich_format, fetched_at)
+    SELECT canonical_url, hash, site, href, title, body, date, tags,
+           authors, lead, rubric, image_url, modified_at, body_fallback, rich_body, rich_format, fetched_at FROM articles_staging s
+    WHERE NOT EXISTS (SELECT 1 FROM articles h WHERE h.hash = s.hash)`, `
+    UPDATE articles a
+    SET hash = s.hash, site = s.site, href = s.href, title = s.title, body = s.body, date = s.date, tags = s.tags,
+        authors = s.authors, lead = s.lead, rubric = s.rubric, image_url = s.image_url, modified_at = s.modified_at,
+        body_fallback = s.body_fallback, rich_body = s.rich_body, rich_format = s.rich_format, checked_at = s.fetched_at
+    FROM articles_staging s
+    WHERE a.canonical_url = s.canonical_url AND NOT EXISTS (SELECT 1 FROM articles h WHERE h.hash = s.hash)`, `
+    INSERT INTO articles (hash, site, href, canonical_url, title, body, date, tags,
+                          authors, lead, rubric, image_url, modified_at, body_fallback, rich_body, rich_format, checked_at)
+    SELECT hash, site, href, canonical_url, title, body, date, tags,
+           authors, lead, rubric, image_url, modified_at, body_fallback, rich_body, rich_format, fetched_at FROM articles_staging s
+    WHERE NOT EXISTS (SELECT 1 FROM articles a WHERE a.canonical_url = s.canonical_url OR a.hash = s.hash)`, `
+    INSERT INTO tags (name)
+    SELECT DISTINCT t.name FROM articles_staging s CROSS JOIN LATERAL unnest(s.norm_tags) AS t(name)
+    WHERE s.changed
+    ON CONFLICT (name) DO NOTHING`, `
+    DELETE FROM article_tags l USING articles_staging s WHERE l.canonical_url = s.canonical_url AND s.changed`, `
+    INSERT INTO article_tags (canonical_url, tag_id)
+    SELECT DISTINCT s.canonical_url, tags.id
+    FROM articles_staging s CROSS JOIN LATERAL unnest(s.norm_tags) AS t(name) JOIN tags ON tags.name = t.name
+    WHERE s.changed`, `
+    DELETE FROM article_categories c USING articles_staging s WHERE c.canonical_url = s.canonical_url AND s.changed`, `
+    INSERT INTO article_categories (canonical_url, category)
+    SELECT DISTINCT s.canonical_url, c.category FROM articles_staging s CROSS JOIN LATERAL unnest(s.categories) AS c(category)
+    WHERE s.changed`,
+	} {
+		if _, err := tx.ExecContext(ctx, query); err != nil {
+			return SaveResult{}, fmt.Errorf("перенос из временной таблицы: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return SaveResult{}, fmt.Errorf("фиксация транзакции: %w", err)
+	}
+
+	for _, p := range batch {
+		result.add(p, outcomes[p.Hash])
+	}
+	return result, nil
+}
+
+// KnownHrefs выбирает из hrefs ссылки, статьи по которым уже есть в таблице articles (сравниваются канонические ссылки)
+func (s *Postgres) KnownHrefs(ctx context.Context, hrefs []string) (map[string]bool, error) {
+	return knownByCanonical(hrefs, func(canonical []string) (map[string]bool, error) {
+		rows, err := s.db.QueryContext(ctx, `SELECT canonical_url FROM articles WHERE canonical_url = ANY($1)`, pq.Array(canonical))
+		if err != nil {
+			return nil, fmt.Errorf("проверка сохранённых ссылок: %w", err)
+		}
+		return scanHrefs(rows)
+	})
+}
+
+// Revisions возвращает редакции статьи от старой к новой
+func (s *Postgres) Revisions(ctx context.Context, href string) ([]Revision, error) {
+	return loadRevisions(ctx, s.db, "postgres", href)
+}
+
+// scanHrefs читает результат запроса из одной колонки href
+func scanHrefs(rows *sql.Rows) (map[string]bool, error) {
+	defer rows.Close()
+
+	stored := make(map[string]bool)
+	for rows.Next() {
+		var href string
+		if err := rows.Scan(&href); err != nil {
+			return nil, fmt.Errorf("чтение сохранённых ссылок: %w", err)
+		}
+		stored[href] = true
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("чтение сохранённых ссылок: %w", err)
+	}
+	return stored, nil
+}
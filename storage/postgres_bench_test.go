@@ -0,0 +1,109 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"os"
+	. "parsing_media/utils"
+	"strings"
+	"testing"
+	"time"
+)
+
+// benchBatchSize - размер пачки, на которой сравниваются COPY и построчная запись
+const benchBatchSize = 500
+
+// benchSite - сайт статей бенчмарка, по нему они удаляются после прогона
+const benchSite = "https://bench.parsing-media.invalid"
+
+// openBenchPostgres подключается к базе из PARSING_MEDIA_BENCH_DSN. Бенчмарк пишет в articles,
+// поэтому отдельная переменная не даёт случайно запустить его на рабочей базе из PARSING_MEDIA_DSN.
+func openBenchPostgres(b *testing.B) *Postgres {
+	b.Helper()
+	dsn := os.Getenv("PARSING_MEDIA_BENCH_DSN")
+	if dsn == "" {
+		b.Skip("PARSING_MEDIA_BENCH_DSN не задан")
+	}
+
+	cfg := DefaultConfig().Database
+	cfg.DSN = dsn
+	cfg.BulkThreshold = 0
+	ctx := context.Background()
+	s, err := OpenPostgres(ctx, cfg, "auto", nil)
+	if err != nil {
+		b.Fatalf("подключение к Postgres: %v", err)
+	}
+	b.Cleanup(func() {
+		s.db.ExecContext(ctx, "DELETE FROM article_revisions WHERE site = $1", benchSite)
+		s.db.ExecContext(ctx, "DELETE FROM articles WHERE site = $1", benchSite)
+		s.Close()
+	})
+	return s
+}
+
+// benchBatch возвращает пачку новых статей: адреса и хеши уникальны для каждого прогона run,
+// чтобы обе реализации каждый раз вставляли строки, а не находили повторы
+func benchBatch(b *testing.B, prefix string, run int) []Data {
+	b.Helper()
+	published := time.Date(2025, time.October, 1, 12, 0, 0, 0, time.UTC)
+	body := strings.Repeat("Текст статьи для проверки скорости сохранения. ", 40)
+	batch := make([]Data, 0, benchBatchSize)
+	for i := range benchBatchSize {
+		item := Data{
+			Site:      benchSite,
+			Href:      fmt.Sprintf("%s/%s/%d/%d", benchSite, prefix, run, i),
+			Title:     fmt.Sprintf("Статья %s %d-%d", prefix, run, i),
+			Body:      body,
+			Date:      published.Add(time.Duration(i) * time.Minute),
+			Tags:      []string{"Политика", "Экономика"},
+			FetchedAt: published,
+			Authors:   []string{"Иван Иванов"},
+			Lead:      "Вводный абзац",
+		}
+		hash, err := item.Hashing()
+		if err != nil {
+			b.Fatal(err)
+		}
+		item.Hash = hash
+		batch = append(batch, item)
+	}
+	return batch
+}
+
+func BenchmarkSaveBulk(b *testing.B) {
+	s := openBenchPostgres(b)
+	prefix := fmt.Sprintf("bulk-%d", time.Now().UnixNano())
+	ctx := context.Background()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		b.StopTimer()
+		batch := benchBatch(b, prefix, i)
+		b.StartTimer()
+		result, err := s.saveBulk(ctx, batch)
+		if err != nil {
+			b.Fatalf("saveBulk: %v", err)
+		}
+		if len(result.Inserted) != len(batch) {
+			b.Fatalf("saveBulk: вставлено %d из %d", len(result.Inserted), len(batch))
+		}
+	}
+}
+
+func BenchmarkSaveRows(b *testing.B) {
+	s := openBenchPostgres(b)
+	prefix := fmt.Sprintf("rows-%d", time.Now().UnixNano())
+	ctx := context.Background()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		b.StopTimer()
+		batch := benchBatch(b, prefix, i)
+		b.StartTimer()
+		result, err := saveRows(ctx, s.db, "postgres", s.taxonomy, batch)
+		if err != nil {
+			b.Fatalf("saveRows: %v", err)
+		}
+		if len(result.Inserted) != len(batch) {
+			b.Fatalf("saveRows: вставлено %d из %d", len(result.Inserted), len(batch))
+		}
+	}
+}
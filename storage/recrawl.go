@@ -0,0 +1,149 @@
+// storage/recrawl.go
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	. "parsing_media/utils"
+	"time"
+)
+
+// RecrawlCandidate - сохранённая статья, которую может понадобиться проверить повторно
+type RecrawlCandidate struct {
+	Site      string
+	Href      string
+	Tags      []string
+	Date      time.Time
+	CheckedAt time.Time // последняя загрузка или проверка (нулевое - неизвестно)
+}
+
+// Due сообщает, наступил ли после последней проверки очередной срок из offsets (отсчитываются от публикации)
+func (c RecrawlCandidate) Due(offsets []time.Duration, now time.Time) bool {
+	last := c.CheckedAt
+	if last.IsZero() {
+		last = c.Date
+	}
+	for _, offset := range offsets {
+		at := c.Date.Add(offset)
+		if at.After(last) && !at.After(now) {
+			return true
+		}
+	}
+	return false
+}
+
+// Rechecker реализуется хранилищами, которые поддерживают повторный обход статей
+type Rechecker interface {
+	// RecentArticles возвращает не удалённые статьи, опубликованные не раньше since
+	RecentArticles(ctx context.Context, since time.Time) ([]RecrawlCandidate, error)
+	// MarkChecked запоминает время проверки статьи, содержимое которой не изменилось
+	MarkChecked(ctx context.Context, href string, at time.Time) error
+	// MarkRetracted отмечает статью как удалённую с сайта; такие статьи больше не проверяются
+	MarkRetracted(ctx context.Context, href string, at time.Time, reason string) error
+}
+
+func recentArticles(ctx context.Context, db *sql.DB, dialect string, since time.Time) ([]RecrawlCandidate, error) {
+	rows, err := db.QueryContext(ctx, rebind(dialect, `
+    SELECT site, href, tags, date, checked_at FROM articles
+    WHERE retracted_at IS NULL AND date >= $1
+    ORDER BY date`), encodeTime(dialect, since))
+	if err != nil {
+		return nil, fmt.Errorf("выбор статей для повторного обхода: %w", err)
+	}
+	defer rows.Close()
+
+	var candidates []RecrawlCandidate
+	for rows.Next() {
+		var c RecrawlCandidate
+		var checkedAt sql.NullTime
+		tags, decodeTags := tagsDest(dialect, &c.Tags)
+		if err := rows.Scan(&c.Site, &c.Href, tags, &c.Date, &checkedAt); err != nil {
+			return nil, fmt.Errorf("выбор статей для повторного обхода: %w", err)
+		}
+		if err := decodeTags(); err != nil {
+			return nil, fmt.Errorf("теги статьи %s: %w", c.Href, err)
+		}
+		c.CheckedAt = checkedAt.Time
+		candidates = append(candidates, c)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("выбор статей для повторного обхода: %w", err)
+	}
+	return candidates, nil
+}
+
+func markChecked(ctx context.Context, db *sql.DB, dialect, href string, at time.Time) error {
+	_, err := db.ExecContext(ctx, rebind(dialect, `UPDATE articles SET checked_at = $1 WHERE canonical_url = $2`),
+		encodeTime(dialect, at), CanonicalURL(href))
+	if err != nil {
+		return fmt.Errorf("отметка о проверке %s: %w", href, err)
+	}
+	return nil
+}
+
+func markRetracted(ctx context.Context, db *sql.DB, dialect, href string, at time.Time, reason string) error {
+	_, err := db.ExecContext(ctx, rebind(dialect, `
+    UPDATE articles SET retracted_at = $1, retraction_reason = $2, checked_at = $3
+    WHERE canonical_url = $4`),
+		encodeTime(dialect, at), reason, encodeTime(dialect, at), CanonicalURL(href))
+	if err != nil {
+		return fmt.Errorf("отметка об удалении %s: %w", href, err)
+	}
+	return nil
+}
+
+func (s *Postgres) RecentArticles(ctx context.Context, since time.Time) ([]RecrawlCandidate, error) {
+	return recentArticles(ctx, s.db, "postgres", since)
+}
+
+func (s *Postgres) MarkChecked(ctx context.Context, href string, at time.Time) error {
+	return markChecked(ctx, s.db, "postgres", href, at)
+}
+
+func (s *Postgres) MarkRetracted(ctx context.Context, href string, at time.Time, reason string) error {
+	return markRetracted(ctx, s.db, "postgres", href, at, reason)
+}
+
+func (s *SQLite) RecentArticles(ctx context.Context, since time.Time) ([]RecrawlCandidate, error) {
+	return recentArticles(ctx, s.db, "sqlite", since)
+}
+
+func (s *SQLite) MarkChecked(ctx context.Context, href string, at time.Time) error {
+	return markChecked(ctx, s.db, "sqlite", href, at)
+}
+
+func (s *SQLite) MarkRetracted(ctx context.Context, href string, at time.Time, reason string) error {
+	return markRetracted(ctx, s.db, "sqlite", href, at, reason)
+}
+
+func (s *Memory) RecentArticles(_ context.Context, since time.Time) ([]RecrawlCandidate, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var candidates []RecrawlCandidate
+	for _, p := range s.articles {
+		canonical := CanonicalURL(p.Href)
+		if s.retracted[canonical] || p.Date.Before(since) {
+			continue
+		}
+		candidates = append(candidates, RecrawlCandidate{Site: p.Site, Href: p.Href, Tags: p.Tags, Date: p.Date, CheckedAt: s.checked[canonical]})
+	}
+	return candidates, nil
+}
+
+func (s *Memory) MarkChecked(_ context.Context, href string, at time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.checked[CanonicalURL(href)] = at
+	return nil
+}
+
+func (s *Memory) MarkRetracted(_ context.Context, href string, at time.Time, _ string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	canonical := CanonicalURL(href)
+	s.checked[canonical] = at
+	s.retracted[canonical] = true
+	return nil
+}
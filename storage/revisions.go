@@ -0,0 +1,190 @@
+// storage/revisions.go
+package storage
+
+import (
+	"fmt"
+	. "parsing_media/utils"
+	"slices"
+	"strings"
+	"time"
+)
+
+// diffContext - сколько неизменённых абзацев показывать вокруг изменений
+const diffContext = 2
+
+// diffMaxCells - предел размера таблицы LCS; для более длинных текстов показывается замена целиком
+const diffMaxCells = 4_000_000
+
+// Revision - одна редакция статьи: содержимое, загруженное в Data.FetchedAt
+type Revision struct {
+	CanonicalURL string
+	Data
+}
+
+// DiffLine - абзац текста в сравнении редакций
+type DiffLine struct {
+	Op   byte // ' ' - без изменений, '-' - удалён, '+' - добавлен
+	Text string
+}
+
+// RevisionDiff - отличия между двумя редакциями статьи
+type RevisionDiff struct {
+	From, To     Revision
+	TitleChanged bool
+	DateChanged  bool
+	TagsAdded    []string
+	TagsRemoved  []string
+	Body         []DiffLine // пусто, если текст не изменился
+}
+
+// DiffRevisions сравнивает редакции from и to: заголовок, дату, теги и текст по абзацам
+func DiffRevisions(from, to Revision) RevisionDiff {
+	diff := RevisionDiff{
+		From:         from,
+		To:           to,
+		TitleChanged: from.Title != to.Title,
+		DateChanged:  !from.Date.Equal(to.Date),
+	}
+	for _, tag := range to.Tags {
+		if !slices.Contains(from.Tags, tag) {
+			diff.TagsAdded = append(diff.TagsAdded, tag)
+		}
+	}
+	for _, tag := range from.Tags {
+		if !slices.Contains(to.Tags, tag) {
+			diff.TagsRemoved = append(diff.TagsRemoved, tag)
+		}
+	}
+	if from.Body != to.Body {
+		diff.Body = diffLines(paragraphs(from.Body), paragraphs(to.Body))
+	}
+	return diff
+}
+
+// Changed сообщает, есть ли между редакциями отличия
+func (d RevisionDiff) Changed() bool {
+	return d.TitleChanged || d.DateChanged || len(d.TagsAdded) > 0 || len(d.TagsRemoved) > 0 || len(d.Body) > 0
+}
+
+// String выводит отличия в виде, похожем на unified diff: изменённые абзацы с diffContext абзацами вокруг
+func (d RevisionDiff) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "--- %s  %s\n", d.From.ShortHash(), d.From.FetchedAt.Local().Format("2006-01-02 15:04:05"))
+	fmt.Fprintf(&b, "+++ %s  %s\n", d.To.ShortHash(), d.To.FetchedAt.Local().Format("2006-01-02 15:04:05"))
+	if !d.Changed() {
+		b.WriteString("Редакции совпадают\n")
+		return b.String()
+	}
+
+	if d.TitleChanged {
+		fmt.Fprintf(&b, "-Заголовок: %s\n+Заголовок: %s\n", d.From.Title, d.To.Title)
+	}
+	if d.DateChanged {
+		fmt.Fprintf(&b, "-Дата: %s\n+Дата: %s\n", d.From.Date.Format(time.RFC3339), d.To.Date.Format(time.RFC3339))
+	}
+	if len(d.TagsRemoved) > 0 {
+		fmt.Fprintf(&b, "-Теги: %s\n", strings.Join(d.TagsRemoved, ", "))
+	}
+	if len(d.TagsAdded) > 0 {
+		fmt.Fprintf(&b, "+Теги: %s\n", strings.Join(d.TagsAdded, ", "))
+	}
+
+	lastPrinted := -1
+	paragraph := 0 // номер абзаца в новой редакции
+	for i, line := range d.Body {
+		if line.Op != '-' {
+			paragraph++
+		}
+		if line.Op == ' ' && !nearChange(d.Body, i) {
+			continue
+		}
+		if lastPrinted < 0 || i != lastPrinted+1 {
+			fmt.Fprintf(&b, "@@ абзац %d @@\n", max(paragraph, 1))
+		}
+		fmt.Fprintf(&b, "%c%s\n", line.Op, line.Text)
+		lastPrinted = i
+	}
+	return b.String()
+}
+
+// nearChange сообщает, есть ли изменённый абзац не дальше diffContext от i
+func nearChange(lines []DiffLine, i int) bool {
+	for j := max(0, i-diffContext); j <= min(len(lines)-1, i+diffContext); j++ {
+		if lines[j].Op != ' ' {
+			return true
+		}
+	}
+	return false
+}
+
+// paragraphs разбивает текст статьи на непустые абзацы
+func paragraphs(body string) []string {
+	var result []string
+	for _, line := range strings.Split(body, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			result = append(result, line)
+		}
+	}
+	return result
+}
+
+// diffLines строит построчное сравнение по наибольшей общей подпоследовательности
+func diffLines(from, to []string) []DiffLine {
+	if len(from)*len(to) > diffMaxCells {
+		lines := make([]DiffLine, 0, len(from)+len(to))
+		for _, text := range from {
+			lines = append(lines, DiffLine{Op: '-', Text: text})
+		}
+		for _, text := range to {
+			lines = append(lines, DiffLine{Op: '+', Text: text})
+		}
+		return lines
+	}
+
+	// lcs[i][j] - длина общей подпоследовательности from[i:] и to[j:]
+	lcs := make([][]int, len(from)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(to)+1)
+	}
+	for i := len(from) - 1; i >= 0; i-- {
+		for j := len(to) - 1; j >= 0; j-- {
+			if from[i] == to[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	lines := make([]DiffLine, 0, len(from)+len(to))
+	i, j := 0, 0
+	for i < len(from) && j < len(to) {
+		switch {
+		case from[i] == to[j]:
+			lines = append(lines, DiffLine{Op: ' ', Text: from[i]})
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			lines = append(lines, DiffLine{Op: '-', Text: from[i]})
+			i++
+		default:
+			lines = append(lines, DiffLine{Op: '+', Text: to[j]})
+			j++
+		}
+	}
+	for ; i < len(from); i++ {
+		lines = append(lines, DiffLine{Op: '-', Text: from[i]})
+	}
+	for ; j < len(to); j++ {
+		lines = append(lines, DiffLine{Op: '+', Text: to[j]})
+	}
+	return lines
+}
+
+// ShortHash - начало хеша редакции для вывода
+func (r Revision) ShortHash() string {
+	if len(r.Hash) > 12 {
+		return r.Hash[:12]
+	}
+	return r.Hash
+}
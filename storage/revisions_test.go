@@ -0,0 +1,110 @@
+package storage
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	. "parsing_media/utils"
+)
+
+// revision собирает редакцию статьи с заданными заголовком, тегами и абзацами текста
+func revision(hash, title string, tags []string, paragraphs ...string) Revision {
+	return Revision{CanonicalURL: "https://site.ru/news/1", Data: Data{
+		Hash: hash, Title: title, Tags: tags, Body: strings.Join(paragraphs, "\n"),
+		Date:      time.Date(2025, time.October, 7, 10, 0, 0, 0, time.UTC),
+		FetchedAt: time.Date(2025, time.October, 7, 12, 0, 0, 0, time.UTC),
+	}}
+}
+
+// diffBody - вывод RevisionDiff.String без строк ---/+++ с хешами и временем загрузки
+func diffBody(d RevisionDiff) string {
+	lines := strings.SplitN(d.String(), "\n", 3)
+	return lines[2]
+}
+
+func TestDiffRevisions(t *testing.T) {
+	body := []string{"Абзац 1", "Абзац 2", "Абзац 3", "Абзац 4", "Абзац 5", "Абзац 6", "Абзац 7", "Абзац 8"}
+	inserted := slices.Insert(slices.Clone(body), 6, "Вставка")
+	tests := []struct {
+		name string
+		from Revision
+		to   Revision
+		want string
+	}{
+		{
+			name: "без изменений",
+			from: revision("a", "Заголовок", []string{"Политика"}, body...),
+			to:   revision("a", "Заголовок", []string{"Политика"}, body...),
+			want: "Редакции совпадают\n",
+		},
+		{
+			name: "изменён заголовок",
+			from: revision("a", "Старый заголовок", nil, body...),
+			to:   revision("b", "Новый заголовок", nil, body...),
+			want: "-Заголовок: Старый заголовок\n+Заголовок: Новый заголовок\n",
+		},
+		{
+			name: "теги добавлены и удалены",
+			from: revision("a", "Заголовок", []string{"Политика", "Выборы", "Москва"}, body...),
+			to:   revision("b", "Заголовок", []string{"Москва", "Госдума", "Политика"}, body...),
+			want: "-Теги: Выборы\n+Теги: Госдума\n",
+		},
+		{
+			name: "вставка абзаца с контекстом",
+			from: revision("a", "Заголовок", nil, body...),
+			to:   revision("b", "Заголовок", nil, inserted...),
+			want: "@@ абзац 5 @@\n Абзац 5\n Абзац 6\n+Вставка\n Абзац 7\n Абзац 8\n",
+		},
+		{
+			name: "два изменения в разных местах",
+			from: revision("a", "Заголовок", nil, body...),
+			to:   revision("b", "Заголовок", nil, append([]string{"Абзац 1 исправлен"}, body[1:7]...)...),
+			want: "@@ абзац 1 @@\n-Абзац 1\n+Абзац 1 исправлен\n Абзац 2\n Абзац 3\n" +
+				"@@ абзац 6 @@\n Абзац 6\n Абзац 7\n-Абзац 8\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := diffBody(DiffRevisions(tt.from, tt.to)); got != tt.want {
+				t.Errorf("String =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDiffLinesLimit(t *testing.T) {
+	// Таблица LCS больше diffMaxCells не строится: старый текст удаляется, новый добавляется целиком
+	size := 2001
+	if size*size <= diffMaxCells {
+		t.Fatalf("%d абзацев не превышают diffMaxCells", size)
+	}
+	from := make([]string, size)
+	for i := range from {
+		from[i] = fmt.Sprintf("Абзац %d", i)
+	}
+	to := slices.Clone(from)
+	to[size/2] = "Изменённый абзац"
+
+	lines := diffLines(from, to)
+	if len(lines) != 2*size {
+		t.Fatalf("строк сравнения %d, want %d", len(lines), 2*size)
+	}
+	for i, line := range lines {
+		want := DiffLine{Op: '-', Text: from[i%size]}
+		if i >= size {
+			want = DiffLine{Op: '+', Text: to[i-size]}
+		}
+		if line != want {
+			t.Fatalf("строка %d = %+v, want %+v", i, line, want)
+		}
+	}
+
+	// Небольшой текст сравнивается по абзацам
+	lines = diffLines(from[:10], to[:10])
+	if changed := slices.IndexFunc(lines, func(l DiffLine) bool { return l.Op != ' ' }); len(lines) != 10 || changed != -1 {
+		t.Errorf("сравнение одинаковых абзацев = %+v", lines)
+	}
+}
@@ -0,0 +1,372 @@
+// storage/sql.go
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	. "parsing_media/utils"
+	"regexp"
+	"time"
+
+	"github.com/lib/pq"
+	"modernc.org/sqlite"
+	sqlite3 "modernc.org/sqlite/lib"
+)
+
+// Общие для Postgres и SQLite запросы. Параметры записываются в синтаксисе Postgres ($1, $2, ...)
+// и для SQLite заменяются на ? функцией rebind, поэтому нумерация в запросе должна идти по порядку.
+const (
+	findArticleSQL = `SELECT hash FROM articles WHERE canonical_url = $1`
+
+	insertArticleSQL = `
+    INSERT INTO articles (hash, site, href, canonical_url, title, body, date, tags,
+                          authors, lead, rubric, image_url, modified_at, body_fallback, rich_body, rich_format, checked_at)
+    VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17)
+    ON CONFLICT (hash) DO NOTHING;`
+
+	updateArticleSQL = `
+    UPDATE articles SET hash = $1, site = $2, href = $3, title = $4, body = $5, date = $6, tags = $7,
+                        authors = $8, lead = $9, rubric = $10, image_url = $11, modified_at = $12,
+                        body_fallback = $13, rich_body = $14, rich_format = $15, checked_at = $16
+    WHERE canonical_url = $17`
+
+	insertRevisionSQL = `
+    INSERT INTO article_revisions (canonical_url, hash, site, href, title, body, date, tags,
+                                   authors, lead, rubric, image_url, modified_at, body_fallback, rich_body, rich_format, fetched_at)
+    VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17)`
+
+	selectRevisionsSQL = `
+    SELECT canonical_url, hash, site, href, title, body, date, tags,
+           authors, lead, rubric, image_url, modified_at, body_fallback, rich_body, rich_format, fetched_at
+    FROM article_revisions WHERE canonical_url = $1
+    ORDER BY fetched_at, id`
+)
+
+var postgresParam = regexp.MustCompile(`\$\d+`)
+
+// rebind переводит параметры запроса в синтаксис диалекта
+func rebind(dialect, query string) string {
+	if dialect == "postgres" {
+		return query
+	}
+	return postgresParam.ReplaceAllString(query, "?")
+}
+
+// saveOutcome - что произошло со статьёй при сохранении
+type saveOutcome int
+
+const (
+	savedNew saveOutcome = iota
+	savedRevision
+	savedDuplicate
+)
+
+// add относит статью к списку результата по итогу сохранения
+func (r *SaveResult) add(p Data, outcome saveOutcome) {
+	switch outcome {
+	case savedNew:
+		r.Inserted = append(r.Inserted, p)
+	case savedRevision:
+		r.Revised = append(r.Revised, p)
+	default:
+		r.Duplicates = append(r.Duplicates, p)
+	}
+}
+
+// articleWriter - подготовленные в транзакции запросы сохранения статьи вместе с её редакциями и тегами
+type articleWriter struct {
+	dialect                        string
+	find, insert, update, revision *sql.Stmt
+	tags                           *tagWriter
+}
+
+func prepareArticleWriter(ctx context.Context, tx *sql.Tx, dialect string, taxonomy *Taxonomy) (*articleWriter, error) {
+	w := &articleWriter{dialect: dialect}
+	err := prepareStatements(ctx, tx, dialect, []preparedQuery{
+		{&w.find, findArticleSQL},
+		{&w.insert, insertArticleSQL},
+		{&w.update, updateArticleSQL},
+		{&w.revision, insertRevisionSQL},
+	})
+	if err == nil {
+		w.tags, err = prepareTagWriter(ctx, tx, dialect, taxonomy)
+	}
+	if err != nil {
+		w.Close()
+		return nil, err
+	}
+	return w, nil
+}
+
+func (w *articleWriter) Close() {
+	closeStatements(w.find, w.insert, w.update, w.revision)
+	if w.tags != nil {
+		w.tags.Close()
+	}
+}
+
+// preparedQuery - запрос и место для подготовленного по нему выражения
+type preparedQuery struct {
+	stmt  **sql.Stmt
+	query string
+}
+
+// prepareStatements готовит запросы в транзакции; при ошибке уже подготовленные остаются в своих местах для closeStatements
+func prepareStatements(ctx context.Context, tx *sql.Tx, dialect string, queries []preparedQuery) error {
+	for _, prepared := range queries {
+		stmt, err := tx.PrepareContext(ctx, rebind(dialect, prepared.query))
+		if err != nil {
+			return err
+		}
+		*prepared.stmt = stmt
+	}
+	return nil
+}
+
+func closeStatements(stmts ...*sql.Stmt) {
+	for _, stmt := range stmts {
+		if stmt != nil {
+			stmt.Close()
+		}
+	}
+}
+
+// save сохраняет статью по её канонической ссылке: новую - вставкой, изменённую - обновлением строки articles.
+// В обоих случаях содержимое добавляется в article_revisions, а связи с тегами и категориями заменяются.
+// Статья с уже сохранённым хешем - дубликат.
+func (w *articleWriter) save(ctx context.Context, p Data) (saveOutcome, error) {
+	canonical := CanonicalURL(p.Href)
+	enc, err := encodeArticle(w.dialect, p)
+	if err != nil {
+		return 0, err
+	}
+	fetchedAt := p.FetchedAt
+	if fetchedAt.IsZero() {
+		fetchedAt = time.Now()
+	}
+	checkedAt := encodeTime(w.dialect, fetchedAt)
+
+	var outcome saveOutcome
+	var currentHash string
+	err = w.find.QueryRowContext(ctx, canonical).Scan(&currentHash)
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		res, err := w.insert.ExecContext(ctx, p.Hash, p.Site, p.Href, canonical, p.Title, p.Body, enc.date, enc.tags,
+			enc.authors, p.Lead, p.Rubric, p.Image, enc.modified, p.BodyFallback, p.RichBody, p.RichFormat, checkedAt)
+		if err != nil {
+			return 0, err
+		}
+		if affected, err := res.RowsAffected(); err == nil && affected == 0 {
+			// Такое же содержимое уже сохранено под другой ссылкой
+			return savedDuplicate, nil
+		}
+		outcome = savedNew
+	case err != nil:
+		return 0, fmt.Errorf("поиск статьи: %w", err)
+	case currentHash == p.Hash:
+		return savedDuplicate, nil
+	default:
+		_, err := w.update.ExecContext(ctx, p.Hash, p.Site, p.Href, p.Title, p.Body, enc.date, enc.tags,
+			enc.authors, p.Lead, p.Rubric, p.Image, enc.modified, p.BodyFallback, p.RichBody, p.RichFormat, checkedAt, canonical)
+		if err != nil {
+			return 0, err
+		}
+		outcome = savedRevision
+	}
+
+	_, err = w.revision.ExecContext(ctx, canonical, p.Hash, p.Site, p.Href, p.Title, p.Body, enc.date, enc.tags,
+		enc.authors, p.Lead, p.Rubric, p.Image, enc.modified, p.BodyFallback, p.RichBody, p.RichFormat, checkedAt)
+	if err != nil {
+		return 0, fmt.Errorf("запись редакции: %w", err)
+	}
+	if err := w.tags.write(ctx, canonical, p.Site, p.Tags); err != nil {
+		return 0, err
+	}
+	return outcome, nil
+}
+
+// encodedArticle - поля статьи в представлении диалекта для параметров запроса
+type encodedArticle struct {
+	tags, authors  any
+	date, modified any // modified - NULL, если время изменения неизвестно
+}
+
+// encodeArticle переводит списки и даты статьи в представление диалекта:
+// в Postgres - массивы и timestamptz, в SQLite - JSON-массивы и строки RFC3339 в UTC
+func encodeArticle(dialect string, p Data) (encodedArticle, error) {
+	enc := encodedArticle{date: encodeTime(dialect, p.Date)}
+	if !p.Modified.IsZero() {
+		enc.modified = encodeTime(dialect, p.Modified)
+	}
+	if dialect == "postgres" {
+		enc.tags, enc.authors = pq.Array(nonNilTags(p.Tags)), pq.Array(nonNilTags(p.Authors))
+		return enc, nil
+	}
+	tags, err := json.Marshal(nonNilTags(p.Tags))
+	if err != nil {
+		return encodedArticle{}, fmt.Errorf("сериализация тегов: %w", err)
+	}
+	authors, err := json.Marshal(nonNilTags(p.Authors))
+	if err != nil {
+		return encodedArticle{}, fmt.Errorf("сериализация авторов: %w", err)
+	}
+	enc.tags, enc.authors = string(tags), string(authors)
+	return enc, nil
+}
+
+func encodeTime(dialect string, t time.Time) any {
+	if dialect == "postgres" {
+		return t
+	}
+	return t.UTC().Format(time.RFC3339)
+}
+
+// saveRows сохраняет статьи одной транзакцией. Каждая статья записывается под своей точкой сохранения:
+// в Postgres ошибка любого запроса прерывает всю транзакцию, а откат к точке сохранения
+// отменяет только неудачную статью. При отмене ctx транзакция откатывается целиком.
+func saveRows(ctx context.Context, db *sql.DB, dialect string, taxonomy *Taxonomy, products []Data) (SaveResult, error) {
+	var result SaveResult
+	if len(products) == 0 {
+		return result, nil
+	}
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		fmt.Printf("%s[DB][ERROR] Ошибка начала транзакции: %v%s\n", ColorRed, err, ColorReset)
+		return SaveResult{}, fmt.Errorf("начало транзакции: %w", err)
+	}
+	defer tx.Rollback()
+
+	writer, err := prepareArticleWriter(ctx, tx, dialect, taxonomy)
+	if err != nil {
+		fmt.Printf("%s[DB][ERROR] Ошибка подготовки запроса: %v%s\n", ColorRed, err, ColorReset)
+		return SaveResult{}, fmt.Errorf("подготовка запроса: %w", err)
+	}
+	defer writer.Close()
+
+	for _, p := range products {
+		if ctx.Err() != nil {
+			fmt.Printf("%s[DB][WARN] Сохранение прервано: %v. Транзакция отменена.%s\n", ColorYellow, ctx.Err(), ColorReset)
+			return SaveResult{}, ctx.Err()
+		}
+
+		if _, err := tx.ExecContext(ctx, `SAVEPOINT article_row`); err != nil {
+			return SaveResult{}, fmt.Errorf("точка сохранения: %w", err)
+		}
+		outcome, err := writer.save(ctx, p)
+		if err != nil {
+			if ctx.Err() != nil {
+				return SaveResult{}, ctx.Err()
+			}
+			if _, rbErr := tx.ExecContext(ctx, `ROLLBACK TO SAVEPOINT article_row`); rbErr != nil {
+				return SaveResult{}, fmt.Errorf("откат к точке сохранения после ошибки вставки %s: %w", p.Href, rbErr)
+			}
+			if isUniqueViolation(err) {
+				// Статья с таким хешем или ссылкой уже сохранена
+				result.add(p, savedDuplicate)
+			} else {
+				result.fail(p, err)
+			}
+			continue
+		}
+		if _, err := tx.ExecContext(ctx, `RELEASE SAVEPOINT article_row`); err != nil {
+			return SaveResult{}, fmt.Errorf("освобождение точки сохранения: %w", err)
+		}
+		result.add(p, outcome)
+	}
+
+	if err := tx.Commit(); err != nil {
+		fmt.Printf("%s[DB][ERROR] Ошибка фиксации транзакции: %v%s\n", ColorRed, err, ColorReset)
+		return SaveResult{}, fmt.Errorf("фиксация транзакции: %w", err)
+	}
+	return result, nil
+}
+
+// pqUniqueViolation - код ошибки Postgres при нарушении уникальности
+const pqUniqueViolation = pq.ErrorCode("23505")
+
+// isUniqueViolation сообщает, что запись отклонена ограничением уникальности (Postgres или SQLite)
+func isUniqueViolation(err error) bool {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		return pqErr.Code == pqUniqueViolation
+	}
+	var sqliteErr *sqlite.Error
+	if errors.As(err, &sqliteErr) {
+		code := sqliteErr.Code()
+		return code == sqlite3.SQLITE_CONSTRAINT_UNIQUE || code == sqlite3.SQLITE_CONSTRAINT_PRIMARYKEY
+	}
+	return false
+}
+
+// knownByCanonical проверяет ссылки по их каноническому виду: lookup получает канонические ссылки
+// и возвращает сохранённые из них, а результат содержит исходные hrefs
+func knownByCanonical(hrefs []string, lookup func(canonical []string) (map[string]bool, error)) (map[string]bool, error) {
+	byCanonical := make(map[string][]string, len(hrefs))
+	canonical := make([]string, 0, len(hrefs))
+	for _, href := range hrefs {
+		c := CanonicalURL(href)
+		if _, ok := byCanonical[c]; !ok {
+			canonical = append(canonical, c)
+		}
+		byCanonical[c] = append(byCanonical[c], href)
+	}
+
+	found, err := lookup(canonical)
+	if err != nil {
+		return nil, err
+	}
+	stored := make(map[string]bool, len(found))
+	for c := range found {
+		for _, href := range byCanonical[c] {
+			stored[href] = true
+		}
+	}
+	return stored, nil
+}
+
+// loadRevisions читает редакции статьи по ссылке href от старой к новой
+func loadRevisions(ctx context.Context, db *sql.DB, dialect, href string) ([]Revision, error) {
+	rows, err := db.QueryContext(ctx, rebind(dialect, selectRevisionsSQL), CanonicalURL(href))
+	if err != nil {
+		return nil, fmt.Errorf("чтение редакций: %w", err)
+	}
+	defer rows.Close()
+
+	var revisions []Revision
+	for rows.Next() {
+		var r Revision
+		var modified sql.NullTime
+		tags, decodeTags := tagsDest(dialect, &r.Tags)
+		authors, decodeAuthors := tagsDest(dialect, &r.Authors)
+		err := rows.Scan(&r.CanonicalURL, &r.Hash, &r.Site, &r.Href, &r.Title, &r.Body, &r.Date, tags,
+			authors, &r.Lead, &r.Rubric, &r.Image, &modified, &r.BodyFallback, &r.RichBody, &r.RichFormat, &r.FetchedAt)
+		if err != nil {
+			return nil, fmt.Errorf("чтение редакций: %w", err)
+		}
+		if err := decodeTags(); err != nil {
+			return nil, fmt.Errorf("теги редакции %s: %w", r.Hash, err)
+		}
+		if err := decodeAuthors(); err != nil {
+			return nil, fmt.Errorf("авторы редакции %s: %w", r.Hash, err)
+		}
+		r.Modified = modified.Time
+		revisions = append(revisions, r)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("чтение редакций: %w", err)
+	}
+	return revisions, nil
+}
+
+// tagsDest возвращает приёмник Scan для колонки-списка (tags, authors) и функцию, которая переносит прочитанное значение в tags
+func tagsDest(dialect string, tags *[]string) (any, func() error) {
+	if dialect == "postgres" {
+		return pq.Array(tags), func() error { return nil }
+	}
+	var raw string
+	return &raw, func() error { return json.Unmarshal([]byte(raw), tags) }
+}
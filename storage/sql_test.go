@@ -0,0 +1,90 @@
+package storage
+
+import (
+	"context"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	. "parsing_media/utils"
+)
+
+// openTestSQLite создаёт базу SQLite во временном каталоге со схемой после всех миграций
+func openTestSQLite(t *testing.T, taxonomy *Taxonomy) *SQLite {
+	t.Helper()
+	s, err := OpenSQLite(context.Background(), filepath.Join(t.TempDir(), "articles.db"), "auto", taxonomy)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { s.Close() })
+	return s
+}
+
+// queryStrings возвращает первый столбец результата запроса
+func queryStrings(t *testing.T, s *SQLite, query string, args ...any) []string {
+	t.Helper()
+	rows, err := s.DB().QueryContext(context.Background(), query, args...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+	var values []string
+	for rows.Next() {
+		var value string
+		if err := rows.Scan(&value); err != nil {
+			t.Fatal(err)
+		}
+		values = append(values, value)
+	}
+	if err := rows.Err(); err != nil {
+		t.Fatal(err)
+	}
+	return values
+}
+
+func TestSQLiteSaveRollsBackFailedRow(t *testing.T) {
+	ctx := context.Background()
+	s := openTestSQLite(t, nil)
+	// Статья "Ошибка" вставляется в articles, но запись её редакции нарушает ограничение
+	_, err := s.DB().ExecContext(ctx, `
+    CREATE TRIGGER reject_revision BEFORE INSERT ON article_revisions
+    WHEN NEW.title = 'Ошибка'
+    BEGIN SELECT RAISE(ABORT, 'редакция отклонена'); END`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	first := testArticle(t, "https://site.ru/news/1", "Первая", "Политика")
+	broken := testArticle(t, "https://site.ru/news/2", "Ошибка", "Политика")
+	last := testArticle(t, "https://site.ru/news/3", "Третья")
+	result, err := s.Save(ctx, []Data{first, broken, last})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Inserted) != 2 || result.Inserted[0].Href != first.Href || result.Inserted[1].Href != last.Href {
+		t.Errorf("сохранены %+v, want первая и третья", result.Inserted)
+	}
+	if len(result.Failed) != 1 || result.Failed[0].Article.Href != broken.Href || result.Failed[0].Err == nil {
+		t.Errorf("ошибки сохранения %+v, want только вторая статья", result.Failed)
+	}
+
+	// Откат к точке сохранения убрал и строку articles, и теги неудачной статьи
+	want := []string{first.Href, last.Href}
+	if got := queryStrings(t, s, `SELECT canonical_url FROM articles ORDER BY canonical_url`); !slices.Equal(got, want) {
+		t.Errorf("articles = %q, want %q", got, want)
+	}
+	if got := queryStrings(t, s, `SELECT canonical_url FROM article_revisions ORDER BY canonical_url`); !slices.Equal(got, want) {
+		t.Errorf("article_revisions = %q, want %q", got, want)
+	}
+	if got := queryStrings(t, s, `SELECT DISTINCT canonical_url FROM article_tags`); !slices.Equal(got, []string{first.Href}) {
+		t.Errorf("article_tags = %q, want только первая статья", got)
+	}
+
+	known, err := s.KnownHrefs(ctx, []string{first.Href, broken.Href, last.Href})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !known[first.Href] || known[broken.Href] || !known[last.Href] {
+		t.Errorf("KnownHrefs = %v", known)
+	}
+}
@@ -0,0 +1,105 @@
+// storage/sqlite.go
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	. "parsing_media/utils"
+	"strings"
+
+	_ "modernc.org/sqlite"
+)
+
+// sqliteMaxParams - сколько ссылок проверяется одним запросом KnownHrefs
+const sqliteMaxParams = 500
+
+// SQLite - встроенное файловое хранилище для локальной разработки без сервера Postgres
+type SQLite struct {
+	db       *sql.DB
+	taxonomy *Taxonomy
+}
+
+// OpenSQLite открывает (или создаёт) файл базы path и готовит схему миграциями (migrateMode: auto, verify, off).
+// Категории сохраняемых статей назначаются по taxonomy.
+func OpenSQLite(ctx context.Context, path string, migrateMode string, taxonomy *Taxonomy) (*SQLite, error) {
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка открытия SQLite %s: %w", path, err)
+	}
+	// SQLite допускает только одного писателя, поэтому пул из одного соединения избавляет от SQLITE_BUSY
+	db.SetMaxOpenConns(1)
+
+	for _, pragma := range []string{"PRAGMA journal_mode = WAL", "PRAGMA busy_timeout = 5000", "PRAGMA foreign_keys = ON"} {
+		if _, err := db.ExecContext(ctx, pragma); err != nil {
+			db.Close()
+			return nil, fmt.Errorf("настройка SQLite %s: %w", path, err)
+		}
+	}
+
+	s := &SQLite{db: db, taxonomy: taxonomy}
+	migrator, err := s.Migrator()
+	if err == nil {
+		err = prepareSchema(ctx, migrator, migrateMode)
+	}
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("схема SQLite %s: %w", path, err)
+	}
+	return s, nil
+}
+
+// DB возвращает соединение с базой
+func (s *SQLite) DB() *sql.DB { return s.db }
+
+// Migrator возвращает мигратор схемы SQLite
+func (s *SQLite) Migrator() (*Migrator, error) { return newMigrator(s.db, "sqlite") }
+
+func (s *SQLite) Close() error { return s.db.Close() }
+
+// Save сохраняет статьи одной транзакцией с учётом редакций (см. articleWriter.save)
+func (s *SQLite) Save(ctx context.Context, articles []Data) (SaveResult, error) {
+	return saveRows(ctx, s.db, "sqlite", s.taxonomy, articles)
+}
+
+// KnownHrefs выбирает из hrefs ссылки, статьи по которым уже есть в таблице articles (сравниваются канонические ссылки)
+func (s *SQLite) KnownHrefs(ctx context.Context, hrefs []string) (map[string]bool, error) {
+	return knownByCanonical(hrefs, func(canonical []string) (map[string]bool, error) {
+		stored := make(map[string]bool)
+		for start := 0; start < len(canonical); start += sqliteMaxParams {
+			chunk := canonical[start:min(start+sqliteMaxParams, len(canonical))]
+
+			args := make([]any, len(chunk))
+			for i, href := range chunk {
+				args[i] = href
+			}
+			query := `SELECT canonical_url FROM articles WHERE canonical_url IN (?` + strings.Repeat(", ?", len(chunk)-1) + `)`
+
+			rows, err := s.db.QueryContext(ctx, query, args...)
+			if err != nil {
+				return nil, fmt.Errorf("проверка сохранённых ссылок: %w", err)
+			}
+			found, err := scanHrefs(rows)
+			if err != nil {
+				return nil, err
+			}
+			for href := range found {
+				stored[href] = true
+			}
+		}
+		return stored, nil
+	})
+}
+
+// Revisions возвращает редакции статьи от старой к новой
+func (s *SQLite) Revisions(ctx context.Context, href string) ([]Revision, error) {
+	return loadRevisions(ctx, s.db, "sqlite", href)
+}
+
+// nonNilTags заменяет nil на пустой срез, чтобы в хранилище попадал [] вместо null
+func nonNilTags(tags []string) []string {
+	if tags == nil {
+		return []string{}
+	}
+	return tags
+}
@@ -0,0 +1,62 @@
+// Package storage - хранилища собранных статей: Postgres, SQLite, JSON Lines и память
+package storage
+
+import (
+	"context"
+	"fmt"
+	. "parsing_media/utils"
+)
+
+// Storage - хранилище собранных статей
+type Storage interface {
+	// Save сохраняет статьи, пропуская дубликаты. Статья опознаётся по канонической ссылке (CanonicalURL):
+	// новое содержимое уже сохранённой статьи становится её новой редакцией.
+	// Ошибка одной статьи не мешает сохранить остальные и попадает в SaveResult.Failed;
+	// возвращённая ошибка означает, что не зафиксировано ничего.
+	Save(ctx context.Context, articles []Data) (SaveResult, error)
+	// KnownHrefs возвращает те из hrefs, статьи по которым уже сохранены
+	KnownHrefs(ctx context.Context, hrefs []string) (map[string]bool, error)
+	// Revisions возвращает сохранённые редакции статьи по ссылке от старой к новой
+	Revisions(ctx context.Context, href string) ([]Revision, error)
+	Close() error
+}
+
+// SaveResult - итог сохранения пачки статей
+type SaveResult struct {
+	Inserted   []Data
+	Revised    []Data // статьи, содержимое которых изменилось с прошлого сохранения
+	Duplicates []Data // статьи, которые уже были в хранилище
+	Failed     []SaveFailure
+}
+
+// SaveFailure - статья, которую не удалось сохранить, и причина
+type SaveFailure struct {
+	Article Data
+	Err     error
+}
+
+func (r *SaveResult) fail(p Data, err error) {
+	r.Failed = append(r.Failed, SaveFailure{Article: p, Err: err})
+}
+
+// Open создаёт хранилище, выбранное в cfg.Storage.Backend. Нормализованные теги и категории
+// по таксономии cfg.Storage.TaxonomyFile ведут только хранилища postgres и sqlite.
+func Open(ctx context.Context, cfg *Config) (Storage, error) {
+	switch cfg.Storage.Backend {
+	case "postgres", "sqlite":
+		taxonomy, err := LoadTaxonomy(cfg.Storage.TaxonomyFile)
+		if err != nil {
+			return nil, err
+		}
+		if cfg.Storage.Backend == "sqlite" {
+			return OpenSQLite(ctx, cfg.Storage.SQLitePath, cfg.Storage.Migrate, taxonomy)
+		}
+		return OpenPostgres(ctx, cfg.Database, cfg.Storage.Migrate, taxonomy)
+	case "jsonl":
+		return OpenJSONL(cfg.Storage.JSONLPath)
+	case "memory":
+		return NewMemory(), nil
+	default:
+		return nil, fmt.Errorf("неизвестное хранилище '%s' (postgres, sqlite, jsonl, memory)", cfg.Storage.Backend)
+	}
+}
@@ -0,0 +1,173 @@
+// storage/tags.go
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	. "parsing_media/utils"
+)
+
+// retagBatch - сколько статей обрабатывается одной транзакцией команды retag
+const retagBatch = 1000
+
+// Запросы связей статьи с нормализованными тегами и категориями (синтаксис параметров - как в sql.go)
+const (
+	insertTagSQL    = `INSERT INTO tags (name) VALUES ($1) ON CONFLICT (name) DO NOTHING`
+	unlinkTagsSQL   = `DELETE FROM article_tags WHERE canonical_url = $1`
+	linkTagSQL      = `INSERT INTO article_tags (canonical_url, tag_id) SELECT CAST($1 AS TEXT), id FROM tags WHERE name = $2 ON CONFLICT DO NOTHING`
+	uncategorizeSQL = `DELETE FROM article_categories WHERE canonical_url = $1`
+	categorizeSQL   = `INSERT INTO article_categories (canonical_url, category) VALUES ($1, $2) ON CONFLICT DO NOTHING`
+)
+
+// Retagger реализуется хранилищами с нормализованными тегами
+type Retagger interface {
+	// Retag заново связывает все сохранённые статьи с тегами и категориями - например, после правки таксономии.
+	// Возвращает число обработанных статей.
+	Retag(ctx context.Context) (int, error)
+}
+
+// tagWriter - подготовленные в транзакции запросы, заменяющие теги и категории статьи
+type tagWriter struct {
+	taxonomy                                                 *Taxonomy
+	insertTag, unlinkTags, linkTag, uncategorize, categorize *sql.Stmt
+}
+
+func prepareTagWriter(ctx context.Context, tx *sql.Tx, dialect string, taxonomy *Taxonomy) (*tagWriter, error) {
+	w := &tagWriter{taxonomy: taxonomy}
+	err := prepareStatements(ctx, tx, dialect, []preparedQuery{
+		{&w.insertTag, insertTagSQL},
+		{&w.unlinkTags, unlinkTagsSQL},
+		{&w.linkTag, linkTagSQL},
+		{&w.uncategorize, uncategorizeSQL},
+		{&w.categorize, categorizeSQL},
+	})
+	if err != nil {
+		w.Close()
+		return nil, err
+	}
+	return w, nil
+}
+
+func (w *tagWriter) Close() {
+	closeStatements(w.insertTag, w.unlinkTags, w.linkTag, w.uncategorize, w.categorize)
+}
+
+// write заменяет связи статьи canonical нормализованными тегами tags и категориями по таксономии
+func (w *tagWriter) write(ctx context.Context, canonical, site string, tags []string) error {
+	if _, err := w.unlinkTags.ExecContext(ctx, canonical); err != nil {
+		return fmt.Errorf("удаление тегов статьи: %w", err)
+	}
+	for _, tag := range NormalizeTags(tags) {
+		if _, err := w.insertTag.ExecContext(ctx, tag); err != nil {
+			return fmt.Errorf("запись тега '%s': %w", tag, err)
+		}
+		if _, err := w.linkTag.ExecContext(ctx, canonical, tag); err != nil {
+			return fmt.Errorf("связь с тегом '%s': %w", tag, err)
+		}
+	}
+
+	if _, err := w.uncategorize.ExecContext(ctx, canonical); err != nil {
+		return fmt.Errorf("удаление категорий статьи: %w", err)
+	}
+	for _, category := range w.taxonomy.Categorize(site, tags) {
+		if _, err := w.categorize.ExecContext(ctx, canonical, category); err != nil {
+			return fmt.Errorf("запись категории '%s': %w", category, err)
+		}
+	}
+	return nil
+}
+
+// taggedArticle - сохранённые теги статьи для команды retag
+type taggedArticle struct {
+	canonical, site string
+	tags            []string
+}
+
+// retag перестраивает связи всех статей с тегами и категориями пачками по retagBatch статей,
+// а затем удаляет теги, на которые не ссылается ни одна статья
+func retag(ctx context.Context, db *sql.DB, dialect string, taxonomy *Taxonomy) (int, error) {
+	processed := 0
+	after := ""
+	for {
+		batch, err := loadTaggedArticles(ctx, db, dialect, after)
+		if err != nil {
+			return processed, err
+		}
+		if len(batch) == 0 {
+			break
+		}
+		if err := retagBatchTx(ctx, db, dialect, taxonomy, batch); err != nil {
+			return processed, err
+		}
+		processed += len(batch)
+		after = batch[len(batch)-1].canonical
+	}
+
+	if _, err := db.ExecContext(ctx, `DELETE FROM tags WHERE NOT EXISTS (SELECT 1 FROM article_tags l WHERE l.tag_id = tags.id)`); err != nil {
+		return processed, fmt.Errorf("удаление неиспользуемых тегов: %w", err)
+	}
+	return processed, nil
+}
+
+// loadTaggedArticles читает следующие retagBatch статей по порядку канонических ссылок после after
+func loadTaggedArticles(ctx context.Context, db *sql.DB, dialect, after string) ([]taggedArticle, error) {
+	rows, err := db.QueryContext(ctx, rebind(dialect, `
+    SELECT canonical_url, site, tags FROM articles
+    WHERE canonical_url > $1
+    ORDER BY canonical_url
+    LIMIT $2`), after, retagBatch)
+	if err != nil {
+		return nil, fmt.Errorf("чтение тегов статей: %w", err)
+	}
+	defer rows.Close()
+
+	var batch []taggedArticle
+	for rows.Next() {
+		var a taggedArticle
+		tags, decodeTags := tagsDest(dialect, &a.tags)
+		if err := rows.Scan(&a.canonical, &a.site, tags); err != nil {
+			return nil, fmt.Errorf("чтение тегов статей: %w", err)
+		}
+		if err := decodeTags(); err != nil {
+			return nil, fmt.Errorf("теги статьи %s: %w", a.canonical, err)
+		}
+		batch = append(batch, a)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("чтение тегов статей: %w", err)
+	}
+	return batch, nil
+}
+
+func retagBatchTx(ctx context.Context, db *sql.DB, dialect string, taxonomy *Taxonomy, batch []taggedArticle) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("начало транзакции: %w", err)
+	}
+	defer tx.Rollback()
+
+	writer, err := prepareTagWriter(ctx, tx, dialect, taxonomy)
+	if err != nil {
+		return fmt.Errorf("подготовка запроса: %w", err)
+	}
+	defer writer.Close()
+
+	for _, a := range batch {
+		if err := writer.write(ctx, a.canonical, a.site, a.tags); err != nil {
+			return fmt.Errorf("теги статьи %s: %w", a.canonical, err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("фиксация транзакции: %w", err)
+	}
+	return nil
+}
+
+func (s *Postgres) Retag(ctx context.Context) (int, error) {
+	return retag(ctx, s.db, "postgres", s.taxonomy)
+}
+
+func (s *SQLite) Retag(ctx context.Context) (int, error) {
+	return retag(ctx, s.db, "sqlite", s.taxonomy)
+}
@@ -0,0 +1,83 @@
+package storage
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	. "parsing_media/utils"
+)
+
+// loadTestTaxonomy читает таксономию из YAML content
+func loadTestTaxonomy(t *testing.T, content string) *Taxonomy {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "taxonomy.yaml")
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	taxonomy, err := LoadTaxonomy(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return taxonomy
+}
+
+func TestSQLiteRetag(t *testing.T) {
+	ctx := context.Background()
+	s := openTestSQLite(t, loadTestTaxonomy(t, `
+categories:
+  politics: [Госдума]
+`))
+	first := testArticle(t, "https://site.ru/news/1", "Первая", "#Госдума", "Выборы")
+	second := testArticle(t, "https://site.ru/news/2", "Вторая", "Футбол")
+	if result, err := s.Save(ctx, []Data{first, second}); err != nil || len(result.Inserted) != 2 {
+		t.Fatalf("Save = %+v, %v", result, err)
+	}
+
+	tagsOf := func(href string) []string {
+		return queryStrings(t, s, `SELECT t.name FROM article_tags l JOIN tags t ON t.id = l.tag_id WHERE l.canonical_url = ? ORDER BY t.name`, href)
+	}
+	categoriesOf := func(href string) []string {
+		return queryStrings(t, s, `SELECT category FROM article_categories WHERE canonical_url = ? ORDER BY category`, href)
+	}
+	if got := tagsOf(first.Href); !slices.Equal(got, []string{"выборы", "госдума"}) {
+		t.Errorf("теги первой статьи = %q", got)
+	}
+	if got := categoriesOf(first.Href); !slices.Equal(got, []string{"politics"}) {
+		t.Errorf("категории первой статьи = %q", got)
+	}
+
+	// Удаление статьи удаляет её связи (PRAGMA foreign_keys), а retag - ставшие ненужными теги
+	if _, err := s.DB().ExecContext(ctx, `DELETE FROM articles WHERE canonical_url = ?`, second.Href); err != nil {
+		t.Fatal(err)
+	}
+	if got := tagsOf(second.Href); len(got) != 0 {
+		t.Errorf("связи удалённой статьи остались: %q", got)
+	}
+
+	s.taxonomy = loadTestTaxonomy(t, `
+categories:
+  politics: [Госдума, Выборы]
+sites:
+  site.ru:
+    society: [Госдума]
+`)
+	processed, err := s.Retag(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if processed != 1 {
+		t.Errorf("Retag обработал %d статей, want 1", processed)
+	}
+	if got := categoriesOf(first.Href); !slices.Equal(got, []string{"politics", "society"}) {
+		t.Errorf("категории после retag = %q, want соответствие сайта для госдумы и общее для выборов", got)
+	}
+	if got := tagsOf(first.Href); !slices.Equal(got, []string{"выборы", "госдума"}) {
+		t.Errorf("теги после retag = %q", got)
+	}
+	if got := queryStrings(t, s, `SELECT name FROM tags ORDER BY name`); !slices.Equal(got, []string{"выборы", "госдума"}) {
+		t.Errorf("таблица tags после retag = %q, want без неиспользуемого тега", got)
+	}
+}
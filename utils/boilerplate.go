@@ -0,0 +1,271 @@
+// utils/boilerplate.go
+package utils
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/andybalholm/cascadia"
+	"gopkg.in/yaml.v3"
+)
+
+// DefaultBoilerplatePath - файл правил очистки текста, который читается, если путь не изменён в конфигурации
+const DefaultBoilerplatePath = "boilerplate.yaml"
+
+// defaultParagraphs - элементы, к которым применяются правила для абзацев, если сайт не задал свои
+const defaultParagraphs = "p, li, blockquote, h2, h3, h4, h5, h6"
+
+// BoilerplateRules - правила удаления служебных блоков со страницы статьи: подписки, врезки, подписи к фото.
+// Все правила действуют внутри Scope (пусто - вся страница).
+type BoilerplateRules struct {
+	Scope        string   `yaml:"scope" json:"scope"`                 // контейнер текста статьи
+	Paragraphs   string   `yaml:"paragraphs" json:"paragraphs"`       // селектор абзацев (по умолчанию p, li, blockquote и подзаголовки)
+	Remove       []string `yaml:"remove" json:"remove"`               // CSS-селекторы, удаляемые целиком
+	DropPrefixes []string `yaml:"drop_prefixes" json:"drop_prefixes"` // абзацы, начинающиеся с одной из строк
+	DropContains []string `yaml:"drop_contains" json:"drop_contains"` // абзацы, содержащие одну из строк
+	DropPatterns []string `yaml:"drop_patterns" json:"drop_patterns"` // регулярные выражения по тексту абзаца
+	Promo        []string `yaml:"promo" json:"promo"`                 // регулярные выражения по тексту абзаца со ссылкой
+}
+
+// boilerplateFile - формат файла правил: общие правила и правила по домену сайта
+type boilerplateFile struct {
+	Common BoilerplateRules            `yaml:"common"`
+	Sites  map[string]BoilerplateRules `yaml:"sites"`
+}
+
+// paragraphRule - правило для текста абзаца; name - как правило показывается в отчёте
+type paragraphRule struct {
+	name      string
+	needsLink bool
+	match     func(text string) bool
+}
+
+// CompiledRules - проверенные и готовые к применению BoilerplateRules
+type CompiledRules struct {
+	scope      string
+	paragraphs string
+	remove     []string
+	rules      []paragraphRule
+}
+
+// CompileRules проверяет селекторы и регулярные выражения правил
+func CompileRules(r BoilerplateRules) (*CompiledRules, error) {
+	c := &CompiledRules{scope: r.Scope, paragraphs: cmp.Or(r.Paragraphs, defaultParagraphs)}
+	for _, selector := range append([]string{r.Scope, c.paragraphs}, r.Remove...) {
+		if selector == "" {
+			continue
+		}
+		if _, err := cascadia.Compile(selector); err != nil {
+			return nil, fmt.Errorf("неверный CSS-селектор '%s': %w", selector, err)
+		}
+	}
+	c.remove = r.Remove
+
+	for _, prefix := range r.DropPrefixes {
+		c.rules = append(c.rules, paragraphRule{name: "prefix " + strconv.Quote(prefix), match: func(text string) bool {
+			return strings.HasPrefix(text, prefix)
+		}})
+	}
+	for _, sub := range r.DropContains {
+		c.rules = append(c.rules, paragraphRule{name: "contains " + strconv.Quote(sub), match: func(text string) bool {
+			return strings.Contains(text, sub)
+		}})
+	}
+	for _, group := range []struct {
+		kind      string
+		patterns  []string
+		needsLink bool
+	}{{"pattern", r.DropPatterns, false}, {"promo", r.Promo, true}} {
+		for _, pattern := range group.patterns {
+			re, err := regexp.Compile(pattern)
+			if err != nil {
+				return nil, fmt.Errorf("неверное регулярное выражение '%s': %w", pattern, err)
+			}
+			c.rules = append(c.rules, paragraphRule{name: group.kind + " " + strconv.Quote(pattern), needsLink: group.needsLink, match: re.MatchString})
+		}
+	}
+	return c, nil
+}
+
+// Empty сообщает, что в наборе нет ни одного правила
+func (c *CompiledRules) Empty() bool {
+	return c == nil || (len(c.remove) == 0 && len(c.rules) == 0)
+}
+
+// Clean удаляет из документа блоки, подходящие под правила, и записывает в stats, сколько текста удалило каждое правило.
+// Абзацы, внутри которых есть другие абзацы, не проверяются: правило применяется к самому вложенному.
+func (c *CompiledRules) Clean(doc *goquery.Document, stats CleaningStats) {
+	if c.Empty() {
+		return
+	}
+	root := doc.Selection
+	if c.scope != "" {
+		root = doc.Find(c.scope)
+	}
+
+	for _, selector := range c.remove {
+		found := root.Find(selector)
+		// Вложенные совпадения удаляются вместе с внешними и не должны учитываться дважды
+		found.FilterFunction(func(_ int, s *goquery.Selection) bool {
+			return s.ParentsFiltered(selector).Length() == 0
+		}).Each(func(_ int, s *goquery.Selection) {
+			stats.add("remove "+strconv.Quote(selector), s.Text())
+		})
+		found.Remove()
+	}
+
+	if len(c.rules) == 0 {
+		return
+	}
+	root.Find(c.paragraphs).Each(func(_ int, s *goquery.Selection) {
+		if s.Find(c.paragraphs).Length() > 0 {
+			return
+		}
+		text := strings.TrimSpace(s.Text())
+		if text == "" {
+			return
+		}
+		for _, rule := range c.rules {
+			if rule.needsLink && s.Find("a[href]").Length() == 0 {
+				continue
+			}
+			if rule.match(text) {
+				stats.add(rule.name, text)
+				s.Remove()
+				return
+			}
+		}
+	})
+}
+
+// Boilerplate - правила очистки текста для всех сайтов: общие и по домену
+type Boilerplate struct {
+	common *CompiledRules
+	sites  map[string]*CompiledRules
+}
+
+// LoadBoilerplate читает файл правил path. Если файла DefaultBoilerplatePath нет, применяются правила defaults,
+// встроенные в бинарник (пустые defaults - страницы не очищаются).
+func LoadBoilerplate(path string, defaults []byte) (*Boilerplate, error) {
+	if path == "" {
+		return ParseBoilerplate(nil, "")
+	}
+
+	raw, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) && path == DefaultBoilerplatePath {
+		fmt.Printf("%s[WARNING] Файл правил очистки %s не найден, используются встроенные правила%s\n", ColorYellow, path, ColorReset)
+		return ParseBoilerplate(defaults, "встроенные")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("чтение правил очистки %s: %w", path, err)
+	}
+	return ParseBoilerplate(raw, path)
+}
+
+// ParseBoilerplate разбирает и проверяет правила очистки в формате boilerplate.yaml; source - откуда они взяты, для ошибок
+func ParseBoilerplate(raw []byte, source string) (*Boilerplate, error) {
+	b := &Boilerplate{sites: make(map[string]*CompiledRules)}
+	var file boilerplateFile
+	if err := yaml.Unmarshal(raw, &file); err != nil {
+		return nil, fmt.Errorf("разбор правил очистки %s: %w", source, err)
+	}
+	var err error
+	if b.common, err = CompileRules(file.Common); err != nil {
+		return nil, fmt.Errorf("общие правила очистки: %w", err)
+	}
+	for site, rules := range file.Sites {
+		compiled, err := CompileRules(rules)
+		if err != nil {
+			return nil, fmt.Errorf("правила очистки %s: %w", site, err)
+		}
+		b.sites[siteHost(site)] = compiled
+	}
+	return b, nil
+}
+
+// Sites возвращает количество сайтов, для которых заданы собственные правила
+func (b *Boilerplate) Sites() int {
+	if b == nil {
+		return 0
+	}
+	return len(b.sites)
+}
+
+// Clean применяет к документу общие правила и правила сайта site (адрес или домен)
+func (b *Boilerplate) Clean(site string, doc *goquery.Document, stats CleaningStats) {
+	if b == nil {
+		return
+	}
+	b.common.Clean(doc, stats)
+	b.sites[siteHost(site)].Clean(doc, stats)
+}
+
+// RuleStats - сколько текста удалило одно правило очистки
+type RuleStats struct {
+	Rule      string
+	Fragments int // удалённые блоки и абзацы
+	Chars     int // символы удалённого текста без пробелов по краям
+	Pages     int // страницы, на которых правило сработало
+}
+
+// CleaningStats - статистика правил очистки по имени правила
+type CleaningStats map[string]*RuleStats
+
+func (s CleaningStats) add(rule, text string) {
+	if s == nil {
+		return
+	}
+	stat := s[rule]
+	if stat == nil {
+		stat = &RuleStats{Rule: rule, Pages: 1}
+		s[rule] = stat
+	}
+	stat.Fragments++
+	stat.Chars += utf8.RuneCountInString(strings.TrimSpace(text))
+}
+
+// Merge добавляет статистику страницы page
+func (s CleaningStats) Merge(page CleaningStats) {
+	for rule, stat := range page {
+		total := s[rule]
+		if total == nil {
+			total = &RuleStats{Rule: rule}
+			s[rule] = total
+		}
+		total.Fragments += stat.Fragments
+		total.Chars += stat.Chars
+		total.Pages += stat.Pages
+	}
+}
+
+// Sorted возвращает статистику правил по убыванию удалённого текста
+func (s CleaningStats) Sorted() []RuleStats {
+	sorted := make([]RuleStats, 0, len(s))
+	for _, stat := range s {
+		sorted = append(sorted, *stat)
+	}
+	slices.SortFunc(sorted, func(a, b RuleStats) int {
+		return cmp.Or(cmp.Compare(b.Chars, a.Chars), cmp.Compare(a.Rule, b.Rule))
+	})
+	return sorted
+}
+
+// Print выводит в консоль, сколько текста удалило каждое правило, с префиксом парсера
+func (s CleaningStats) Print(tag string) {
+	if len(s) == 0 {
+		return
+	}
+	fmt.Printf("%s[%s]%s[INFO] Правила очистки текста:%s\n", ColorBlue, tag, ColorYellow, ColorReset)
+	for idx, stat := range s.Sorted() {
+		fmt.Printf("%s  %d. %s: фрагментов %d, символов %d, страниц %d%s\n", ColorYellow, idx+1, stat.Rule, stat.Fragments, stat.Chars, stat.Pages, ColorReset)
+	}
+}
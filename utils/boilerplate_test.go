@@ -0,0 +1,190 @@
+package utils
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+const boilerplatePage = `<html><body>
+<div class="promo">Подпишитесь на рассылку</div>
+<div class="article">
+  <p>Первый абзац статьи.</p>
+  <div class="incut">Врезка <div class="incut">вложенная</div></div>
+  <p>Читайте также: другая статья</p>
+  <blockquote><p>Цитата. Материал дополняется</p></blockquote>
+  <p>Читайте нас в <a href="https://t.me/site">Telegram</a></p>
+  <p>Читайте нас в Telegram</p>
+  <p>Последний абзац статьи.</p>
+</div>
+</body></html>`
+
+func boilerplateDoc(t *testing.T) *goquery.Document {
+	t.Helper()
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(boilerplatePage))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return doc
+}
+
+func TestCompiledRulesClean(t *testing.T) {
+	rules, err := CompileRules(BoilerplateRules{
+		Scope:        "div.article",
+		Remove:       []string{".incut", ".promo"},
+		DropPrefixes: []string{"Читайте также:"},
+		DropContains: []string{"Материал дополняется"},
+		Promo:        []string{"^Читайте нас в"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	doc := boilerplateDoc(t)
+	stats := make(CleaningStats)
+	rules.Clean(doc, stats)
+
+	// .promo вне scope остаётся, абзац без ссылки не считается промо
+	want := []string{"Первый абзац статьи.", "Читайте нас в Telegram", "Последний абзац статьи."}
+	var got []string
+	doc.Find("div.article p").Each(func(_ int, s *goquery.Selection) {
+		got = append(got, strings.TrimSpace(s.Text()))
+	})
+	if strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Errorf("абзацы после очистки = %q, want %q", got, want)
+	}
+	if doc.Find(".promo").Length() != 1 {
+		t.Error("блок вне scope удалён")
+	}
+	// blockquote содержит абзац, поэтому правило срабатывает на вложенном p, а сама цитата остаётся
+	if doc.Find("blockquote").Length() != 1 {
+		t.Error("blockquote удалён вместо вложенного абзаца")
+	}
+
+	wantStats := map[string]RuleStats{
+		`remove ".incut"`:                 {Fragments: 1, Chars: len([]rune("Врезка вложенная")), Pages: 1},
+		`prefix "Читайте также:"`:         {Fragments: 1, Chars: len([]rune("Читайте также: другая статья")), Pages: 1},
+		`contains "Материал дополняется"`: {Fragments: 1, Chars: len([]rune("Цитата. Материал дополняется")), Pages: 1},
+		`promo "^Читайте нас в"`:          {Fragments: 1, Chars: len([]rune("Читайте нас в Telegram")), Pages: 1},
+	}
+	if len(stats) != len(wantStats) {
+		t.Errorf("сработало правил %d, want %d: %v", len(stats), len(wantStats), stats.Sorted())
+	}
+	for rule, want := range wantStats {
+		stat := stats[rule]
+		if stat == nil {
+			t.Errorf("нет статистики правила %s", rule)
+			continue
+		}
+		if stat.Fragments != want.Fragments || stat.Chars != want.Chars || stat.Pages != want.Pages {
+			t.Errorf("%s: фрагментов %d, символов %d, страниц %d; want %d, %d, %d",
+				rule, stat.Fragments, stat.Chars, stat.Pages, want.Fragments, want.Chars, want.Pages)
+		}
+	}
+}
+
+func TestCleaningStatsMerge(t *testing.T) {
+	total := make(CleaningStats)
+	for range 2 {
+		page := make(CleaningStats)
+		page.add("rule", "  абв  ")
+		page.add("rule", "где")
+		total.Merge(page)
+	}
+	stat := total["rule"]
+	if stat == nil || stat.Fragments != 4 || stat.Chars != 12 || stat.Pages != 2 {
+		t.Errorf("Merge = %+v, want фрагментов 4, символов 12, страниц 2", stat)
+	}
+}
+
+func TestCompileRulesErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		rules BoilerplateRules
+	}{
+		{"scope", BoilerplateRules{Scope: "div["}},
+		{"paragraphs", BoilerplateRules{Paragraphs: "p >"}},
+		{"remove", BoilerplateRules{Remove: []string{".ok", "::bad"}}},
+		{"drop_patterns", BoilerplateRules{DropPatterns: []string{"(незакрытая"}}},
+		{"promo", BoilerplateRules{Promo: []string{"[z-a]"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := CompileRules(tt.rules); err == nil {
+				t.Error("ожидалась ошибка")
+			}
+		})
+	}
+}
+
+func TestBoilerplateSites(t *testing.T) {
+	b, err := ParseBoilerplate([]byte(`
+common:
+  remove: [.promo]
+sites:
+  www.example.ru:
+    drop_prefixes: ["Читайте также:"]
+`), "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b.Sites() != 1 {
+		t.Errorf("Sites() = %d, want 1", b.Sites())
+	}
+
+	other := boilerplateDoc(t)
+	b.Clean("https://other.ru", other, nil)
+	if other.Find(".promo").Length() != 0 {
+		t.Error("общее правило не применено")
+	}
+	if !strings.Contains(other.Text(), "Читайте также:") {
+		t.Error("правило example.ru применено к другому сайту")
+	}
+
+	own := boilerplateDoc(t)
+	b.Clean("https://example.ru/news/1", own, nil)
+	if strings.Contains(own.Text(), "Читайте также:") {
+		t.Error("правило сайта не применено")
+	}
+}
+
+func TestLoadBoilerplate(t *testing.T) {
+	t.Chdir(t.TempDir())
+	defaults := []byte("sites:\n  example.ru:\n    remove: [.promo]\n")
+
+	b, err := LoadBoilerplate(DefaultBoilerplatePath, defaults)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b.Sites() != 1 {
+		t.Errorf("без файла по умолчанию: Sites() = %d, want 1 (встроенные правила)", b.Sites())
+	}
+
+	if _, err := LoadBoilerplate(filepath.Join("missing", "rules.yaml"), defaults); err == nil {
+		t.Error("отсутствие явно заданного файла должно быть ошибкой")
+	}
+
+	if err := os.WriteFile(DefaultBoilerplatePath, []byte("common:\n  drop_patterns: ['(']\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadBoilerplate(DefaultBoilerplatePath, defaults); err == nil {
+		t.Error("неверное регулярное выражение в файле должно быть ошибкой")
+	}
+}
+
+// Файл правил из репозитория встраивается в бинарник, поэтому ошибка в нём должна находиться до сборки
+func TestDefaultBoilerplateFile(t *testing.T) {
+	raw, err := os.ReadFile(filepath.Join("..", DefaultBoilerplatePath))
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := ParseBoilerplate(raw, DefaultBoilerplatePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b.Sites() == 0 {
+		t.Error("в boilerplate.yaml нет правил сайтов")
+	}
+}
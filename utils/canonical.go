@@ -0,0 +1,45 @@
+// utils/canonical.go
+package utils
+
+import (
+	"net/url"
+	"strings"
+)
+
+// trackingParams - параметры запроса, которые не меняют статью и добавляются счётчиками и рассылками
+var trackingParams = map[string]bool{
+	"fbclid":    true,
+	"gclid":     true,
+	"yclid":     true,
+	"_openstat": true,
+}
+
+// CanonicalURL приводит ссылку на статью к виду, по которому статья опознаётся между запусками:
+// схема и хост в нижнем регистре, без порта по умолчанию, якоря и меток utm_* и других счётчиков.
+// Путь не меняется, поэтому чистые ссылки парсеров остаются как есть.
+func CanonicalURL(href string) string {
+	href = strings.TrimSpace(href)
+	u, err := url.Parse(href)
+	if err != nil || u.Host == "" {
+		return href
+	}
+
+	u.Scheme = strings.ToLower(u.Scheme)
+	u.Host = strings.ToLower(u.Host)
+	if port := u.Port(); (u.Scheme == "https" && port == "443") || (u.Scheme == "http" && port == "80") {
+		u.Host = u.Hostname()
+	}
+	u.Fragment = ""
+	u.RawFragment = ""
+
+	if u.RawQuery != "" {
+		query := u.Query()
+		for key := range query {
+			if trackingParams[strings.ToLower(key)] || strings.HasPrefix(strings.ToLower(key), "utm_") {
+				query.Del(key)
+			}
+		}
+		u.RawQuery = query.Encode()
+	}
+	return u.String()
+}
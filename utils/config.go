@@ -0,0 +1,499 @@
+// utils/config.go
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"maps"
+	"net/url"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// DefaultConfigPath - файл конфигурации, который читается, если путь не указан явно
+const DefaultConfigPath = "config.yaml"
+
+// envPrefix - префикс переменных окружения, переопределяющих конфигурацию
+const envPrefix = "PARSING_MEDIA_"
+
+// Config - настройки запуска: подключение к БД, интервалы, количество потоков и таймауты
+type Config struct {
+	Storage  StorageConfig  `yaml:"storage"`
+	Database DatabaseConfig `yaml:"database"`
+	Loop     LoopConfig     `yaml:"loop"`
+	HTTP     HTTPConfig     `yaml:"http"`
+	Parsers  ParsersConfig  `yaml:"parsers"`
+	Recrawl  RecrawlConfig  `yaml:"recrawl"`
+}
+
+// StorageConfig - выбор хранилища статей
+type StorageConfig struct {
+	Backend      string `yaml:"backend"`       // postgres, sqlite, jsonl или memory
+	SQLitePath   string `yaml:"sqlite_path"`   // файл базы для backend: sqlite
+	JSONLPath    string `yaml:"jsonl_path"`    // файл для backend: jsonl
+	Migrate      string `yaml:"migrate"`       // миграции SQL-хранилищ при запуске: auto (применить), verify (только проверить), off
+	TaxonomyFile string `yaml:"taxonomy_file"` // соответствие тегов сайтов общим категориям (см. LoadTaxonomy)
+}
+
+// DatabaseConfig - подключение к Postgres
+type DatabaseConfig struct {
+	DSN             string        `yaml:"dsn"`
+	Password        string        `yaml:"password"` // добавляется к DSN, чтобы не хранить пароль в строке подключения
+	MaxOpenConns    int           `yaml:"max_open_conns"`
+	MaxIdleConns    int           `yaml:"max_idle_conns"`
+	ConnMaxLifetime time.Duration `yaml:"conn_max_lifetime"`
+	BulkThreshold   int           `yaml:"bulk_threshold"` // с какого размера пачки сохранять через COPY (0 - всегда построчно)
+}
+
+// LoopConfig - циклический режим
+type LoopConfig struct {
+	Interval   time.Duration     `yaml:"interval"`    // расписание сайтов, для которых не задано своё
+	Schedules  map[string]string `yaml:"schedules"`   // интервал или cron-выражение по имени сайта
+	Adaptive   AdaptiveConfig    `yaml:"adaptive"`    // подстройка интервальных расписаний под частоту публикаций
+	StatusFile string            `yaml:"status_file"` // JSON с текущими интервалами и временем запусков (пусто - не писать)
+}
+
+// HTTPConfig - параметры HTTP-клиента парсеров
+type HTTPConfig struct {
+	Timeout time.Duration `yaml:"timeout"`
+}
+
+// ParsersConfig - общие параметры парсеров
+type ParsersConfig struct {
+	Timeout         time.Duration     `yaml:"timeout"`          // предельное время одного запуска парсера
+	DefaultWorkers  int               `yaml:"default_workers"`  // 0 - у каждого сайта своё значение по умолчанию
+	Workers         map[string]int    `yaml:"workers"`          // количество потоков по имени сайта
+	SitesDir        string            `yaml:"sites_dir"`        // каталог декларативных определений сайтов
+	KnownCacheSize  int               `yaml:"known_cache_size"` // размер LRU-кеша ссылок на сохранённые статьи
+	ForceRefetch    bool              `yaml:"force_refetch"`    // загружать статьи, даже если они уже есть в БД
+	KeepRichBody    bool              `yaml:"keep_rich_body"`   // сохранять исходный текст с разметкой рядом с обычным
+	BoilerplateFile string            `yaml:"boilerplate_file"` // правила удаления служебных блоков по сайтам (см. LoadBoilerplate)
+	LinkSources     map[string]string `yaml:"link_sources"`     // откуда брать ссылки по имени сайта (см. ParseLinkSources)
+	SitemapMaxAge   time.Duration     `yaml:"sitemap_max_age"`  // статьи из карт сайта старше этого срока пропускаются
+}
+
+// LinkSources - источники ссылок на статьи сайта (parsers.link_sources). Найденные ссылки объединяются.
+type LinkSources struct {
+	HTML    bool // страница-список сайта
+	Feed    bool // RSS/Atom-ленты
+	Sitemap bool // карты сайта из robots.txt или определения
+}
+
+// ParseLinkSources разбирает список источников через запятую: "html", "feed", "sitemap" или их сочетание,
+// например "html,sitemap". "both" - прежнее обозначение "html,feed".
+func ParseLinkSources(value string) (LinkSources, error) {
+	var sources LinkSources
+	for _, part := range strings.Split(value, ",") {
+		switch strings.ToLower(strings.TrimSpace(part)) {
+		case "html":
+			sources.HTML = true
+		case "feed":
+			sources.Feed = true
+		case "sitemap":
+			sources.Sitemap = true
+		case "both":
+			sources.HTML, sources.Feed = true, true
+		case "":
+		default:
+			return LinkSources{}, fmt.Errorf("неизвестный источник '%s' (html, feed, sitemap, both)", strings.TrimSpace(part))
+		}
+	}
+	if sources == (LinkSources{}) {
+		return LinkSources{}, fmt.Errorf("не указан ни один источник")
+	}
+	return sources, nil
+}
+
+// DefaultConfig возвращает настройки, с которыми программа работала до появления файла конфигурации
+func DefaultConfig() *Config {
+	return &Config{
+		Storage: StorageConfig{
+			Backend:      "postgres",
+			SQLitePath:   "parsing_media.db",
+			JSONLPath:    "articles.jsonl",
+			Migrate:      "auto",
+			TaxonomyFile: DefaultTaxonomyPath,
+		},
+		Database: DatabaseConfig{
+			DSN:             DefaultDSN,
+			MaxOpenConns:    25,
+			MaxIdleConns:    5,
+			ConnMaxLifetime: 5 * time.Minute,
+			BulkThreshold:   100,
+		},
+		Loop: LoopConfig{
+			Interval: 3 * time.Minute,
+			Adaptive: AdaptiveConfig{
+				MinInterval:      time.Minute,
+				MaxInterval:      30 * time.Minute,
+				SpeedupThreshold: 10,
+				BackoffFactor:    1.5,
+			},
+		},
+		HTTP:    HTTPConfig{Timeout: 30 * time.Second},
+		Parsers: ParsersConfig{Timeout: 3 * time.Minute, SitesDir: "sites", KnownCacheSize: 50000, BoilerplateFile: DefaultBoilerplatePath, SitemapMaxAge: 48 * time.Hour},
+		Recrawl: RecrawlConfig{
+			Offsets:  []time.Duration{15 * time.Minute, time.Hour, 6 * time.Hour, 24 * time.Hour},
+			Interval: 5 * time.Minute,
+			Workers:  4,
+		},
+	}
+}
+
+// RecrawlConfig - повторный обход недавно опубликованных статей: правки и удаления
+type RecrawlConfig struct {
+	Enabled  bool            `yaml:"enabled"`  // запускать повторный обход в циклическом режиме
+	Offsets  []time.Duration `yaml:"offsets"`  // через сколько после публикации проверять статью
+	Interval time.Duration   `yaml:"interval"` // как часто искать статьи, для которых подошёл срок проверки
+	Workers  int             `yaml:"workers"`
+}
+
+// MaxOffset возвращает последний срок проверки статьи после публикации
+func (c RecrawlConfig) MaxOffset() time.Duration {
+	var maxOffset time.Duration
+	for _, offset := range c.Offsets {
+		maxOffset = max(maxOffset, offset)
+	}
+	return maxOffset
+}
+
+// LoadConfig собирает конфигурацию: значения по умолчанию, затем файл path, затем переменные окружения.
+// Пустой path означает DefaultConfigPath; его отсутствие не является ошибкой, в отличие от явно указанного файла.
+func LoadConfig(path string) (*Config, error) {
+	cfg := DefaultConfig()
+
+	explicit := path != ""
+	if !explicit {
+		path = DefaultConfigPath
+	}
+
+	raw, err := os.ReadFile(path)
+	switch {
+	case err == nil:
+		if err := yaml.Unmarshal(raw, cfg); err != nil {
+			return nil, fmt.Errorf("разбор файла конфигурации %s: %w", path, err)
+		}
+		if err := cfg.normalizeSiteKeys(); err != nil {
+			return nil, fmt.Errorf("файл конфигурации %s: %w", path, err)
+		}
+	case explicit || !errors.Is(err, fs.ErrNotExist):
+		return nil, fmt.Errorf("чтение файла конфигурации %s: %w", path, err)
+	}
+
+	if err := cfg.applyEnv(os.Environ()); err != nil {
+		return nil, err
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// siteKey - ключ сайта в loop.schedules, parsers.workers и parsers.link_sources: имя сайта в верхнем регистре,
+// как в переменных окружения (RBC, DUMATV)
+func siteKey(name string) string {
+	return strings.ToUpper(strings.TrimSpace(name))
+}
+
+// normalizeSiteKeys приводит ключи настроек по сайтам к siteKey, чтобы WorkersFor, LinkSourcesFor и ScheduleFor
+// искали сайт в карте напрямую. Два ключа одного сайта в разном регистре - ошибка: какой из них выбрать, неизвестно.
+func (c *Config) normalizeSiteKeys() error {
+	var err error
+	if c.Loop.Schedules, err = normalizeKeys("loop.schedules", c.Loop.Schedules); err != nil {
+		return err
+	}
+	if c.Parsers.Workers, err = normalizeKeys("parsers.workers", c.Parsers.Workers); err != nil {
+		return err
+	}
+	c.Parsers.LinkSources, err = normalizeKeys("parsers.link_sources", c.Parsers.LinkSources)
+	return err
+}
+
+// normalizeKeys возвращает копию values с ключами siteKey; section - раздел конфигурации для сообщения об ошибке
+func normalizeKeys[V any](section string, values map[string]V) (map[string]V, error) {
+	if values == nil {
+		return nil, nil
+	}
+	normalized := make(map[string]V, len(values))
+	for _, site := range slices.Sorted(maps.Keys(values)) {
+		key := siteKey(site)
+		if _, duplicate := normalized[key]; duplicate {
+			return nil, fmt.Errorf("%s: сайт %s указан несколько раз в разном регистре", section, key)
+		}
+		normalized[key] = values[site]
+	}
+	return normalized, nil
+}
+
+// applyEnv переопределяет настройки переменными окружения PARSING_MEDIA_*.
+// Количество потоков отдельного сайта задаётся как PARSING_MEDIA_WORKERS_<ИМЯ>, например PARSING_MEDIA_WORKERS_RBC=5,
+// расписание - как PARSING_MEDIA_SCHEDULE_<ИМЯ>, например PARSING_MEDIA_SCHEDULE_RIA=1m.
+func (c *Config) applyEnv(environ []string) error {
+	for _, kv := range environ {
+		key, value, found := strings.Cut(kv, "=")
+		if !found || !strings.HasPrefix(key, envPrefix) {
+			continue
+		}
+		name := strings.TrimPrefix(key, envPrefix)
+
+		var err error
+		switch name {
+		case "CONFIG":
+			// путь к файлу обрабатывается вызывающим кодом
+		case "STORAGE":
+			c.Storage.Backend = value
+		case "MIGRATE":
+			c.Storage.Migrate = value
+		case "SQLITE_PATH":
+			c.Storage.SQLitePath = value
+		case "JSONL_PATH":
+			c.Storage.JSONLPath = value
+		case "TAXONOMY_FILE":
+			c.Storage.TaxonomyFile = value
+		case "DSN":
+			c.Database.DSN = value
+		case "DB_PASSWORD":
+			c.Database.Password = value
+		case "DB_MAX_OPEN_CONNS":
+			c.Database.MaxOpenConns, err = strconv.Atoi(value)
+		case "DB_MAX_IDLE_CONNS":
+			c.Database.MaxIdleConns, err = strconv.Atoi(value)
+		case "DB_CONN_MAX_LIFETIME":
+			c.Database.ConnMaxLifetime, err = time.ParseDuration(value)
+		case "DB_BULK_THRESHOLD":
+			c.Database.BulkThreshold, err = strconv.Atoi(value)
+		case "LOOP_INTERVAL":
+			c.Loop.Interval, err = time.ParseDuration(value)
+		case "ADAPTIVE":
+			c.Loop.Adaptive.Enabled, err = strconv.ParseBool(value)
+		case "ADAPTIVE_MIN_INTERVAL":
+			c.Loop.Adaptive.MinInterval, err = time.ParseDuration(value)
+		case "ADAPTIVE_MAX_INTERVAL":
+			c.Loop.Adaptive.MaxInterval, err = time.ParseDuration(value)
+		case "STATUS_FILE":
+			c.Loop.StatusFile = value
+		case "HTTP_TIMEOUT":
+			c.HTTP.Timeout, err = time.ParseDuration(value)
+		case "PARSER_TIMEOUT":
+			c.Parsers.Timeout, err = time.ParseDuration(value)
+		case "WORKERS":
+			c.Parsers.DefaultWorkers, err = strconv.Atoi(value)
+		case "SITES_DIR":
+			c.Parsers.SitesDir = value
+		case "KNOWN_CACHE_SIZE":
+			c.Parsers.KnownCacheSize, err = strconv.Atoi(value)
+		case "FORCE":
+			c.Parsers.ForceRefetch, err = strconv.ParseBool(value)
+		case "KEEP_RICH_BODY":
+			c.Parsers.KeepRichBody, err = strconv.ParseBool(value)
+		case "BOILERPLATE_FILE":
+			c.Parsers.BoilerplateFile = value
+		case "SITEMAP_MAX_AGE":
+			c.Parsers.SitemapMaxAge, err = time.ParseDuration(value)
+		case "RECRAWL":
+			c.Recrawl.Enabled, err = strconv.ParseBool(value)
+		case "RECRAWL_OFFSETS":
+			c.Recrawl.Offsets, err = parseDurationList(value)
+		case "RECRAWL_INTERVAL":
+			c.Recrawl.Interval, err = time.ParseDuration(value)
+		case "RECRAWL_WORKERS":
+			c.Recrawl.Workers, err = strconv.Atoi(value)
+		default:
+			if site, isSchedule := strings.CutPrefix(name, "SCHEDULE_"); isSchedule {
+				if c.Loop.Schedules == nil {
+					c.Loop.Schedules = make(map[string]string)
+				}
+				c.Loop.Schedules[siteKey(site)] = value
+				continue
+			}
+			if site, isLinks := strings.CutPrefix(name, "LINKS_"); isLinks {
+				if c.Parsers.LinkSources == nil {
+					c.Parsers.LinkSources = make(map[string]string)
+				}
+				c.Parsers.LinkSources[siteKey(site)] = value
+				continue
+			}
+			site, isWorkers := strings.CutPrefix(name, "WORKERS_")
+			if !isWorkers {
+				continue
+			}
+			var workers int
+			workers, err = strconv.Atoi(value)
+			if err == nil {
+				if c.Parsers.Workers == nil {
+					c.Parsers.Workers = make(map[string]int)
+				}
+				c.Parsers.Workers[siteKey(site)] = workers
+			}
+		}
+		if err != nil {
+			return fmt.Errorf("переменная окружения %s='%s': %w", key, value, err)
+		}
+	}
+	return nil
+}
+
+// Validate проверяет, что значения конфигурации имеют смысл
+func (c *Config) Validate() error {
+	var problems []string
+	switch c.Storage.Backend {
+	case "postgres":
+		if strings.TrimSpace(c.Database.DSN) == "" {
+			problems = append(problems, "database.dsn пуст")
+		}
+	case "sqlite":
+		if c.Storage.SQLitePath == "" {
+			problems = append(problems, "storage.sqlite_path пуст")
+		}
+	case "jsonl":
+		if c.Storage.JSONLPath == "" {
+			problems = append(problems, "storage.jsonl_path пуст")
+		}
+	case "memory":
+	default:
+		problems = append(problems, fmt.Sprintf("storage.backend: неизвестное хранилище '%s' (postgres, sqlite, jsonl, memory)", c.Storage.Backend))
+	}
+	if c.Database.BulkThreshold < 0 {
+		problems = append(problems, "database.bulk_threshold не может быть отрицательным")
+	}
+	switch c.Storage.Migrate {
+	case "auto", "verify", "off":
+	default:
+		problems = append(problems, fmt.Sprintf("storage.migrate: неизвестный режим '%s' (auto, verify, off)", c.Storage.Migrate))
+	}
+	if c.Loop.Interval <= 0 {
+		problems = append(problems, "loop.interval должен быть положительным")
+	}
+	for _, site := range slices.Sorted(maps.Keys(c.Loop.Schedules)) {
+		if _, err := ParseSchedule(c.Loop.Schedules[site]); err != nil {
+			problems = append(problems, fmt.Sprintf("loop.schedules.%s: %v", site, err))
+		}
+	}
+	if a := c.Loop.Adaptive; a.Enabled {
+		if a.MinInterval <= 0 || a.MaxInterval < a.MinInterval {
+			problems = append(problems, "loop.adaptive: нужно 0 < min_interval <= max_interval")
+		}
+		if a.BackoffFactor < 1 {
+			problems = append(problems, "loop.adaptive.backoff_factor должен быть не меньше 1")
+		}
+	}
+	if c.HTTP.Timeout <= 0 {
+		problems = append(problems, "http.timeout должен быть положительным")
+	}
+	if c.Parsers.Timeout <= 0 {
+		problems = append(problems, "parsers.timeout должен быть положительным")
+	}
+	if c.Parsers.KnownCacheSize < 1 {
+		problems = append(problems, "parsers.known_cache_size должен быть не меньше 1")
+	}
+	if c.Parsers.DefaultWorkers < 0 {
+		problems = append(problems, "parsers.default_workers не может быть отрицательным")
+	}
+	for _, site := range slices.Sorted(maps.Keys(c.Parsers.Workers)) {
+		if c.Parsers.Workers[site] < 1 {
+			problems = append(problems, fmt.Sprintf("parsers.workers.%s должен быть не меньше 1", site))
+		}
+	}
+	for _, site := range slices.Sorted(maps.Keys(c.Parsers.LinkSources)) {
+		if _, err := ParseLinkSources(c.Parsers.LinkSources[site]); err != nil {
+			problems = append(problems, fmt.Sprintf("parsers.link_sources.%s: %v", site, err))
+		}
+	}
+	if c.Parsers.SitemapMaxAge <= 0 {
+		problems = append(problems, "parsers.sitemap_max_age должен быть положительным")
+	}
+	for _, offset := range c.Recrawl.Offsets {
+		if offset <= 0 {
+			problems = append(problems, "recrawl.offsets должны быть положительными")
+			break
+		}
+	}
+	if c.Recrawl.Interval <= 0 {
+		problems = append(problems, "recrawl.interval должен быть положительным")
+	}
+	if c.Recrawl.Workers < 1 {
+		problems = append(problems, "recrawl.workers должен быть не меньше 1")
+	}
+	if len(problems) > 0 {
+		return fmt.Errorf("некорректная конфигурация: %s", strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+// parseDurationList разбирает список длительностей через запятую: "15m,1h,6h"
+func parseDurationList(value string) ([]time.Duration, error) {
+	var durations []time.Duration
+	for _, part := range strings.Split(value, ",") {
+		if part = strings.TrimSpace(part); part == "" {
+			continue
+		}
+		d, err := time.ParseDuration(part)
+		if err != nil {
+			return nil, err
+		}
+		durations = append(durations, d)
+	}
+	return durations, nil
+}
+
+// WorkersFor возвращает количество потоков для сайта: явное значение сайта, общее значение или fallback
+func (c *Config) WorkersFor(name string, fallback int) int {
+	if workers, ok := c.Parsers.Workers[siteKey(name)]; ok {
+		return workers
+	}
+	if c.Parsers.DefaultWorkers > 0 {
+		return c.Parsers.DefaultWorkers
+	}
+	return fallback
+}
+
+// LinkSourcesFor возвращает источники ссылок сайта из parsers.link_sources.
+// Нулевое значение - сайт не настроен, и парсер выбирает источники по умолчанию.
+func (c *Config) LinkSourcesFor(name string) LinkSources {
+	sources, _ := ParseLinkSources(c.Parsers.LinkSources[siteKey(name)])
+	return sources
+}
+
+// ScheduleFor возвращает расписание сайта: собственное из loop.schedules или общий интервал loop.interval.
+// При включённом loop.adaptive интервальные расписания становятся адаптивными, cron-выражения остаются как есть.
+func (c *Config) ScheduleFor(name string) (Schedule, error) {
+	spec, ok := c.Loop.Schedules[siteKey(name)]
+	if !ok {
+		spec = c.Loop.Interval.String()
+	}
+
+	schedule, err := ParseSchedule(spec)
+	if err != nil {
+		return nil, err
+	}
+	if interval, ok := schedule.(intervalSchedule); ok && c.Loop.Adaptive.Enabled {
+		return NewAdaptiveSchedule(interval.every, c.Loop.Adaptive), nil
+	}
+	return schedule, nil
+}
+
+// ConnectionString возвращает DSN с подставленным паролем (для формата key=value и для URL postgres://)
+func (c DatabaseConfig) ConnectionString() string {
+	if c.Password == "" {
+		return c.DSN
+	}
+	if strings.HasPrefix(c.DSN, "postgres://") || strings.HasPrefix(c.DSN, "postgresql://") {
+		u, err := url.Parse(c.DSN)
+		if err == nil {
+			username := ""
+			if u.User != nil {
+				username = u.User.Username()
+			}
+			u.User = url.UserPassword(username, c.Password)
+			return u.String()
+		}
+	}
+	escaped := strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(c.Password)
+	return c.DSN + " password='" + escaped + "'"
+}
@@ -0,0 +1,78 @@
+package utils
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// writeConfig записывает файл конфигурации во временный каталог и возвращает путь к нему
+func writeConfig(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadConfigSiteKeys(t *testing.T) {
+	path := writeConfig(t, `
+loop:
+  schedules:
+    DumaTV: 30m
+    ria: 1m
+parsers:
+  workers:
+    Rbc: 5
+    kp: 2
+  link_sources:
+    Lenta: html,sitemap
+`)
+	t.Setenv("PARSING_MEDIA_WORKERS_KP", "7")
+	t.Setenv("PARSING_MEDIA_LINKS_lenta", "feed")
+	t.Setenv("PARSING_MEDIA_SCHEDULE_Interfax", "2m")
+
+	cfg, err := LoadConfig(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for name, want := range map[string]int{"RBC": 5, "rbc": 5, "Kp": 7, "Lenta": 3} {
+		if got := cfg.WorkersFor(name, 3); got != want {
+			t.Errorf("WorkersFor(%q) = %d, want %d", name, got, want)
+		}
+	}
+	if got := cfg.LinkSourcesFor("LENTA"); got != (LinkSources{Feed: true}) {
+		t.Errorf("LinkSourcesFor(LENTA) = %+v, want только feed из окружения", got)
+	}
+	if got := cfg.LinkSourcesFor("rbc"); got != (LinkSources{}) {
+		t.Errorf("LinkSourcesFor(rbc) = %+v, want нулевое значение", got)
+	}
+
+	now := time.Date(2026, time.March, 10, 12, 0, 0, 0, time.UTC)
+	for name, want := range map[string]time.Duration{"dumatv": 30 * time.Minute, "RIA": time.Minute, "interfax": 2 * time.Minute, "Lenta": 3 * time.Minute} {
+		schedule, err := cfg.ScheduleFor(name)
+		if err != nil {
+			t.Fatalf("ScheduleFor(%q): %v", name, err)
+		}
+		if got := schedule.Next(now).Sub(now); got != want {
+			t.Errorf("ScheduleFor(%q): следующий запуск через %s, want %s", name, got, want)
+		}
+	}
+}
+
+func TestLoadConfigDuplicateSiteKeys(t *testing.T) {
+	path := writeConfig(t, `
+parsers:
+  workers:
+    RBC: 5
+    rbc: 2
+`)
+	_, err := LoadConfig(path)
+	if err == nil || !strings.Contains(err.Error(), "parsers.workers") {
+		t.Errorf("LoadConfig = %v, want ошибку о повторе сайта в parsers.workers", err)
+	}
+}
@@ -0,0 +1,192 @@
+// utils/crawl.go
+package utils
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// PageResult - результат разбора одной страницы
+type PageResult struct {
+	Data     Data
+	Error    error
+	PageURL  string
+	IsEmpty  bool
+	Reasons  []string
+	Cleaning CleaningStats // сколько текста удалили правила очистки (см. Boilerplate)
+}
+
+// CrawlOptions задаёт параметры пула обработчиков
+type CrawlOptions struct {
+	Workers  int // количество параллельных обработчиков (минимум 1)
+	MaxPages int // ограничение на количество обрабатываемых страниц (0 - без ограничения)
+}
+
+// CrawlReport - итог работы пула: собранные статьи, неудачные страницы и статистика
+type CrawlReport struct {
+	Data      []Data
+	Failed    []PageResult
+	Total     int
+	Skipped   int
+	Panics    int
+	Cancelled int           // страницы, до которых не дошла очередь из-за отмены ctx
+	Cleaning  CleaningStats // статистика правил очистки по всем обработанным страницам
+	Elapsed   time.Duration
+}
+
+// Crawl параллельно обрабатывает список URL функцией extract и собирает результаты.
+// Паника внутри extract перехватывается и превращается в ошибку страницы, не роняя остальные обработчики.
+// После отмены ctx оставшиеся в очереди страницы не обрабатываются и учитываются в Cancelled.
+func Crawl(ctx context.Context, urls []string, opts CrawlOptions, extract func(ctx context.Context, pageURL string) PageResult) CrawlReport {
+	startTime := time.Now()
+	report := CrawlReport{Total: len(urls), Cleaning: make(CleaningStats)}
+
+	if opts.MaxPages > 0 && len(urls) > opts.MaxPages {
+		report.Skipped = len(urls) - opts.MaxPages
+		urls = urls[:opts.MaxPages]
+	}
+
+	totalLinks := len(urls)
+	if totalLinks == 0 {
+		report.Elapsed = time.Since(startTime)
+		return report
+	}
+
+	resultsChan := make(chan PageResult, totalLinks)
+	var cancelledMu sync.Mutex
+	linkChan := make(chan string, totalLinks)
+	for _, link := range urls {
+		linkChan <- link
+	}
+	close(linkChan)
+
+	actualNumWorkers := opts.Workers
+	if actualNumWorkers < 1 {
+		actualNumWorkers = 1
+	}
+	if totalLinks < actualNumWorkers {
+		actualNumWorkers = totalLinks
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < actualNumWorkers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for pageURL := range linkChan {
+				if ctx.Err() != nil {
+					cancelledMu.Lock()
+					report.Cancelled++
+					cancelledMu.Unlock()
+					continue
+				}
+				resultsChan <- safeExtract(ctx, pageURL, extract)
+			}
+		}()
+	}
+
+	go func() {
+		wg.Wait()
+		close(resultsChan)
+	}()
+
+	for result := range resultsChan {
+		report.Cleaning.Merge(result.Cleaning)
+		if result.Error != nil || result.IsEmpty {
+			if errors.Is(result.Error, ErrPagePanic) {
+				report.Panics++
+			}
+			report.Failed = append(report.Failed, result)
+		} else {
+			report.Data = append(report.Data, result.Data)
+		}
+	}
+
+	report.Elapsed = time.Since(startTime)
+	return report
+}
+
+// ErrPagePanic оборачивает панику, перехваченную при обработке страницы
+var ErrPagePanic = errors.New("паника при обработке страницы")
+
+func safeExtract(ctx context.Context, pageURL string, extract func(ctx context.Context, pageURL string) PageResult) (result PageResult) {
+	defer func() {
+		if r := recover(); r != nil {
+			result = PageResult{PageURL: pageURL, Error: fmt.Errorf("%w: %v", ErrPagePanic, r)}
+		}
+	}()
+	result = extract(ctx, pageURL)
+	if result.PageURL == "" {
+		result.PageURL = pageURL
+	}
+	return result
+}
+
+// FailureMessages форматирует неудачные страницы в строки для вывода
+func (r CrawlReport) FailureMessages() []string {
+	var errItems []string
+	for _, failed := range r.Failed {
+		if failed.Error != nil {
+			errItems = append(errItems, fmt.Sprintf("%s (%s)", failed.PageURL, failed.Error.Error()))
+		} else {
+			errItems = append(errItems, fmt.Sprintf("%s (нет данных: %s)", failed.PageURL, strings.Join(failed.Reasons, ", ")))
+		}
+	}
+	return errItems
+}
+
+// BodyFallbacks возвращает ссылки статей, текст которых не нашли селекторы сайта и извлёк ExtractMainText
+func (r CrawlReport) BodyFallbacks() []string {
+	var hrefs []string
+	for _, item := range r.Data {
+		if item.BodyFallback {
+			hrefs = append(hrefs, item.Href)
+		}
+	}
+	return hrefs
+}
+
+// PrintSummary выводит в консоль список неудачных страниц с префиксом парсера
+func (r CrawlReport) PrintSummary(tag, name string) {
+	errItems := r.FailureMessages()
+	processed := r.Total - r.Skipped - r.Cancelled
+
+	if len(r.Data) > 0 {
+		if len(errItems) > 0 {
+			fmt.Printf("%s[%s]%s[WARNING] Не удалось обработать %d из %d страниц (или отсутствовали данные):%s\n", ColorBlue, tag, ColorYellow, len(errItems), processed, ColorReset)
+			for idx, itemMessage := range errItems {
+				fmt.Printf("%s  %d. %s%s\n", ColorYellow, idx+1, itemMessage, ColorReset)
+			}
+		}
+	} else if processed > 0 {
+		fmt.Printf("%s[%s]%s[ERROR] Парсинг статей %s завершен, но не удалось собрать данные ни с одной из %d страниц.%s\n", ColorBlue, tag, ColorRed, name, processed, ColorReset)
+		if len(errItems) > 0 {
+			fmt.Printf("%s[%s]%s[INFO] Список страниц с ошибками или без данных:%s\n", ColorBlue, tag, ColorYellow, ColorReset)
+			for idx, itemMessage := range errItems {
+				fmt.Printf("%s  %d. %s%s\n", ColorYellow, idx+1, itemMessage, ColorReset)
+			}
+		}
+	}
+
+	if fallback := r.BodyFallbacks(); len(fallback) > 0 {
+		// Селекторы текста сайта перестали срабатывать - вероятно, сменилась вёрстка
+		fmt.Printf("%s[%s]%s[WARNING] Текст %d из %d статей извлечён резервным алгоритмом:%s\n", ColorBlue, tag, ColorYellow, len(fallback), len(r.Data), ColorReset)
+		for idx, href := range fallback {
+			fmt.Printf("%s  %d. %s%s\n", ColorYellow, idx+1, href, ColorReset)
+		}
+	}
+	r.Cleaning.Print(tag)
+	if r.Panics > 0 {
+		fmt.Printf("%s[%s]%s[ERROR] Перехвачено паник при разборе страниц: %d%s\n", ColorBlue, tag, ColorRed, r.Panics, ColorReset)
+	}
+	if r.Cancelled > 0 {
+		fmt.Printf("%s[%s]%s[INFO] Не обработано страниц из-за остановки: %d%s\n", ColorBlue, tag, ColorYellow, r.Cancelled, ColorReset)
+	}
+	if r.Skipped > 0 {
+		fmt.Printf("%s[%s]%s[INFO] Пропущено страниц сверх лимита: %d%s\n", ColorBlue, tag, ColorYellow, r.Skipped, ColorReset)
+	}
+}
@@ -0,0 +1,214 @@
+// utils/feed.go
+package utils
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"parsing_media/rudate"
+	"slices"
+	"strings"
+	"time"
+
+	"golang.org/x/net/html/charset"
+)
+
+// FeedItem - запись RSS- или Atom-ленты: ссылка на статью и то, что лента сообщает о ней
+type FeedItem struct {
+	Title      string
+	Link       string
+	Published  time.Time
+	Categories []string
+}
+
+// feedDocument покрывает RSS 2.0 (rss/channel/item), RSS 1.0 (rdf:RDF/item) и Atom (feed/entry)
+type feedDocument struct {
+	XMLName xml.Name
+	Channel struct {
+		Items []rssItem `xml:"item"`
+	} `xml:"channel"`
+	Items   []rssItem   `xml:"item"`
+	Entries []atomEntry `xml:"entry"`
+}
+
+type rssItem struct {
+	Title      string   `xml:"title"`
+	Link       string   `xml:"link"`
+	GUID       string   `xml:"guid"`
+	PubDate    string   `xml:"pubDate"`
+	DCDate     string   `xml:"http://purl.org/dc/elements/1.1/ date"`
+	Categories []string `xml:"category"`
+}
+
+type atomEntry struct {
+	Title string `xml:"title"`
+	Links []struct {
+		Href string `xml:"href,attr"`
+		Rel  string `xml:"rel,attr"`
+	} `xml:"link"`
+	Published  string `xml:"published"`
+	Updated    string `xml:"updated"`
+	Categories []struct {
+		Term  string `xml:"term,attr"`
+		Label string `xml:"label,attr"`
+	} `xml:"category"`
+}
+
+// rssDateLayouts - форматы pubDate: RFC 822 с четырёхзначным годом, с днём недели и без, с числовым поясом и с названием
+var rssDateLayouts = []string{
+	time.RFC1123Z,
+	time.RFC1123,
+	"Mon, 2 Jan 2006 15:04:05 -0700",
+	"Mon, 2 Jan 2006 15:04:05 MST",
+	"2 Jan 2006 15:04:05 -0700",
+	"2 Jan 2006 15:04:05 MST",
+	time.RFC822Z,
+	time.RFC822,
+}
+
+// parseFeedDate разбирает дату записи ленты: RFC 822 у RSS, ISO 8601 у Atom и dc:date.
+// Названия поясов ищутся в часовом поясе Москвы: time.Parse не знает "MSK" и считал бы его смещением 0.
+func parseFeedDate(value string) time.Time {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return time.Time{}
+	}
+	for _, layout := range rssDateLayouts {
+		if t, err := time.ParseInLocation(layout, value, rudate.Moscow); err == nil {
+			return t.In(rudate.Moscow)
+		}
+	}
+	t, _ := rudate.ParseISO(value)
+	return t
+}
+
+// ParseFeed разбирает RSS- или Atom-ленту. Записи без ссылки пропускаются.
+// Кодировка берётся из XML-декларации, поэтому ленты в windows-1251 читаются без перекодировки заранее.
+func ParseFeed(raw []byte) ([]FeedItem, error) {
+	decoder := xml.NewDecoder(bytes.NewReader(raw))
+	decoder.CharsetReader = charset.NewReaderLabel
+	decoder.Strict = false
+
+	var document feedDocument
+	if err := decoder.Decode(&document); err != nil {
+		return nil, fmt.Errorf("разбор XML ленты: %w", err)
+	}
+	switch document.XMLName.Local {
+	case "rss", "RDF", "feed":
+	default:
+		return nil, fmt.Errorf("корневой элемент <%s> не относится к RSS или Atom", document.XMLName.Local)
+	}
+
+	var items []FeedItem
+	for _, entry := range append(document.Channel.Items, document.Items...) {
+		link := strings.TrimSpace(entry.Link)
+		if guid := strings.TrimSpace(entry.GUID); link == "" && strings.HasPrefix(guid, "http") {
+			link = guid
+		}
+		if link == "" {
+			continue
+		}
+		item := FeedItem{Title: feedText(entry.Title), Link: link, Published: parseFeedDate(entry.PubDate)}
+		if item.Published.IsZero() {
+			item.Published = parseFeedDate(entry.DCDate)
+		}
+		for _, category := range entry.Categories {
+			item.addCategory(category)
+		}
+		items = append(items, item)
+	}
+
+	for _, entry := range document.Entries {
+		var link string
+		for _, candidate := range entry.Links {
+			if candidate.Rel == "" || candidate.Rel == "alternate" {
+				link = strings.TrimSpace(candidate.Href)
+				break
+			}
+		}
+		if link == "" {
+			continue
+		}
+		item := FeedItem{Title: feedText(entry.Title), Link: link, Published: parseFeedDate(entry.Published)}
+		if item.Published.IsZero() {
+			item.Published = parseFeedDate(entry.Updated)
+		}
+		for _, category := range entry.Categories {
+			item.addCategory(cmp.Or(category.Label, category.Term))
+		}
+		items = append(items, item)
+	}
+	return items, nil
+}
+
+// addCategory добавляет рубрику записи без повторов
+func (f *FeedItem) addCategory(category string) {
+	category = feedText(category)
+	if category != "" && !slices.Contains(f.Categories, category) {
+		f.Categories = append(f.Categories, category)
+	}
+}
+
+// feedText схлопывает пробелы и переводы строк в тексте записи
+func feedText(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
+
+// GetFeedForClient загружает и разбирает RSS- или Atom-ленту по адресу feedURL
+func GetFeedForClient(ctx context.Context, client *http.Client, feedURL string) ([]FeedItem, error) {
+	raw, err := getXMLForClient(ctx, client, feedURL, "application/rss+xml, application/atom+xml, application/xml;q=0.9, text/xml;q=0.8, */*;q=0.5")
+	if err != nil {
+		return nil, err
+	}
+	items, err := ParseFeed(raw)
+	if err != nil {
+		return nil, fmt.Errorf("лента %s: %w", feedURL, err)
+	}
+	return items, nil
+}
+
+// maxXMLSize - предел размера ленты, карты сайта или robots.txt; протокол Sitemaps ограничивает карту 50 МБ без сжатия
+var maxXMLSize int64 = 50 << 20
+
+// readLimited читает r целиком, но не больше limit байт: больший ответ - ошибка, а не обрезанный документ
+func readLimited(r io.Reader, limit int64) ([]byte, error) {
+	raw, err := io.ReadAll(io.LimitReader(r, limit+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(raw)) > limit {
+		return nil, fmt.Errorf("размер больше предела %d байт", limit)
+	}
+	return raw, nil
+}
+
+// getXMLForClient загружает XML-документ (ленту, карту сайта) или robots.txt без разбора.
+// В отличие от GetHTMLForClient не повторяет запрос: такие документы читаются раз за запуск парсера.
+func getXMLForClient(ctx context.Context, client *http.Client, pageURL, accept string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", pageURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("создание HTTP GET-запроса для %s: %w", pageURL, err)
+	}
+	req.Header.Set("User-Agent", "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/91.0.4472.124 Safari/537.36")
+	req.Header.Set("Accept", accept)
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("выполнение HTTP GET-запроса к %s: %w", pageURL, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("HTTP-запрос к %s вернул статус %d (%s) вместо 200 (OK)", pageURL, resp.StatusCode, resp.Status)
+	}
+
+	raw, err := readLimited(resp.Body, maxXMLSize)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка чтения ответа с %s: %w", pageURL, err)
+	}
+	return raw, nil
+}
@@ -0,0 +1,152 @@
+package utils
+
+import (
+	"parsing_media/rudate"
+	"slices"
+	"testing"
+	"time"
+
+	"golang.org/x/text/encoding/charmap"
+)
+
+func TestParseFeedRSS(t *testing.T) {
+	items, err := ParseFeed([]byte(`<?xml version="1.0" encoding="UTF-8"?>
+<rss version="2.0" xmlns:dc="http://purl.org/dc/elements/1.1/">
+<channel>
+  <title>Лента</title>
+  <item>
+    <title>
+      Первая   новость
+    </title>
+    <link> https://site.ru/news/1?utm_source=rss </link>
+    <pubDate>Tue, 07 Oct 2025 10:15:00 +0300</pubDate>
+    <category>Политика</category>
+    <category> Политика </category>
+    <category>Экономика</category>
+  </item>
+  <item>
+    <title>Ссылка только в guid</title>
+    <guid isPermaLink="true">https://site.ru/news/2</guid>
+    <dc:date>2025-10-07T09:00:00+03:00</dc:date>
+  </item>
+  <item>
+    <title>Без ссылки</title>
+    <guid isPermaLink="false">12345</guid>
+  </item>
+</channel>
+</rss>`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(items) != 2 {
+		t.Fatalf("записей %d, want 2: %+v", len(items), items)
+	}
+
+	first := items[0]
+	if first.Title != "Первая новость" || first.Link != "https://site.ru/news/1?utm_source=rss" {
+		t.Errorf("первая запись = %q, %q", first.Title, first.Link)
+	}
+	if want := time.Date(2025, time.October, 7, 10, 15, 0, 0, rudate.Moscow); !first.Published.Equal(want) {
+		t.Errorf("pubDate = %v, want %v", first.Published, want)
+	}
+	if want := []string{"Политика", "Экономика"}; !slices.Equal(first.Categories, want) {
+		t.Errorf("рубрики = %q, want %q", first.Categories, want)
+	}
+
+	second := items[1]
+	if second.Link != "https://site.ru/news/2" {
+		t.Errorf("ссылка из guid = %q", second.Link)
+	}
+	if want := time.Date(2025, time.October, 7, 9, 0, 0, 0, rudate.Moscow); !second.Published.Equal(want) {
+		t.Errorf("dc:date = %v, want %v", second.Published, want)
+	}
+}
+
+func TestParseFeedAtom(t *testing.T) {
+	items, err := ParseFeed([]byte(`<?xml version="1.0" encoding="utf-8"?>
+<feed xmlns="http://www.w3.org/2005/Atom">
+  <entry>
+    <title>Запись Atom</title>
+    <link rel="self" href="https://site.ru/api/1"/>
+    <link rel="alternate" href="https://site.ru/news/1"/>
+    <updated>2025-10-07T12:00:00Z</updated>
+    <category term="society" label="Общество"/>
+    <category term="Спорт"/>
+  </entry>
+  <entry>
+    <title>Без ссылки на страницу</title>
+    <link rel="self" href="https://site.ru/api/2"/>
+  </entry>
+</feed>`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(items) != 1 {
+		t.Fatalf("записей %d, want 1: %+v", len(items), items)
+	}
+	item := items[0]
+	if item.Link != "https://site.ru/news/1" {
+		t.Errorf("ссылка = %q, want alternate", item.Link)
+	}
+	if want := time.Date(2025, time.October, 7, 12, 0, 0, 0, time.UTC); !item.Published.Equal(want) {
+		t.Errorf("updated = %v, want %v", item.Published, want)
+	}
+	if want := []string{"Общество", "Спорт"}; !slices.Equal(item.Categories, want) {
+		t.Errorf("рубрики = %q, want %q", item.Categories, want)
+	}
+}
+
+func TestParseFeedWindows1251(t *testing.T) {
+	raw, err := charmap.Windows1251.NewEncoder().Bytes([]byte(`<?xml version="1.0" encoding="windows-1251"?>
+<rss version="2.0"><channel><item>
+  <title>Новость в кодировке windows-1251</title>
+  <link>https://site.ru/news/1</link>
+  <category>Общество</category>
+</item></channel></rss>`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	items, err := ParseFeed(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(items) != 1 || items[0].Title != "Новость в кодировке windows-1251" || !slices.Equal(items[0].Categories, []string{"Общество"}) {
+		t.Errorf("ParseFeed = %+v", items)
+	}
+}
+
+func TestParseFeedErrors(t *testing.T) {
+	for name, raw := range map[string]string{
+		"не XML":       "<html><body>Страница",
+		"карта сайта":  `<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9"><url><loc>https://site.ru/</loc></url></urlset>`,
+		"пустой ответ": "",
+	} {
+		t.Run(name, func(t *testing.T) {
+			if items, err := ParseFeed([]byte(raw)); err == nil {
+				t.Errorf("ожидалась ошибка, получено %+v", items)
+			}
+		})
+	}
+}
+
+func TestParseFeedDate(t *testing.T) {
+	want := time.Date(2025, time.October, 7, 10, 0, 0, 0, rudate.Moscow)
+	for _, value := range []string{
+		"Tue, 07 Oct 2025 10:00:00 +0300",
+		"Tue, 07 Oct 2025 10:00:00 MSK",
+		"Tue, 7 Oct 2025 10:00:00 MSK",
+		"07 Oct 2025 10:00:00 MSK",
+		"Tue, 07 Oct 2025 07:00:00 GMT",
+		"Tue, 07 Oct 2025 07:00:00 UTC",
+		"07 Oct 25 10:00 +0300",
+		"2025-10-07T10:00:00+03:00",
+		" 2025-10-07T07:00:00Z ",
+	} {
+		if got := parseFeedDate(value); !got.Equal(want) {
+			t.Errorf("parseFeedDate(%q) = %v, want %v", value, got, want)
+		}
+	}
+	if got := parseFeedDate("вчера"); !got.IsZero() {
+		t.Errorf("parseFeedDate(вчера) = %v, want нулевое время", got)
+	}
+}
@@ -0,0 +1,120 @@
+// utils/readability.go
+package utils
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+	"golang.org/x/net/html"
+)
+
+const (
+	// readabilityMinParagraph - абзацы короче этого (в символах) не учитываются при оценке блоков
+	readabilityMinParagraph = 25
+	// readabilityMinText - если в лучшем блоке меньше текста, считается, что статья не найдена
+	readabilityMinText = 250
+	// readabilityMaxLinkDensity - абзацы, в которых ссылки занимают большую долю текста, отбрасываются
+	readabilityMaxLinkDensity = 0.5
+)
+
+var (
+	readabilityPositive = regexp.MustCompile(`(?i)article|body|content|entry|main|news|post|story|text|material|topic`)
+	readabilityNegative = regexp.MustCompile(`(?i)comment|footer|sidebar|aside|nav|menu|share|social|related|promo|banner|advert|subscribe|widget|tags|breadcrumb|popup|recommend|read-?more|also`)
+)
+
+// ExtractMainText находит основной текст статьи по плотности текста, не зная разметки сайта:
+// абзацы оцениваются по длине и числу запятых, оценка переходит к родительским блокам,
+// а лучший блок (с поправкой на классы и долю ссылок) отдаёт свои абзацы.
+// Возвращает абзацы через пустую строку или "", если подходящего блока нет. Документ не изменяется.
+func ExtractMainText(doc *goquery.Document) string {
+	root := doc.Find("body").First().Clone()
+	if root.Length() == 0 {
+		return ""
+	}
+	root.Find("script, style, noscript, iframe, form, nav, header, footer, aside, figure, figcaption, button, svg").Remove()
+
+	scores := make(map[*html.Node]float64)
+	root.Find("p, blockquote, pre").Each(func(_ int, p *goquery.Selection) {
+		text := cleanText(p.Text())
+		if len([]rune(text)) < readabilityMinParagraph {
+			return
+		}
+		score := 1 + float64(strings.Count(text, ",")) + min(float64(len([]rune(text)))/100, 3)
+
+		parent := p.Parent()
+		if parent.Length() == 0 {
+			return
+		}
+		scores[parent.Get(0)] += score
+		if grandparent := parent.Parent(); grandparent.Length() > 0 {
+			scores[grandparent.Get(0)] += score / 2
+		}
+	})
+
+	var best *goquery.Selection
+	var bestScore float64
+	for node, score := range scores {
+		block := goquery.NewDocumentFromNode(node).Selection
+		score = (score + classWeight(block)) * (1 - linkDensity(block))
+		if best == nil || score > bestScore {
+			best, bestScore = block, score
+		}
+	}
+	if best == nil {
+		return ""
+	}
+
+	var paragraphs []string
+	best.Find("p, blockquote, pre, h2, h3, li").Each(func(_ int, s *goquery.Selection) {
+		// Вложенные элементы уже вошли в текст своего абзаца
+		if s.ParentsUntilSelection(best).Filter("p, blockquote, pre, li").Length() > 0 {
+			return
+		}
+		text := cleanText(s.Text())
+		if text == "" || linkDensity(s) > readabilityMaxLinkDensity || readabilityNegative.MatchString(s.AttrOr("class", "")) {
+			return
+		}
+		paragraphs = append(paragraphs, text)
+	})
+
+	body := strings.Join(paragraphs, "\n\n")
+	if len([]rune(body)) < readabilityMinText {
+		return ""
+	}
+	return body
+}
+
+// classWeight повышает оценку блоков с "текстовыми" class/id и понижает служебные
+func classWeight(s *goquery.Selection) float64 {
+	var weight float64
+	for _, attr := range []string{"class", "id"} {
+		value := s.AttrOr(attr, "")
+		if value == "" {
+			continue
+		}
+		if readabilityNegative.MatchString(value) {
+			weight -= 25
+		}
+		if readabilityPositive.MatchString(value) {
+			weight += 25
+		}
+	}
+	if s.Is("article, main, [itemprop='articleBody']") {
+		weight += 25
+	}
+	return weight
+}
+
+// linkDensity - доля текста элемента, которая приходится на ссылки
+func linkDensity(s *goquery.Selection) float64 {
+	total := len([]rune(cleanText(s.Text())))
+	if total == 0 {
+		return 0
+	}
+	var links int
+	s.Find("a").Each(func(_ int, a *goquery.Selection) {
+		links += len([]rune(cleanText(a.Text())))
+	})
+	return min(float64(links)/float64(total), 1)
+}
@@ -0,0 +1,88 @@
+package utils
+
+import (
+	"strings"
+	"testing"
+)
+
+const (
+	readabilityFirst  = "Госдума приняла во втором чтении законопроект о новых правилах, по которым компании будут публиковать отчётность, сообщили в комитете."
+	readabilitySecond = "По словам авторов поправок, изменения затронут банки, страховщиков и крупные торговые сети, а вступят в силу с начала следующего года."
+	readabilityThird  = "Правительство, как отмечается в отзыве, поддержало инициативу, но предложило уточнить сроки, порядок и ответственность за нарушения."
+)
+
+func TestExtractMainText(t *testing.T) {
+	article := "<p>" + readabilityFirst + "</p><p>" + readabilitySecond + "</p><p>" + readabilityThird + "</p>"
+	full := readabilityFirst + "\n\n" + readabilitySecond + "\n\n" + readabilityThird
+	tests := []struct {
+		name string
+		html string
+		want string
+	}{
+		{
+			name: "статья среди служебных блоков",
+			html: `<header><p>Главная, новости, политика, экономика, общество, спорт, культура</p></header>
+				<div class="article-body">` + article + `</div>
+				<footer><p>Все права защищены, перепечатка запрещена, по вопросам рекламы пишите нам</p></footer>`,
+			want: full,
+		},
+		{
+			name: "абзацы из ссылок отбрасываются",
+			html: `<div class="content">` + article + `
+				<p><a href="/news/2">Читайте также: в Москве открыли новую станцию метро</a>, подробности</p>
+				<p class="share-block">Поделиться новостью в социальных сетях, мессенджерах и по почте с друзьями</p>
+			</div>`,
+			want: full,
+		},
+		{
+			name: "блок ссылок проигрывает тексту",
+			html: `<div class="text">` + article + `</div>
+				<div class="list">
+					<p><a href="/1">Первая новость дня, которую обсуждают все, кто следит за политикой</a></p>
+					<p><a href="/2">Вторая новость дня, которую обсуждают все, кто следит за экономикой</a></p>
+					<p><a href="/3">Третья новость дня, которую обсуждают все, кто следит за обществом</a></p>
+					<p><a href="/4">Четвёртая новость дня, которую обсуждают все, кто следит за спортом</a></p>
+				</div>`,
+			want: full,
+		},
+		{
+			name: "текста меньше порога",
+			html: `<div class="article-body"><p>` + readabilityFirst + `</p></div>`,
+		},
+		{
+			name: "абзацев нет",
+			html: `<div>Короткая подпись</div>`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc := parseHTML(t, "<html><body>"+tt.html+"</body></html>")
+			before, _ := doc.Html()
+			if got := ExtractMainText(doc); got != tt.want {
+				t.Errorf("ExtractMainText =\n%q\nwant\n%q", got, tt.want)
+			}
+			if after, _ := doc.Html(); after != before {
+				t.Error("ExtractMainText изменил документ")
+			}
+		})
+	}
+}
+
+func TestExtractMainTextMinText(t *testing.T) {
+	// Ровно readabilityMinText символов вместе с разделителем абзацев - ещё статья, на символ меньше - уже нет
+	first := strings.Repeat("а", 100) + ", " + strings.Repeat("б", 20)
+	second := strings.Repeat("в", readabilityMinText-len([]rune(first))-2)
+	for _, tt := range []struct {
+		second string
+		want   bool
+	}{
+		{second, true},
+		{second[:len(second)-len("в")], false},
+	} {
+		doc := parseHTML(t, "<html><body><article><p>"+first+"</p><p>"+tt.second+"</p></article></body></html>")
+		got := ExtractMainText(doc)
+		if length := len([]rune(first + "\n\n" + tt.second)); (got != "") != tt.want {
+			t.Errorf("текст длиной %d: ExtractMainText = %q, want найден: %v", length, got, tt.want)
+		}
+	}
+}
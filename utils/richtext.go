@@ -0,0 +1,411 @@
+// utils/richtext.go
+package utils
+
+import (
+	"html"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+	xhtml "golang.org/x/net/html"
+)
+
+// Форматы исходного текста статьи в Data.RichFormat
+const (
+	RichFormatMarkdown = "markdown"
+	RichFormatHTML     = "html"
+)
+
+// Правила разбора Markdown. Выделение подчёркиваниями срабатывает только на границе слова,
+// чтобы не портить слова вида snake_case.
+var (
+	mdHeading     = regexp.MustCompile(`^#{1,6}\s+(.*?)\s*#*\s*$`)
+	mdRule        = regexp.MustCompile(`^\s*([-*_])(\s*([-*_])){2,}\s*$`)
+	mdBullet      = regexp.MustCompile(`^(\s*)[-*+]\s+(.*)$`)
+	mdOrdered     = regexp.MustCompile(`^(\s*)(\d+)[.)]\s+(.*)$`)
+	mdQuote       = regexp.MustCompile(`^\s*>\s?(.*)$`)
+	mdFence       = regexp.MustCompile("^\\s*(```|~~~)")
+	mdHTMLBlock   = regexp.MustCompile(`^\s*<(p|div|ul|ol|blockquote|table|h[1-6]|figure|section)[\s>]`)
+	mdImage       = regexp.MustCompile(`!\[[^\]]*\]\([^)]*\)`)
+	mdLink        = regexp.MustCompile(`\[([^\]]*)\]\([^)]*\)`)
+	mdAutoLink    = regexp.MustCompile(`<((?:https?|mailto):[^>\s]+)>`)
+	mdCode        = regexp.MustCompile("`([^`]*)`")
+	mdStrong      = regexp.MustCompile(`\*\*(\S(?:.*?\S)?)\*\*`)
+	mdStrongUnder = regexp.MustCompile(`(^|[^\p{L}\p{N}_])__(\S(?:.*?\S)?)__($|[^\p{L}\p{N}_])`)
+	mdEm          = regexp.MustCompile(`\*(\S(?:.*?\S)?)\*`)
+	mdEmUnder     = regexp.MustCompile(`(^|[^\p{L}\p{N}_])_(\S(?:.*?\S)?)_($|[^\p{L}\p{N}_])`)
+	mdStrike      = regexp.MustCompile(`~~(\S(?:.*?\S)?)~~`)
+	mdEscape      = regexp.MustCompile("\\\\([\\\\`*_{}\\[\\]()#+\\-.!>~|])")
+	mdBreak       = regexp.MustCompile(`(?i)<br\s*/?>`)
+	mdTag         = regexp.MustCompile(`</?[a-zA-Z][^>]*>`)
+)
+
+// MarkdownToText переводит Markdown в обычный текст, сохраняя структуру: абзацы и заголовки
+// разделяются пустой строкой, пункты списков остаются отдельными строками с "- " или номером,
+// цитаты - с "> ". Разметка выделения и ссылок убирается, HTML-сущности декодируются.
+func MarkdownToText(md string) string {
+	lines := strings.Split(strings.ReplaceAll(md, "\r\n", "\n"), "\n")
+	var blocks []string
+	var paragraph, list, quote []string
+	flush := func() {
+		if len(paragraph) > 0 {
+			blocks = append(blocks, markdownInline(strings.Join(paragraph, " ")))
+			paragraph = nil
+		}
+		if len(list) > 0 {
+			blocks = append(blocks, strings.Join(list, "\n"))
+			list = nil
+		}
+		if len(quote) > 0 {
+			for _, block := range strings.Split(MarkdownToText(strings.Join(quote, "\n")), "\n\n") {
+				blocks = append(blocks, prefixLines(block, "> "))
+			}
+			quote = nil
+		}
+	}
+
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+		trimmed := strings.TrimSpace(line)
+		if m := mdQuote.FindStringSubmatch(line); m != nil {
+			if len(quote) == 0 {
+				flush()
+			}
+			quote = append(quote, m[1])
+			continue
+		}
+		if len(quote) > 0 && trimmed != "" && len(paragraph) == 0 {
+			// Ленивое продолжение цитаты
+			quote = append(quote, trimmed)
+			continue
+		}
+
+		switch {
+		case trimmed == "":
+			flush()
+		case mdFence.MatchString(line):
+			flush()
+			fence := mdFence.FindStringSubmatch(line)[1]
+			var code []string
+			for i++; i < len(lines) && !strings.HasPrefix(strings.TrimSpace(lines[i]), fence); i++ {
+				code = append(code, lines[i])
+			}
+			if text := strings.Trim(strings.Join(code, "\n"), "\n"); text != "" {
+				blocks = append(blocks, text)
+			}
+		case mdHTMLBlock.MatchString(line):
+			flush()
+			var raw []string
+			for ; i < len(lines) && strings.TrimSpace(lines[i]) != ""; i++ {
+				raw = append(raw, lines[i])
+			}
+			if doc, err := goquery.NewDocumentFromReader(strings.NewReader(strings.Join(raw, "\n"))); err == nil {
+				if text := HTMLToText(doc.Find("body")); text != "" {
+					blocks = append(blocks, text)
+				}
+			}
+		case mdRule.MatchString(line):
+			flush()
+		case mdHeading.MatchString(line):
+			flush()
+			if text := markdownInline(mdHeading.FindStringSubmatch(line)[1]); text != "" {
+				blocks = append(blocks, text)
+			}
+		case mdBullet.MatchString(line):
+			if len(paragraph) > 0 {
+				flush()
+			}
+			m := mdBullet.FindStringSubmatch(line)
+			list = append(list, listIndent(m[1])+"- "+markdownInline(m[2]))
+		case mdOrdered.MatchString(line):
+			if len(paragraph) > 0 {
+				flush()
+			}
+			m := mdOrdered.FindStringSubmatch(line)
+			list = append(list, listIndent(m[1])+m[2]+". "+markdownInline(m[3]))
+		case len(list) > 0 && (line[0] == ' ' || line[0] == '\t'):
+			// Продолжение пункта списка
+			list[len(list)-1] += " " + markdownInline(trimmed)
+		default:
+			if len(list) > 0 {
+				flush()
+			}
+			paragraph = append(paragraph, trimmed)
+		}
+	}
+	flush()
+
+	var nonEmpty []string
+	for _, block := range blocks {
+		if strings.TrimSpace(block) != "" {
+			nonEmpty = append(nonEmpty, block)
+		}
+	}
+	return strings.Join(nonEmpty, "\n\n")
+}
+
+// markdownInline убирает строчную разметку Markdown и HTML и декодирует сущности
+func markdownInline(s string) string {
+	// Экранированные символы прячутся, чтобы правила выделения их не трогали
+	var escaped []string
+	s = mdEscape.ReplaceAllStringFunc(s, func(m string) string {
+		escaped = append(escaped, m[1:])
+		return "\x00" + strconv.Itoa(len(escaped)-1) + "\x00"
+	})
+
+	s = mdImage.ReplaceAllString(s, "")
+	s = mdLink.ReplaceAllString(s, "$1")
+	s = mdAutoLink.ReplaceAllString(s, "$1")
+	s = mdCode.ReplaceAllString(s, "$1")
+	s = mdStrong.ReplaceAllString(s, "$1")
+	s = mdStrongUnder.ReplaceAllString(s, "$1$2$3")
+	s = mdEm.ReplaceAllString(s, "$1")
+	s = mdEmUnder.ReplaceAllString(s, "$1$2$3")
+	s = mdStrike.ReplaceAllString(s, "$1")
+	s = mdBreak.ReplaceAllString(s, " ")
+	s = mdTag.ReplaceAllString(s, "")
+
+	for i, value := range escaped {
+		s = strings.ReplaceAll(s, "\x00"+strconv.Itoa(i)+"\x00", value)
+	}
+	return cleanText(html.UnescapeString(s))
+}
+
+// listIndent переводит отступ вложенного пункта Markdown в два пробела на уровень
+func listIndent(indent string) string {
+	width := len(strings.ReplaceAll(indent, "\t", "    "))
+	return strings.Repeat("  ", width/2)
+}
+
+func prefixLines(block, prefix string) string {
+	lines := strings.Split(block, "\n")
+	for i, line := range lines {
+		lines[i] = prefix + line
+	}
+	return strings.Join(lines, "\n")
+}
+
+// htmlSkipped - элементы, текст которых не относится к статье
+var htmlSkipped = map[string]bool{
+	"script": true, "style": true, "noscript": true, "iframe": true, "svg": true,
+	"button": true, "form": true, "input": true, "select": true, "textarea": true, "template": true,
+}
+
+// htmlBlocks - элементы, которые начинают новый абзац
+var htmlBlocks = map[string]bool{
+	"p": true, "div": true, "section": true, "article": true, "main": true, "header": true, "footer": true,
+	"aside": true, "figure": true, "figcaption": true, "table": true, "tr": true, "dl": true, "dt": true, "dd": true,
+	"h1": true, "h2": true, "h3": true, "h4": true, "h5": true, "h6": true, "hr": true,
+}
+
+// HTMLToText переводит HTML выбранных элементов в обычный текст по тем же правилам, что MarkdownToText:
+// абзацы и заголовки через пустую строку, пункты списков с "- " или номером, цитаты с "> ".
+// Сущности декодируются, переводы строк внутри абзаца (кроме <br> и <pre>) схлопываются.
+func HTMLToText(sel *goquery.Selection) string {
+	w := &textWriter{}
+	for _, node := range sel.Nodes {
+		w.node(node)
+	}
+	w.flush()
+	return strings.Join(w.blocks, "\n\n")
+}
+
+var sourceBreaks = strings.NewReplacer("\r\n", " ", "\n", " ", "\r", " ")
+
+// textWriter собирает абзацы текста при обходе дерева HTML
+type textWriter struct {
+	blocks []string
+	inline strings.Builder
+}
+
+// flush завершает текущий абзац
+func (w *textWriter) flush() {
+	var lines []string
+	for _, line := range strings.Split(w.inline.String(), "\n") {
+		if line = cleanText(line); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	if len(lines) > 0 {
+		w.blocks = append(w.blocks, strings.Join(lines, "\n"))
+	}
+	w.inline.Reset()
+}
+
+func (w *textWriter) node(n *xhtml.Node) {
+	switch n.Type {
+	case xhtml.TextNode:
+		// Переводы строк в исходном HTML - форматирование разметки, абзац переносят только <br>
+		w.inline.WriteString(sourceBreaks.Replace(n.Data))
+		return
+	case xhtml.ElementNode:
+	case xhtml.DocumentNode:
+		w.children(n)
+		return
+	default:
+		return
+	}
+
+	tag := n.Data
+	switch {
+	case htmlSkipped[tag]:
+	case tag == "br":
+		w.inline.WriteString("\n")
+	case tag == "pre":
+		w.flush()
+		if text := strings.Trim(goquery.NewDocumentFromNode(n).Text(), "\n"); strings.TrimSpace(text) != "" {
+			w.blocks = append(w.blocks, text)
+		}
+	case tag == "ul" || tag == "ol":
+		w.flush()
+		if list := listText(n, 0); list != "" {
+			w.blocks = append(w.blocks, list)
+		}
+	case tag == "blockquote":
+		w.flush()
+		inner := &textWriter{}
+		inner.children(n)
+		inner.flush()
+		for _, block := range inner.blocks {
+			w.blocks = append(w.blocks, prefixLines(block, "> "))
+		}
+	case tag == "td" || tag == "th":
+		w.inline.WriteString(" ")
+		w.children(n)
+		w.inline.WriteString(" ")
+	case htmlBlocks[tag]:
+		w.flush()
+		w.children(n)
+		w.flush()
+	default:
+		w.children(n)
+	}
+}
+
+func (w *textWriter) children(n *xhtml.Node) {
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		w.node(c)
+	}
+}
+
+// listText выводит пункты списка n отдельными строками; вложенные списки сдвигаются на два пробела
+func listText(n *xhtml.Node, depth int) string {
+	var lines []string
+	number := 1
+	if start, err := strconv.Atoi(attr(n, "start")); err == nil {
+		number = start
+	}
+	for li := n.FirstChild; li != nil; li = li.NextSibling {
+		if li.Type != xhtml.ElementNode || li.Data != "li" {
+			continue
+		}
+		marker := "- "
+		if n.Data == "ol" {
+			marker = strconv.Itoa(number) + ". "
+			number++
+		}
+
+		item := &textWriter{}
+		var nested []string
+		for c := li.FirstChild; c != nil; c = c.NextSibling {
+			if c.Type == xhtml.ElementNode && (c.Data == "ul" || c.Data == "ol") {
+				if text := listText(c, depth+1); text != "" {
+					nested = append(nested, text)
+				}
+				continue
+			}
+			item.node(c)
+		}
+		item.flush()
+		text := strings.ReplaceAll(strings.Join(item.blocks, " "), "\n", " ")
+		if text != "" {
+			lines = append(lines, strings.Repeat("  ", depth)+marker+text)
+		}
+		lines = append(lines, nested...)
+	}
+	return strings.Join(lines, "\n")
+}
+
+func attr(n *xhtml.Node, name string) string {
+	for _, a := range n.Attr {
+		if a.Key == name {
+			return a.Val
+		}
+	}
+	return ""
+}
+
+// sanitizedTags - теги, которые остаются в санированном HTML, и разрешённые у них атрибуты
+var sanitizedTags = map[string][]string{
+	"p": nil, "br": nil, "h2": nil, "h3": nil, "h4": nil, "h5": nil, "h6": nil,
+	"ul": nil, "ol": {"start"}, "li": nil, "blockquote": nil, "pre": nil, "code": nil,
+	"strong": nil, "b": nil, "em": nil, "i": nil, "u": nil, "s": nil, "sub": nil, "sup": nil,
+	"a": {"href"}, "img": {"src", "alt"}, "figure": nil, "figcaption": nil,
+	"table": nil, "thead": nil, "tbody": nil, "tr": nil, "th": nil, "td": nil,
+}
+
+// SanitizeHTML возвращает HTML выбранных элементов, в котором остались только теги разметки текста
+// (абзацы, заголовки, списки, цитаты, выделение, ссылки, иллюстрации, таблицы) без классов, стилей и скриптов.
+// Прочие теги заменяются своим содержимым, ссылки допускаются только http(s) и относительные.
+func SanitizeHTML(sel *goquery.Selection) string {
+	var b strings.Builder
+	for _, node := range sel.Nodes {
+		for c := node.FirstChild; c != nil; c = c.NextSibling {
+			sanitizeNode(&b, c)
+		}
+	}
+	return strings.TrimSpace(b.String())
+}
+
+func sanitizeNode(b *strings.Builder, n *xhtml.Node) {
+	switch n.Type {
+	case xhtml.TextNode:
+		b.WriteString(html.EscapeString(n.Data))
+		return
+	case xhtml.ElementNode:
+	default:
+		return
+	}
+	if htmlSkipped[n.Data] {
+		return
+	}
+
+	tag := n.Data
+	if tag == "h1" {
+		tag = "h2"
+	}
+	allowed, ok := sanitizedTags[tag]
+	if !ok {
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			sanitizeNode(b, c)
+		}
+		return
+	}
+
+	b.WriteString("<" + tag)
+	for _, name := range allowed {
+		value := strings.TrimSpace(attr(n, name))
+		if value == "" || ((name == "href" || name == "src") && !safeURL(value)) {
+			continue
+		}
+		b.WriteString(" " + name + `="` + html.EscapeString(value) + `"`)
+	}
+	b.WriteString(">")
+	if tag == "br" || tag == "img" {
+		return
+	}
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		sanitizeNode(b, c)
+	}
+	b.WriteString("</" + tag + ">")
+}
+
+// safeURL отсекает javascript:, data: и прочие схемы, кроме http(s)
+func safeURL(value string) bool {
+	lower := strings.ToLower(value)
+	if strings.HasPrefix(lower, "http://") || strings.HasPrefix(lower, "https://") || strings.HasPrefix(lower, "//") {
+		return true
+	}
+	return !strings.Contains(strings.SplitN(lower, "/", 2)[0], ":")
+}
@@ -0,0 +1,77 @@
+package utils
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+func TestMarkdownToText(t *testing.T) {
+	tests := []struct {
+		name string
+		md   string
+		want string
+	}{
+		{
+			name: "заголовок и абзац со строчной разметкой",
+			md:   "# Заголовок #\n\nТекст с **жирным**, _курсивом_, `кодом` и [ссылкой](https://example.ru).\nПродолжение абзаца.",
+			want: "Заголовок\n\nТекст с жирным, курсивом, кодом и ссылкой. Продолжение абзаца.",
+		},
+		{
+			name: "маркированный, вложенный и нумерованный списки",
+			md:   "Список:\n\n- первый\n* второй\n  - вложенный\n    продолжение пункта\n\n1. раз\n2) два",
+			want: "Список:\n\n- первый\n- второй\n  - вложенный продолжение пункта\n\n1. раз\n2. два",
+		},
+		{
+			name: "цитата из двух абзацев",
+			md:   "> Первая строка\n> вторая строка\n>\n> Второй абзац **цитаты**\n\nПосле цитаты",
+			want: "> Первая строка вторая строка\n\n> Второй абзац цитаты\n\nПосле цитаты",
+		},
+		{
+			name: "сущности HTML и неразрывный пробел",
+			md:   "Цены &laquo;выросли&raquo; на 5&nbsp;% &amp; больше&hellip;",
+			want: "Цены «выросли» на 5 % & больше…",
+		},
+		{
+			name: "подчёркивания внутри слова и экранирование",
+			md:   "Поле snake_case_name и \\*звёздочки\\* остаются",
+			want: "Поле snake_case_name и *звёздочки* остаются",
+		},
+		{
+			name: "иллюстрация, разделитель и блок кода",
+			md:   "![фото](https://example.ru/a.jpg) Подпись\n\n---\n\n```go\nx := 1\n```",
+			want: "Подпись\n\nx := 1",
+		},
+		{
+			name: "встроенный HTML",
+			md:   "<p>Абзац<br>с переносом</p>\n\nТекст <b>жирный</b>",
+			want: "Абзац\nс переносом\n\nТекст жирный",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := MarkdownToText(tt.md); got != tt.want {
+				t.Errorf("MarkdownToText(%q)\n got: %q\nwant: %q", tt.md, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHTMLToText(t *testing.T) {
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(`<div id="body">
+		<h1>Заголовок</h1>
+		<p>Первый   абзац
+		на двух строках &mdash; с&nbsp;сущностями.</p>
+		<script>alert(1)</script>
+		<ol start="3"><li>три</li><li>четыре<ul><li>вложенный</li></ul></li></ol>
+		<blockquote><p>Цитата</p></blockquote>
+	</div>`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "Заголовок\n\nПервый абзац на двух строках — с сущностями.\n\n3. три\n4. четыре\n  - вложенный\n\n> Цитата"
+	if got := HTMLToText(doc.Find("#body")); got != want {
+		t.Errorf("HTMLToText\n got: %q\nwant: %q", got, want)
+	}
+}
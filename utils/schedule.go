@@ -0,0 +1,146 @@
+// utils/schedule.go
+package utils
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/robfig/cron/v3"
+)
+
+// Schedule определяет, когда парсер сайта запускается в следующий раз
+type Schedule interface {
+	// First - время первого запуска после старта планировщика
+	First(now time.Time) time.Time
+	// Next - время следующего запуска после запуска, начавшегося в lastStart
+	Next(lastStart time.Time) time.Time
+	String() string
+}
+
+// ParseSchedule разбирает расписание: интервал в формате Go ("1m", "30m")
+// или cron-выражение из пяти полей ("*/5 * * * *", а также "@hourly", "@every 10m").
+func ParseSchedule(spec string) (Schedule, error) {
+	spec = strings.TrimSpace(spec)
+	if spec == "" {
+		return nil, fmt.Errorf("пустое расписание")
+	}
+
+	if every, err := time.ParseDuration(spec); err == nil {
+		if every <= 0 {
+			return nil, fmt.Errorf("интервал расписания должен быть положительным: '%s'", spec)
+		}
+		return intervalSchedule{every: every}, nil
+	}
+
+	cronSchedule, err := cron.ParseStandard(spec)
+	if err != nil {
+		return nil, fmt.Errorf("расписание '%s' не является ни интервалом, ни cron-выражением: %w", spec, err)
+	}
+	return cronExprSchedule{spec: spec, schedule: cronSchedule}, nil
+}
+
+// intervalSchedule - запуск сразу после старта и далее каждые every от начала предыдущего запуска
+type intervalSchedule struct {
+	every time.Duration
+}
+
+func (s intervalSchedule) First(now time.Time) time.Time      { return now }
+func (s intervalSchedule) Next(lastStart time.Time) time.Time { return lastStart.Add(s.every) }
+func (s intervalSchedule) String() string                     { return "каждые " + s.every.String() }
+
+// cronExprSchedule - запуск в моменты, заданные cron-выражением (первый - в ближайший из них)
+type cronExprSchedule struct {
+	spec     string
+	schedule cron.Schedule
+}
+
+func (s cronExprSchedule) First(now time.Time) time.Time      { return s.schedule.Next(now) }
+func (s cronExprSchedule) Next(lastStart time.Time) time.Time { return s.schedule.Next(lastStart) }
+func (s cronExprSchedule) String() string                     { return "cron '" + s.spec + "'" }
+
+// AdaptiveConfig - границы и коэффициенты адаптивного интервала
+type AdaptiveConfig struct {
+	Enabled          bool          `yaml:"enabled"`
+	MinInterval      time.Duration `yaml:"min_interval"`
+	MaxInterval      time.Duration `yaml:"max_interval"`
+	SpeedupThreshold int           `yaml:"speedup_threshold"` // новых ссылок за запуск, при котором интервал сокращается вдвое
+	BackoffFactor    float64       `yaml:"backoff_factor"`    // во сколько раз увеличивать интервал, когда новых ссылок нет
+}
+
+// AdaptiveSchedule - интервальное расписание, которое подстраивается под частоту публикаций сайта.
+// После каждого запуска вызывающий код сообщает через Observe, сколько действительно новых ссылок нашлось.
+type AdaptiveSchedule struct {
+	mu       sync.Mutex
+	cfg      AdaptiveConfig
+	interval time.Duration
+	avgNew   float64 // скользящее среднее новых ссылок за запуск
+	observed bool
+	lastNew  int
+}
+
+// NewAdaptiveSchedule создаёт адаптивное расписание с начальным интервалом initial (в пределах границ cfg)
+func NewAdaptiveSchedule(initial time.Duration, cfg AdaptiveConfig) *AdaptiveSchedule {
+	s := &AdaptiveSchedule{cfg: cfg}
+	s.interval = s.clamp(initial)
+	return s
+}
+
+func (s *AdaptiveSchedule) First(now time.Time) time.Time { return now }
+
+func (s *AdaptiveSchedule) Next(lastStart time.Time) time.Time {
+	return lastStart.Add(s.Interval())
+}
+
+func (s *AdaptiveSchedule) String() string {
+	return "каждые " + s.Interval().String() + " (адаптивно)"
+}
+
+// Interval возвращает текущий интервал
+func (s *AdaptiveSchedule) Interval() time.Duration {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.interval
+}
+
+// Stats возвращает число новых ссылок последнего запуска и их скользящее среднее
+func (s *AdaptiveSchedule) Stats() (lastNew int, avgNew float64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.lastNew, s.avgNew
+}
+
+// Observe учитывает число новых ссылок очередного запуска и пересчитывает интервал:
+// всплеск (не меньше SpeedupThreshold) сокращает интервал вдвое, отсутствие новых ссылок в последних запусках
+// увеличивает его в BackoffFactor раз. Результат всегда остаётся в пределах [MinInterval, MaxInterval].
+func (s *AdaptiveSchedule) Observe(newLinks int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	const alpha = 0.5
+	if s.observed {
+		s.avgNew = alpha*float64(newLinks) + (1-alpha)*s.avgNew
+	} else {
+		s.avgNew = float64(newLinks)
+		s.observed = true
+	}
+	s.lastNew = newLinks
+
+	switch {
+	case s.cfg.SpeedupThreshold > 0 && newLinks >= s.cfg.SpeedupThreshold:
+		s.interval = s.clamp(s.interval / 2)
+	case s.avgNew < 1:
+		s.interval = s.clamp(time.Duration(float64(s.interval) * s.cfg.BackoffFactor))
+	}
+}
+
+func (s *AdaptiveSchedule) clamp(d time.Duration) time.Duration {
+	if s.cfg.MinInterval > 0 && d < s.cfg.MinInterval {
+		return s.cfg.MinInterval
+	}
+	if s.cfg.MaxInterval > 0 && d > s.cfg.MaxInterval {
+		return s.cfg.MaxInterval
+	}
+	return d
+}
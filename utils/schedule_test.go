@@ -0,0 +1,83 @@
+package utils
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseSchedule(t *testing.T) {
+	start := time.Date(2026, time.March, 10, 12, 7, 30, 0, time.UTC)
+	tests := []struct {
+		spec        string
+		first, next time.Time // next - после запуска в first
+	}{
+		{"1m", start, start.Add(time.Minute)},
+		{" 30m ", start, start.Add(30 * time.Minute)},
+		{"*/5 * * * *", start.Truncate(5 * time.Minute).Add(5 * time.Minute), start.Truncate(5 * time.Minute).Add(10 * time.Minute)},
+		{"@hourly", time.Date(2026, time.March, 10, 13, 0, 0, 0, time.UTC), time.Date(2026, time.March, 10, 14, 0, 0, 0, time.UTC)},
+		{"@every 10m", start.Add(10 * time.Minute), start.Add(20 * time.Minute)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			schedule, err := ParseSchedule(tt.spec)
+			if err != nil {
+				t.Fatal(err)
+			}
+			first := schedule.First(start)
+			if !first.Equal(tt.first) {
+				t.Errorf("First = %v, want %v", first, tt.first)
+			}
+			if next := schedule.Next(first); !next.Equal(tt.next) {
+				t.Errorf("Next = %v, want %v", next, tt.next)
+			}
+		})
+	}
+
+	for _, spec := range []string{"", "  ", "0s", "-5m", "5", "каждый час", "* * * *", "61 * * * *"} {
+		if schedule, err := ParseSchedule(spec); err == nil {
+			t.Errorf("ParseSchedule(%q) = %v, want ошибку", spec, schedule)
+		}
+	}
+}
+
+func TestAdaptiveSchedule(t *testing.T) {
+	cfg := AdaptiveConfig{Enabled: true, MinInterval: time.Minute, MaxInterval: 20 * time.Minute, SpeedupThreshold: 10, BackoffFactor: 2}
+	tests := []struct {
+		name     string
+		initial  time.Duration
+		observed []int
+		want     []time.Duration // интервал после каждого Observe
+	}{
+		{"всплеск сокращает вдвое", 8 * time.Minute, []int{10, 25, 3}, []time.Duration{4 * time.Minute, 2 * time.Minute, 2 * time.Minute}},
+		{"не быстрее MinInterval", 3 * time.Minute, []int{12, 12, 12}, []time.Duration{90 * time.Second, time.Minute, time.Minute}},
+		{"без новых ссылок - реже", 5 * time.Minute, []int{0, 0, 0}, []time.Duration{10 * time.Minute, 20 * time.Minute, 20 * time.Minute}},
+		// Среднее 4 -> 2 -> 1 -> 0.5: интервал растёт, только когда среднее падает ниже одной ссылки
+		{"по скользящему среднему", 5 * time.Minute, []int{4, 0, 0, 0}, []time.Duration{5 * time.Minute, 5 * time.Minute, 5 * time.Minute, 10 * time.Minute}},
+		{"начальный интервал в границах", time.Hour, nil, nil},
+		{"начальный интервал не меньше MinInterval", time.Second, nil, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewAdaptiveSchedule(tt.initial, cfg)
+			if got := s.Interval(); got < cfg.MinInterval || got > cfg.MaxInterval {
+				t.Errorf("начальный интервал %s вне [%s, %s]", got, cfg.MinInterval, cfg.MaxInterval)
+			}
+			for i, newLinks := range tt.observed {
+				s.Observe(newLinks)
+				if got := s.Interval(); got != tt.want[i] {
+					t.Errorf("после Observe(%d) #%d интервал %s, want %s", newLinks, i+1, got, tt.want[i])
+				}
+			}
+		})
+	}
+
+	s := NewAdaptiveSchedule(5*time.Minute, cfg)
+	start := time.Date(2026, time.March, 10, 12, 0, 0, 0, time.UTC)
+	s.Observe(20)
+	if next := s.Next(start); !next.Equal(start.Add(150 * time.Second)) {
+		t.Errorf("Next после всплеска = %v, want через 2m30s", next)
+	}
+	if lastNew, avgNew := s.Stats(); lastNew != 20 || avgNew != 20 {
+		t.Errorf("Stats = %d, %v; want 20, 20", lastNew, avgNew)
+	}
+}
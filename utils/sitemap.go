@@ -0,0 +1,147 @@
+// utils/sitemap.go
+package utils
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"golang.org/x/net/html/charset"
+)
+
+// SitemapEntry - адрес из карты сайта. У записей Google News заполнены Published, Title и Keywords,
+// у вложенных карт индекса - только Loc и LastMod.
+type SitemapEntry struct {
+	Loc       string
+	LastMod   time.Time
+	Published time.Time
+	Title     string
+	Keywords  []string
+}
+
+// Sitemap - разобранная карта сайта: адреса страниц (urlset) или вложенные карты (sitemapindex)
+type Sitemap struct {
+	URLs     []SitemapEntry
+	Sitemaps []SitemapEntry
+}
+
+type sitemapDocument struct {
+	XMLName xml.Name
+	URLs    []struct {
+		Loc     string `xml:"loc"`
+		LastMod string `xml:"lastmod"`
+		News    struct {
+			PublicationDate string `xml:"publication_date"`
+			Title           string `xml:"title"`
+			Keywords        string `xml:"keywords"`
+		} `xml:"news"`
+	} `xml:"url"`
+	Sitemaps []struct {
+		Loc     string `xml:"loc"`
+		LastMod string `xml:"lastmod"`
+	} `xml:"sitemap"`
+}
+
+// ParseSitemap разбирает карту сайта или индекс карт, в том числе сжатые gzip (.xml.gz).
+// Распакованная карта тоже ограничена maxXMLSize, чтобы небольшой архив не разворачивался в гигабайты.
+// Расширение Google News (news:news) даёт дату публикации, заголовок и ключевые слова.
+func ParseSitemap(raw []byte) (*Sitemap, error) {
+	if len(raw) > 2 && raw[0] == 0x1f && raw[1] == 0x8b {
+		unpacked, err := gzip.NewReader(bytes.NewReader(raw))
+		if err != nil {
+			return nil, fmt.Errorf("распаковка gzip: %w", err)
+		}
+		defer unpacked.Close()
+		if raw, err = readLimited(unpacked, maxXMLSize); err != nil {
+			return nil, fmt.Errorf("распаковка gzip: %w", err)
+		}
+	}
+
+	decoder := xml.NewDecoder(bytes.NewReader(raw))
+	decoder.CharsetReader = charset.NewReaderLabel
+	var document sitemapDocument
+	if err := decoder.Decode(&document); err != nil {
+		return nil, fmt.Errorf("разбор XML карты сайта: %w", err)
+	}
+	if name := document.XMLName.Local; name != "urlset" && name != "sitemapindex" {
+		return nil, fmt.Errorf("корневой элемент <%s> не относится к карте сайта", name)
+	}
+
+	sitemap := &Sitemap{}
+	for _, page := range document.URLs {
+		entry := SitemapEntry{
+			Loc:       strings.TrimSpace(page.Loc),
+			LastMod:   parseFeedDate(page.LastMod),
+			Published: parseFeedDate(page.News.PublicationDate),
+			Title:     feedText(page.News.Title),
+		}
+		for _, keyword := range strings.Split(page.News.Keywords, ",") {
+			if keyword = feedText(keyword); keyword != "" {
+				entry.Keywords = append(entry.Keywords, keyword)
+			}
+		}
+		if entry.Loc != "" {
+			sitemap.URLs = append(sitemap.URLs, entry)
+		}
+	}
+	for _, child := range document.Sitemaps {
+		if loc := strings.TrimSpace(child.Loc); loc != "" {
+			sitemap.Sitemaps = append(sitemap.Sitemaps, SitemapEntry{Loc: loc, LastMod: parseFeedDate(child.LastMod)})
+		}
+	}
+	return sitemap, nil
+}
+
+// GetSitemapForClient загружает и разбирает карту сайта по адресу sitemapURL
+func GetSitemapForClient(ctx context.Context, client *http.Client, sitemapURL string) (*Sitemap, error) {
+	raw, err := getXMLForClient(ctx, client, sitemapURL, "application/xml, text/xml;q=0.9, */*;q=0.5")
+	if err != nil {
+		return nil, err
+	}
+	sitemap, err := ParseSitemap(raw)
+	if err != nil {
+		return nil, fmt.Errorf("карта сайта %s: %w", sitemapURL, err)
+	}
+	return sitemap, nil
+}
+
+// RobotsSitemaps возвращает адреса карт сайта из директив Sitemap файла robots.txt
+func RobotsSitemaps(raw []byte) []string {
+	var sitemaps []string
+	scanner := bufio.NewScanner(bytes.NewReader(raw))
+	for scanner.Scan() {
+		line, _, _ := strings.Cut(scanner.Text(), "#")
+		name, value, found := strings.Cut(line, ":")
+		if found && strings.EqualFold(strings.TrimSpace(name), "sitemap") {
+			if value = strings.TrimSpace(value); value != "" {
+				sitemaps = append(sitemaps, value)
+			}
+		}
+	}
+	return sitemaps
+}
+
+// GetRobotsSitemapsForClient читает robots.txt в корне сайта siteURL и возвращает адреса карт сайта из него
+func GetRobotsSitemapsForClient(ctx context.Context, client *http.Client, siteURL string) ([]string, error) {
+	site, err := url.Parse(siteURL)
+	if err != nil || site.Host == "" {
+		return nil, fmt.Errorf("неверный адрес сайта '%s'", siteURL)
+	}
+	robotsURL := (&url.URL{Scheme: site.Scheme, Host: site.Host, Path: "/robots.txt"}).String()
+	raw, err := getXMLForClient(ctx, client, robotsURL, "text/plain, */*;q=0.5")
+	if err != nil {
+		return nil, err
+	}
+	sitemaps := RobotsSitemaps(raw)
+	if len(sitemaps) == 0 {
+		return nil, fmt.Errorf("в %s нет директив Sitemap", robotsURL)
+	}
+	return sitemaps, nil
+}
@@ -0,0 +1,202 @@
+package utils
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"parsing_media/rudate"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+const newsSitemap = `<?xml version="1.0" encoding="UTF-8"?>
+<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9"
+        xmlns:news="http://www.google.com/schemas/sitemap-news/0.9">
+  <url>
+    <loc> https://site.ru/news/1 </loc>
+    <news:news>
+      <news:publication>
+        <news:name>Сайт</news:name>
+        <news:language>ru</news:language>
+      </news:publication>
+      <news:publication_date>2025-10-07T10:15:00+03:00</news:publication_date>
+      <news:title>Новость  дня</news:title>
+      <news:keywords>Политика, Выборы, ,Госдума</news:keywords>
+    </news:news>
+  </url>
+  <url>
+    <loc>https://site.ru/about</loc>
+    <lastmod>2025-10-01</lastmod>
+  </url>
+  <url>
+    <loc></loc>
+  </url>
+</urlset>`
+
+// gzipBytes сжимает raw так же, как сайты отдают карты .xml.gz
+func gzipBytes(t *testing.T, raw []byte) []byte {
+	t.Helper()
+	var packed bytes.Buffer
+	writer := gzip.NewWriter(&packed)
+	if _, err := writer.Write(raw); err != nil {
+		t.Fatal(err)
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return packed.Bytes()
+}
+
+func checkNewsSitemap(t *testing.T, sitemap *Sitemap) {
+	t.Helper()
+	if len(sitemap.URLs) != 2 || len(sitemap.Sitemaps) != 0 {
+		t.Fatalf("адресов %d, вложенных карт %d; want 2, 0", len(sitemap.URLs), len(sitemap.Sitemaps))
+	}
+	news := sitemap.URLs[0]
+	if news.Loc != "https://site.ru/news/1" || news.Title != "Новость дня" {
+		t.Errorf("запись новости = %q, %q", news.Loc, news.Title)
+	}
+	if want := time.Date(2025, time.October, 7, 10, 15, 0, 0, rudate.Moscow); !news.Published.Equal(want) {
+		t.Errorf("дата публикации = %v, want %v", news.Published, want)
+	}
+	if want := []string{"Политика", "Выборы", "Госдума"}; !slices.Equal(news.Keywords, want) {
+		t.Errorf("ключевые слова = %q, want %q", news.Keywords, want)
+	}
+
+	page := sitemap.URLs[1]
+	if !page.Published.IsZero() || page.Keywords != nil {
+		t.Errorf("у страницы без news:news заполнены поля новости: %+v", page)
+	}
+	if want := time.Date(2025, time.October, 1, 0, 0, 0, 0, rudate.Moscow); !page.LastMod.Equal(want) {
+		t.Errorf("lastmod = %v, want %v", page.LastMod, want)
+	}
+}
+
+func TestParseSitemapNews(t *testing.T) {
+	sitemap, err := ParseSitemap([]byte(newsSitemap))
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkNewsSitemap(t, sitemap)
+}
+
+func TestParseSitemapGzip(t *testing.T) {
+	sitemap, err := ParseSitemap(gzipBytes(t, []byte(newsSitemap)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkNewsSitemap(t, sitemap)
+}
+
+func TestParseSitemapIndex(t *testing.T) {
+	sitemap, err := ParseSitemap([]byte(`<?xml version="1.0" encoding="UTF-8"?>
+<sitemapindex xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+  <sitemap><loc>https://site.ru/sitemap-news.xml</loc><lastmod>2025-10-07T10:00:00+03:00</lastmod></sitemap>
+  <sitemap><loc>https://site.ru/sitemap-2020.xml.gz</loc></sitemap>
+</sitemapindex>`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sitemap.URLs) != 0 || len(sitemap.Sitemaps) != 2 {
+		t.Fatalf("адресов %d, вложенных карт %d; want 0, 2", len(sitemap.URLs), len(sitemap.Sitemaps))
+	}
+	if sitemap.Sitemaps[0].Loc != "https://site.ru/sitemap-news.xml" || sitemap.Sitemaps[0].LastMod.IsZero() {
+		t.Errorf("первая карта = %+v", sitemap.Sitemaps[0])
+	}
+	if sitemap.Sitemaps[1].Loc != "https://site.ru/sitemap-2020.xml.gz" || !sitemap.Sitemaps[1].LastMod.IsZero() {
+		t.Errorf("вторая карта = %+v", sitemap.Sitemaps[1])
+	}
+}
+
+func TestParseSitemapErrors(t *testing.T) {
+	for name, raw := range map[string][]byte{
+		"лента":       []byte(`<rss version="2.0"><channel></channel></rss>`),
+		"не XML":      []byte("User-agent: *"),
+		"битый gzip":  {0x1f, 0x8b, 0x08, 0x00},
+		"пустой ввод": nil,
+	} {
+		t.Run(name, func(t *testing.T) {
+			if sitemap, err := ParseSitemap(raw); err == nil {
+				t.Errorf("ожидалась ошибка, получено %+v", sitemap)
+			}
+		})
+	}
+}
+
+func TestParseSitemapGzipLimit(t *testing.T) {
+	limit := maxXMLSize
+	maxXMLSize = 1024
+	t.Cleanup(func() { maxXMLSize = limit })
+
+	// Пробелы сжимаются в сотни раз: архив меньше предела, распакованная карта - больше
+	raw := []byte(`<urlset>` + strings.Repeat(" ", 64*1024) + `</urlset>`)
+	packed := gzipBytes(t, raw)
+	if int64(len(packed)) > maxXMLSize {
+		t.Fatalf("сжатая карта %d байт не меньше предела", len(packed))
+	}
+	if _, err := ParseSitemap(packed); err == nil {
+		t.Error("распакованная карта больше предела должна быть ошибкой")
+	}
+}
+
+func TestRobotsSitemaps(t *testing.T) {
+	got := RobotsSitemaps([]byte(`User-agent: *
+Disallow: /search # поиск
+sitemap: https://site.ru/sitemap-news.xml
+Sitemap:https://site.ru/sitemap.xml   # основная карта
+# Sitemap: https://site.ru/old.xml
+Sitemap:
+Host: site.ru
+`))
+	want := []string{"https://site.ru/sitemap-news.xml", "https://site.ru/sitemap.xml"}
+	if !slices.Equal(got, want) {
+		t.Errorf("RobotsSitemaps = %q, want %q", got, want)
+	}
+}
+
+func TestGetRobotsSitemapsForClient(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/robots.txt" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte("User-agent: *\nSitemap: https://site.ru/sitemap.xml\n"))
+	}))
+	defer server.Close()
+
+	// robots.txt читается из корня сайта, даже если адрес сайта указывает на раздел
+	got, err := GetRobotsSitemapsForClient(context.Background(), server.Client(), server.URL+"/news/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(got, []string{"https://site.ru/sitemap.xml"}) {
+		t.Errorf("карты из robots.txt = %q", got)
+	}
+}
+
+func TestGetXMLForClientLimit(t *testing.T) {
+	limit := maxXMLSize
+	maxXMLSize = 1024
+	t.Cleanup(func() { maxXMLSize = limit })
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		size := 1024
+		if r.URL.Path == "/large.xml" {
+			size++
+		}
+		w.Write(bytes.Repeat([]byte(" "), size))
+	}))
+	defer server.Close()
+
+	ctx := context.Background()
+	if raw, err := getXMLForClient(ctx, server.Client(), server.URL+"/exact.xml", "*/*"); err != nil || len(raw) != 1024 {
+		t.Errorf("ответ размером с предел: %d байт, ошибка %v", len(raw), err)
+	}
+	if _, err := getXMLForClient(ctx, server.Client(), server.URL+"/large.xml", "*/*"); err == nil {
+		t.Error("ответ больше предела должен быть ошибкой")
+	}
+}
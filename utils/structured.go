@@ -0,0 +1,272 @@
+// utils/structured.go
+package utils
+
+import (
+	"encoding/json"
+	"parsing_media/rudate"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// PageMetadata - сведения о статье из структурированной разметки страницы: JSON-LD, микроданных schema.org и OpenGraph
+type PageMetadata struct {
+	Headline    string
+	Description string
+	Section     string
+	Published   time.Time
+	Modified    time.Time
+	Authors     []string
+	Keywords    []string
+	Image       string
+}
+
+// ExtractMetadata собирает сведения о статье из разметки документа. Каждое поле берётся из первого источника,
+// где оно есть: JSON-LD (NewsArticle и другие статьи), затем микроданные, затем OpenGraph.
+func ExtractMetadata(doc *goquery.Document) PageMetadata {
+	var meta PageMetadata
+	doc.Find("script[type='application/ld+json']").Each(func(_ int, s *goquery.Selection) {
+		var raw any
+		if err := json.Unmarshal([]byte(s.Text()), &raw); err != nil {
+			return
+		}
+		for _, article := range jsonLDArticles(raw) {
+			meta.merge(jsonLDMetadata(article))
+		}
+	})
+	meta.merge(microdataMetadata(doc))
+	meta.merge(openGraphMetadata(doc))
+	return meta
+}
+
+// merge заполняет пустые поля значениями из other
+func (m *PageMetadata) merge(other PageMetadata) {
+	if m.Headline == "" {
+		m.Headline = other.Headline
+	}
+	if m.Description == "" {
+		m.Description = other.Description
+	}
+	if m.Section == "" {
+		m.Section = other.Section
+	}
+	if m.Published.IsZero() {
+		m.Published = other.Published
+	}
+	if m.Modified.IsZero() {
+		m.Modified = other.Modified
+	}
+	if len(m.Authors) == 0 {
+		m.Authors = other.Authors
+	}
+	if len(m.Keywords) == 0 {
+		m.Keywords = other.Keywords
+	}
+	if m.Image == "" {
+		m.Image = other.Image
+	}
+}
+
+// jsonLDArticles находит в JSON-LD объекты статей, в том числе внутри массивов и @graph
+func jsonLDArticles(raw any) []map[string]any {
+	switch v := raw.(type) {
+	case []any:
+		var articles []map[string]any
+		for _, item := range v {
+			articles = append(articles, jsonLDArticles(item)...)
+		}
+		return articles
+	case map[string]any:
+		articles := jsonLDArticles(v["@graph"])
+		if isArticleType(v["@type"]) {
+			articles = append([]map[string]any{v}, articles...)
+		}
+		return articles
+	}
+	return nil
+}
+
+// isArticleType сообщает, что @type (строка или список) описывает статью: NewsArticle, Article, BlogPosting и т. п.
+func isArticleType(value any) bool {
+	for _, t := range jsonLDStrings(value) {
+		if strings.HasSuffix(t, "Article") || t == "BlogPosting" {
+			return true
+		}
+	}
+	return false
+}
+
+func jsonLDMetadata(article map[string]any) PageMetadata {
+	meta := PageMetadata{
+		Headline:    cleanText(firstString(jsonLDStrings(article["headline"]))),
+		Description: cleanText(firstString(jsonLDStrings(article["description"]))),
+		Section:     cleanText(firstString(jsonLDStrings(article["articleSection"]))),
+		Published:   isoTime(firstString(jsonLDStrings(article["datePublished"]))),
+		Modified:    isoTime(firstString(jsonLDStrings(article["dateModified"]))),
+		Image:       firstString(jsonLDURLs(article["image"])),
+	}
+	for _, author := range jsonLDNames(article["author"]) {
+		meta.Authors = appendUnique(meta.Authors, author)
+	}
+	for _, keywords := range jsonLDStrings(article["keywords"]) {
+		for _, keyword := range strings.Split(keywords, ",") {
+			meta.Keywords = appendUnique(meta.Keywords, keyword)
+		}
+	}
+	return meta
+}
+
+// jsonLDStrings возвращает строковые значения поля: строку или строки из списка
+func jsonLDStrings(value any) []string {
+	switch v := value.(type) {
+	case string:
+		return []string{v}
+	case []any:
+		var result []string
+		for _, item := range v {
+			if s, ok := item.(string); ok {
+				result = append(result, s)
+			}
+		}
+		return result
+	}
+	return nil
+}
+
+// jsonLDNames возвращает имена из поля author: строки или объекты Person/Organization с name
+func jsonLDNames(value any) []string {
+	switch v := value.(type) {
+	case string:
+		return []string{v}
+	case map[string]any:
+		return jsonLDStrings(v["name"])
+	case []any:
+		var names []string
+		for _, item := range v {
+			names = append(names, jsonLDNames(item)...)
+		}
+		return names
+	}
+	return nil
+}
+
+// jsonLDURLs возвращает адреса из поля image: строки или объекты ImageObject с url
+func jsonLDURLs(value any) []string {
+	switch v := value.(type) {
+	case string:
+		return []string{v}
+	case map[string]any:
+		return jsonLDStrings(v["url"])
+	case []any:
+		var urls []string
+		for _, item := range v {
+			urls = append(urls, jsonLDURLs(item)...)
+		}
+		return urls
+	}
+	return nil
+}
+
+func microdataMetadata(doc *goquery.Document) PageMetadata {
+	meta := PageMetadata{
+		Headline:    cleanText(itemValue(doc.Find("[itemprop='headline']").First())),
+		Description: cleanText(itemValue(doc.Find("[itemprop='description']").First())),
+		Section:     cleanText(itemValue(doc.Find("[itemprop='articleSection']").First())),
+		Published:   isoTime(itemValue(doc.Find("[itemprop='datePublished']").First())),
+		Modified:    isoTime(itemValue(doc.Find("[itemprop='dateModified']").First())),
+	}
+	doc.Find("[itemprop='author']").Each(func(_ int, s *goquery.Selection) {
+		if name := s.Find("[itemprop='name']").First(); name.Length() > 0 {
+			s = name
+		}
+		meta.Authors = appendUnique(meta.Authors, itemValue(s))
+	})
+	doc.Find("[itemprop='keywords']").Each(func(_ int, s *goquery.Selection) {
+		for _, keyword := range strings.Split(itemValue(s), ",") {
+			meta.Keywords = appendUnique(meta.Keywords, keyword)
+		}
+	})
+	image := doc.Find("[itemprop='image']").First()
+	if url := image.Find("[itemprop='url']").First(); url.Length() > 0 {
+		image = url
+	}
+	meta.Image = itemURL(image)
+	return meta
+}
+
+// itemValue возвращает значение свойства микроданных: content, datetime или текст элемента
+func itemValue(s *goquery.Selection) string {
+	if s.Length() == 0 {
+		return ""
+	}
+	for _, attr := range []string{"content", "datetime"} {
+		if value, ok := s.Attr(attr); ok {
+			return strings.TrimSpace(value)
+		}
+	}
+	return strings.TrimSpace(s.Text())
+}
+
+// itemURL возвращает адрес из свойства микроданных: src, href или content
+func itemURL(s *goquery.Selection) string {
+	for _, attr := range []string{"src", "href", "content"} {
+		if value := strings.TrimSpace(s.AttrOr(attr, "")); value != "" {
+			return value
+		}
+	}
+	return ""
+}
+
+func openGraphMetadata(doc *goquery.Document) PageMetadata {
+	property := func(name string) string {
+		return strings.TrimSpace(doc.Find("meta[property='"+name+"']").First().AttrOr("content", ""))
+	}
+	meta := PageMetadata{
+		Headline:    cleanText(property("og:title")),
+		Description: cleanText(property("og:description")),
+		Section:     cleanText(property("article:section")),
+		Published:   isoTime(property("article:published_time")),
+		Modified:    isoTime(property("article:modified_time")),
+		Image:       property("og:image"),
+	}
+	doc.Find("meta[property='article:author']").Each(func(_ int, s *goquery.Selection) {
+		// article:author часто содержит ссылку на профиль, а не имя
+		if author := s.AttrOr("content", ""); !strings.Contains(author, "://") {
+			meta.Authors = appendUnique(meta.Authors, author)
+		}
+	})
+	doc.Find("meta[property='article:tag']").Each(func(_ int, s *goquery.Selection) {
+		meta.Keywords = appendUnique(meta.Keywords, s.AttrOr("content", ""))
+	})
+	return meta
+}
+
+// isoTime разбирает дату из разметки; нулевое время - дату разобрать не удалось
+func isoTime(value string) time.Time {
+	parsed, _ := rudate.ParseISO(value)
+	return parsed
+}
+
+// cleanText схлопывает пробелы и переводы строк в тексте из разметки
+func cleanText(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
+
+func firstString(values []string) string {
+	for _, value := range values {
+		if value = strings.TrimSpace(value); value != "" {
+			return value
+		}
+	}
+	return ""
+}
+
+// appendUnique добавляет очищенное непустое значение, если его ещё нет в списке
+func appendUnique(list []string, value string) []string {
+	if value = cleanText(value); value != "" && !slices.Contains(list, value) {
+		list = append(list, value)
+	}
+	return list
+}
@@ -0,0 +1,143 @@
+package utils
+
+import (
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// parseHTML разбирает HTML-фрагмент теста в документ
+func parseHTML(t *testing.T, raw string) *goquery.Document {
+	t.Helper()
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(raw))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return doc
+}
+
+func TestExtractMetadata(t *testing.T) {
+	published := time.Date(2025, time.October, 7, 10, 15, 0, 0, time.FixedZone("", 3*60*60))
+	tests := []struct {
+		name string
+		html string
+		want PageMetadata
+	}{
+		{
+			name: "JSON-LD @graph",
+			html: `<script type="application/ld+json">{"@context":"https://schema.org","@graph":[
+				{"@type":"WebSite","name":"Сайт"},
+				{"@type":"NewsArticle","headline":" Новость\n дня ","datePublished":"2025-10-07T10:15:00+03:00",
+				 "author":[{"@type":"Person","name":"Иван Петров"},"Анна Смирнова"],
+				 "keywords":"Политика, Госдума","image":{"@type":"ImageObject","url":"https://site.ru/1.jpg"}}]}</script>`,
+			want: PageMetadata{
+				Headline:  "Новость дня",
+				Published: published,
+				Authors:   []string{"Иван Петров", "Анна Смирнова"},
+				Keywords:  []string{"Политика", "Госдума"},
+				Image:     "https://site.ru/1.jpg",
+			},
+		},
+		{
+			name: "JSON-LD массив и несколько типов",
+			html: `<script type="application/ld+json">[
+				{"@type":"BreadcrumbList","name":"Крошки"},
+				{"@type":["Article","ReportageNewsArticle"],"headline":"Репортаж","articleSection":"Общество",
+				 "keywords":["Москва","Метро, Москва"],"image":["https://site.ru/2.jpg"]}]</script>`,
+			want: PageMetadata{
+				Headline: "Репортаж",
+				Section:  "Общество",
+				Keywords: []string{"Москва", "Метро"},
+				Image:    "https://site.ru/2.jpg",
+			},
+		},
+		{
+			name: "битый JSON-LD пропускается",
+			html: `<script type="application/ld+json">{"@type":"NewsArticle",</script>
+				<script type="application/ld+json">{"@type":"NewsArticle","headline":"Вторая разметка"}</script>`,
+			want: PageMetadata{Headline: "Вторая разметка"},
+		},
+		{
+			name: "микроданные",
+			html: `<article itemscope itemtype="https://schema.org/NewsArticle">
+				<h1 itemprop="headline">Заголовок из микроданных</h1>
+				<time itemprop="datePublished" datetime="2025-10-07T10:15:00+03:00">7 октября</time>
+				<span itemprop="author" itemscope itemtype="https://schema.org/Person"><span itemprop="name">Иван Петров</span></span>
+				<span itemprop="author">Анна Смирнова</span>
+				<span itemprop="author">Иван Петров</span>
+				<div itemprop="image" itemscope itemtype="https://schema.org/ImageObject">
+					<img src="https://site.ru/thumb.jpg"><meta itemprop="url" content="https://site.ru/3.jpg">
+				</div>
+				<meta itemprop="keywords" content="Экономика, Банки">
+			</article>`,
+			want: PageMetadata{
+				Headline:  "Заголовок из микроданных",
+				Published: published,
+				Authors:   []string{"Иван Петров", "Анна Смирнова"},
+				Keywords:  []string{"Экономика", "Банки"},
+				Image:     "https://site.ru/3.jpg",
+			},
+		},
+		{
+			name: "микроданные: картинка без ImageObject",
+			html: `<img itemprop="image" src="https://site.ru/4.jpg">`,
+			want: PageMetadata{Image: "https://site.ru/4.jpg"},
+		},
+		{
+			name: "OpenGraph: ссылка на профиль автора пропускается",
+			html: `<meta property="og:title" content="Заголовок OG">
+				<meta property="article:author" content="https://site.ru/authors/ivanov">
+				<meta property="article:author" content="Анна Смирнова">
+				<meta property="article:tag" content="Спорт"><meta property="article:tag" content="Футбол">
+				<meta property="article:published_time" content="2025-10-07T10:15:00+03:00">`,
+			want: PageMetadata{
+				Headline:  "Заголовок OG",
+				Published: published,
+				Authors:   []string{"Анна Смирнова"},
+				Keywords:  []string{"Спорт", "Футбол"},
+			},
+		},
+		{
+			name: "приоритет: JSON-LD, затем микроданные, затем OpenGraph",
+			html: `<meta property="og:title" content="Заголовок OG">
+				<meta property="og:description" content="Описание OG">
+				<meta property="og:image" content="https://site.ru/og.jpg">
+				<meta property="article:section" content="Раздел OG">
+				<script type="application/ld+json">{"@type":"NewsArticle","headline":"Заголовок JSON-LD"}</script>
+				<div itemscope itemtype="https://schema.org/NewsArticle">
+					<h1 itemprop="headline">Заголовок микроданных</h1>
+					<p itemprop="description">Описание микроданных</p>
+				</div>`,
+			want: PageMetadata{
+				Headline:    "Заголовок JSON-LD",
+				Description: "Описание микроданных",
+				Section:     "Раздел OG",
+				Image:       "https://site.ru/og.jpg",
+			},
+		},
+		{
+			name: "разметки нет",
+			html: `<p>Просто текст</p>`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ExtractMetadata(parseHTML(t, "<html><head></head><body>"+tt.html+"</body></html>"))
+			if got.Headline != tt.want.Headline || got.Description != tt.want.Description || got.Section != tt.want.Section || got.Image != tt.want.Image {
+				t.Errorf("ExtractMetadata = %+v, want %+v", got, tt.want)
+			}
+			if !got.Published.Equal(tt.want.Published) || !got.Modified.Equal(tt.want.Modified) {
+				t.Errorf("даты = %v, %v; want %v, %v", got.Published, got.Modified, tt.want.Published, tt.want.Modified)
+			}
+			if !slices.Equal(got.Authors, tt.want.Authors) {
+				t.Errorf("авторы = %q, want %q", got.Authors, tt.want.Authors)
+			}
+			if !slices.Equal(got.Keywords, tt.want.Keywords) {
+				t.Errorf("ключевые слова = %q, want %q", got.Keywords, tt.want.Keywords)
+			}
+		})
+	}
+}
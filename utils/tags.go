@@ -0,0 +1,127 @@
+// utils/tags.go
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/url"
+	"os"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// DefaultTaxonomyPath - файл соответствия тегов категориям, который читается, если путь не изменён в конфигурации
+const DefaultTaxonomyPath = "taxonomy.yaml"
+
+// NormalizeTag приводит тег к единому виду: нижний регистр, "ё" как "е", одиночные пробелы,
+// без "#" в начале и без кавычек и точек по краям
+func NormalizeTag(tag string) string {
+	tag = strings.Join(strings.Fields(tag), " ")
+	tag = strings.TrimLeft(tag, "#")
+	tag = strings.Trim(tag, " \"'«».,;:")
+	tag = strings.ToLower(tag)
+	return strings.ReplaceAll(tag, "ё", "е")
+}
+
+// NormalizeTags нормализует теги, отбрасывая пустые и повторы (порядок первых вхождений сохраняется)
+func NormalizeTags(tags []string) []string {
+	var normalized []string
+	for _, tag := range tags {
+		if tag = NormalizeTag(tag); tag != "" && !slices.Contains(normalized, tag) {
+			normalized = append(normalized, tag)
+		}
+	}
+	return normalized
+}
+
+// Taxonomy сопоставляет нормализованные теги сайтов общим категориям (politics, economy, sports, ...)
+type Taxonomy struct {
+	common map[string][]string            // тег -> категории
+	sites  map[string]map[string][]string // домен сайта -> тег -> категории
+}
+
+// taxonomyFile - формат файла соответствий: категория -> список тегов, отдельно для каждого сайта при необходимости
+type taxonomyFile struct {
+	Categories map[string][]string            `yaml:"categories"`
+	Sites      map[string]map[string][]string `yaml:"sites"`
+}
+
+// LoadTaxonomy читает файл соответствий path. Отсутствие файла DefaultTaxonomyPath не является ошибкой:
+// тогда теги только нормализуются, а категории не назначаются.
+func LoadTaxonomy(path string) (*Taxonomy, error) {
+	taxonomy := &Taxonomy{common: make(map[string][]string), sites: make(map[string]map[string][]string)}
+	if path == "" {
+		return taxonomy, nil
+	}
+
+	raw, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) && path == DefaultTaxonomyPath {
+		return taxonomy, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("чтение таксономии %s: %w", path, err)
+	}
+
+	var file taxonomyFile
+	if err := yaml.Unmarshal(raw, &file); err != nil {
+		return nil, fmt.Errorf("разбор таксономии %s: %w", path, err)
+	}
+	for category, tags := range file.Categories {
+		addMappings(taxonomy.common, category, tags)
+	}
+	for site, categories := range file.Sites {
+		host := siteHost(site)
+		if taxonomy.sites[host] == nil {
+			taxonomy.sites[host] = make(map[string][]string)
+		}
+		for category, tags := range categories {
+			addMappings(taxonomy.sites[host], category, tags)
+		}
+	}
+	return taxonomy, nil
+}
+
+func addMappings(mapping map[string][]string, category string, tags []string) {
+	category = strings.TrimSpace(category)
+	for _, tag := range tags {
+		if tag = NormalizeTag(tag); tag != "" && !slices.Contains(mapping[tag], category) {
+			mapping[tag] = append(mapping[tag], category)
+		}
+	}
+}
+
+// Categorize возвращает отсортированные категории статьи сайта site (адрес или домен) по её тегам.
+// Соответствие, заданное для сайта, заменяет общее соответствие того же тега.
+func (t *Taxonomy) Categorize(site string, tags []string) []string {
+	if t == nil {
+		return nil
+	}
+	siteMapping := t.sites[siteHost(site)]
+
+	var categories []string
+	for _, tag := range NormalizeTags(tags) {
+		mapped, ok := siteMapping[tag]
+		if !ok {
+			mapped = t.common[tag]
+		}
+		for _, category := range mapped {
+			if !slices.Contains(categories, category) {
+				categories = append(categories, category)
+			}
+		}
+	}
+	slices.Sort(categories)
+	return categories
+}
+
+// siteHost возвращает домен сайта без "www." из адреса или самого домена
+func siteHost(site string) string {
+	site = strings.ToLower(strings.TrimSpace(site))
+	if u, err := url.Parse(site); err == nil && u.Host != "" {
+		site = u.Hostname()
+	}
+	return strings.TrimPrefix(site, "www.")
+}
@@ -0,0 +1,77 @@
+package utils
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestNormalizeTag(t *testing.T) {
+	tests := map[string]string{
+		"Политика":            "политика",
+		"  Госдума\n  РФ ":    "госдума рф",
+		"#Ёлки":               "елки",
+		"«Спартак»":           "спартак",
+		"\"Газпром\".":        "газпром",
+		"ЧМ-2026":             "чм-2026",
+		" #, ":                "",
+		"Новости. Экономика;": "новости. экономика",
+	}
+	for tag, want := range tests {
+		if got := NormalizeTag(tag); got != want {
+			t.Errorf("NormalizeTag(%q) = %q, want %q", tag, got, want)
+		}
+	}
+
+	got := NormalizeTags([]string{"Выборы", "выборы", "", "#Выборы", "Госдума"})
+	if want := []string{"выборы", "госдума"}; !slices.Equal(got, want) {
+		t.Errorf("NormalizeTags = %q, want %q", got, want)
+	}
+}
+
+func TestTaxonomyCategorize(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "taxonomy.yaml")
+	err := os.WriteFile(path, []byte(`
+categories:
+  politics: [Политика, Госдума, Выборы]
+  economy: [Экономика, Банки]
+  sports: [Спорт]
+sites:
+  https://www.sport.ru:
+    sports: [Банки]
+`), 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	taxonomy, err := LoadTaxonomy(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		site string
+		tags []string
+		want []string
+	}{
+		{"общее соответствие", "https://news.ru", []string{"#ГОСДУМА", "Банки", "Выборы"}, []string{"economy", "politics"}},
+		{"соответствие сайта важнее общего", "https://sport.ru/football", []string{"банки"}, []string{"sports"}},
+		{"сайт по домену с www", "www.sport.ru", []string{"Банки", "Политика"}, []string{"politics", "sports"}},
+		{"тег без категории", "https://news.ru", []string{"Погода"}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := taxonomy.Categorize(tt.site, tt.tags); !slices.Equal(got, tt.want) {
+				t.Errorf("Categorize(%q, %q) = %q, want %q", tt.site, tt.tags, got, tt.want)
+			}
+		})
+	}
+
+	if got := (*Taxonomy)(nil).Categorize("https://news.ru", []string{"Политика"}); got != nil {
+		t.Errorf("Categorize без таксономии = %q, want nil", got)
+	}
+	if _, err := LoadTaxonomy(filepath.Join(t.TempDir(), "missing.yaml")); err == nil {
+		t.Error("отсутствующий файл, указанный явно, должен быть ошибкой")
+	}
+}
@@ -3,19 +3,18 @@ package utils
 
 import (
 	"bytes"
+	"context"
 	"crypto/sha256"
-	"database/sql"
 	"fmt"
 	"io"
 	"math/rand"
 	"net/http"
+	"net/url"
 	"strconv"
 	"strings"
 	"time"
 
 	"github.com/PuerkitoBio/goquery"
-	"github.com/lib/pq"
-	_ "github.com/lib/pq"
 	"golang.org/x/net/html/charset"
 	"golang.org/x/text/encoding"
 	"golang.org/x/text/encoding/charmap"
@@ -25,13 +24,28 @@ import (
 
 // Data определяет структуру для хранения данных о продукте
 type Data struct {
-	Hash  string
-	Site  string
-	Href  string
-	Title string
-	Body  string
-	Date  time.Time
-	Tags  []string
+	Hash      string
+	Site      string
+	Href      string
+	Title     string
+	Body      string
+	Date      time.Time
+	Tags      []string
+	FetchedAt time.Time // время загрузки страницы, в хеш не входит
+
+	// Необязательные поля: заполняются, если сайт их публикует, и в хеш не входят
+	Authors  []string
+	Lead     string    // вводный абзац (подзаголовок)
+	Rubric   string    // рубрика сайта
+	Image    string    // адрес главной иллюстрации
+	Modified time.Time // время последнего изменения по данным сайта
+
+	BodyFallback bool // текст не найден селекторами сайта и извлечён ExtractMainText, в хеш не входит
+
+	// Исходный текст статьи с разметкой (Markdown или санированный HTML), если включено parsers.keep_rich_body.
+	// В хеш не входит; Body - всегда обычный текст.
+	RichBody   string
+	RichFormat string // RichFormatMarkdown или RichFormatHTML
 }
 
 const (
@@ -47,137 +61,90 @@ const (
 	maxDelay   = 10 * time.Second
 )
 
-var RussianMonths = map[string]string{
-	// Нижний регистр (склонения)
-	"января": "01", "февраля": "02", "марта": "03", "апреля": "04", "мая": "05", "июня": "06",
-	"июля": "07", "августа": "08", "сентября": "09", "октября": "10", "ноября": "11", "декабря": "12",
-	// Нижний регистр (именительный падеж)
-	"январь": "01", "февраль": "02", "март": "03", "апрель": "04", "май": "05", "июнь": "06",
-	"июль": "07", "август": "08", "сентябрь": "09", "октябрь": "10", "ноябрь": "11", "декабрь": "12",
-
-	// Верхний регистр (склонения)
-	"ЯНВАРЯ": "01", "ФЕВРАЛЯ": "02", "МАРТА": "03", "АПРЕЛЯ": "04", "МАЯ": "05", "ИЮНЯ": "06",
-	"ИЮЛЯ": "07", "АВГУСТА": "08", "СЕНТЯБРЯ": "09", "ОКТЯБРЯ": "10", "НОЯБРЯ": "11", "ДЕКАБРЯ": "12",
-	// Верхний регистр (именительный падеж)
-	"ЯНВАРЬ": "01", "ФЕВРАЛЬ": "02", "МАРТ": "03", "АПРЕЛЬ": "04", "МАЙ": "05", "ИЮНЬ": "06",
-	"ИЮЛЬ": "07", "АВГУСТ": "08", "СЕНТЯБРЬ": "09", "ОКТЯБРЬ": "10", "НОЯБРЬ": "11", "ДЕКАБРЬ": "12",
-}
-
-// russianMonthsLife ДОЛЖЕН содержать английские НАЗВАНИЯ месяцев
-var RussianMonthsLife = map[string]string{
-	"января":   "January",
-	"февраля":  "February",
-	"марта":    "March",
-	"апреля":   "April",
-	"мая":      "May",
-	"июня":     "June",
-	"июля":     "July",
-	"августа":  "August",
-	"сентября": "September",
-	"октября":  "October",
-	"ноября":   "November",
-	"декабря":  "December",
-}
+// DefaultDSN (Data Source Name) - строка подключения по умолчанию, БЕЗ пароля (см. DatabaseConfig)
+const DefaultDSN = "user=postgres dbname=parsing_media_db host=localhost port=5432 sslmode=disable"
 
-// DbConn представляет пул соединений
-var DbConn *sql.DB
+func (d *Data) Hashing() (string, error) {
+	var builder strings.Builder
 
-// DSN (Data Source Name) - строка подключения БЕЗ пароля (текущие настройки)
-const DSN = "user=postgres dbname=parsing_media_db host=localhost port=5432 sslmode=disable"
+	builder.WriteString(d.Title)
+	builder.WriteString(d.Body)
+	builder.WriteString(strconv.FormatInt(d.Date.Unix(), 10))
 
-// Функция InitDB: Инициализирует соединение с базой данных
-func InitDB() error {
-	var err error
-	DbConn, err = sql.Open("postgres", DSN)
-	if err != nil {
-		return fmt.Errorf("ошибка открытия БД: %w", err)
-	}
+	hasher := sha256.New()
 
-	if err = DbConn.Ping(); err != nil {
-		return fmt.Errorf("ошибка проверки соединения с БД: %w", err)
+	if _, err := io.WriteString(hasher, builder.String()); err != nil {
+		return "", fmt.Errorf("ошибка записи данных в хешер: %w", err)
 	}
+	hashBytes := hasher.Sum(nil)
 
-	// Рекомендуемые настройки
-	DbConn.SetMaxOpenConns(25)
-	DbConn.SetMaxIdleConns(5)
-	DbConn.SetConnMaxLifetime(5 * time.Minute)
-
-	return nil
+	return fmt.Sprintf("%x", hashBytes), nil
 }
 
-// Функция SaveData: Сохраняет данные в БД
-func SaveData(products []Data) {
-	if DbConn == nil {
-		fmt.Printf("%s[DB] Соединение с БД не инициализировано.%s\n", ColorRed, ColorReset)
-		return
-	}
-
-	if len(products) == 0 {
-		return
+// sleepContext ждёт указанное время или отмены ctx. Возвращает ошибку ctx, если ожидание прервано.
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
 	}
+}
 
-	//fmt.Printf("%s[DB] Сохранение %d записей в БД...%s\n", ColorCyan, len(products), ColorReset)
-
-	// SQL: Вставка, которая игнорирует дубликаты по хешу (ON CONFLICT (hash) DO NOTHING)
-	sqlStatement := `
-    INSERT INTO articles (hash, site, href, title, body, date, tags)
-    VALUES ($1, $2, $3, $4, $5, $6, $7)
-    ON CONFLICT (hash) DO NOTHING;`
+// PageGoneError - страница удалена: сервер ответил 404/410 или перенаправил запрос статьи на главную страницу сайта
+type PageGoneError struct {
+	URL        string
+	StatusCode int    // 404 или 410; 0 при перенаправлении
+	Location   string // адрес, на который перенаправил сервер
+}
 
-	tx, err := DbConn.Begin()
-	if err != nil {
-		fmt.Printf("%s[DB][ERROR] Ошибка начала транзакции: %v%s\n", ColorRed, err, ColorReset)
-		return
+func (e *PageGoneError) Error() string {
+	if e.StatusCode != 0 {
+		return fmt.Sprintf("страница %s удалена: статус %d", e.URL, e.StatusCode)
 	}
+	return fmt.Sprintf("страница %s перенаправляет на главную %s", e.URL, e.Location)
+}
 
-	stmt, err := tx.Prepare(sqlStatement)
-	if err != nil {
-		fmt.Printf("%s[DB][ERROR] Ошибка подготовки запроса: %v%s\n", ColorRed, err, ColorReset)
-		tx.Rollback()
-		return
+// Reason - краткая причина удаления: "http 404", "http 410" или "redirect home"
+func (e *PageGoneError) Reason() string {
+	if e.StatusCode != 0 {
+		return "http " + strconv.Itoa(e.StatusCode)
 	}
-	defer stmt.Close()
+	return "redirect home"
+}
 
-	insertedCount := 0
-	for _, p := range products {
-		_, err = stmt.Exec(p.Hash, p.Site, p.Href, p.Title, p.Body, p.Date, pq.Array(p.Tags))
-		if err != nil {
-			// Если ошибка - дубликат по href, просто пропускаем
-			if !strings.Contains(err.Error(), "duplicate key value violates unique constraint") {
-				fmt.Printf("%s[DB][WARN] Ошибка вставки %s: %v%s\n", ColorYellow, LimitString(p.Title, 40), err, ColorReset)
-			}
-			continue
-		}
-		insertedCount++
+// redirectedToHome сообщает, что запрос страницы сайта закончился на его главной странице.
+// Перенаправление на корень другого домена (зеркало, партнёр, страница входа) удалением не считается; www. не учитывается.
+func redirectedToHome(requested string, final *url.URL) bool {
+	if final == nil {
+		return false
 	}
-
-	err = tx.Commit()
-	if err != nil {
-		fmt.Printf("%s[DB][ERROR] Ошибка фиксации транзакции: %v%s\n", ColorRed, err, ColorReset)
-		return
+	original, err := url.Parse(requested)
+	if err != nil || strings.Trim(original.Path, "/") == "" {
+		return false
 	}
-
-	//fmt.Printf("%s[DB] Успешно сохранено %d новых записей (из %d) в БД.%s\n", ColorGreen, insertedCount, len(products), ColorReset)
+	if siteHost(original.Hostname()) != siteHost(final.Hostname()) {
+		return false
+	}
+	return strings.Trim(final.Path, "/") == "" && final.RawQuery == ""
 }
 
-func (d *Data) Hashing() (string, error) {
-	var builder strings.Builder
-
-	builder.WriteString(d.Title)
-	builder.WriteString(d.Body)
-	builder.WriteString(strconv.FormatInt(d.Date.Unix(), 10))
-
-	hasher := sha256.New()
-
-	if _, err := io.WriteString(hasher, builder.String()); err != nil {
-		return "", fmt.Errorf("ошибка записи данных в хешер: %w", err)
-	}
-	hashBytes := hasher.Sum(nil)
+// GetHTMLForClient загружает страницу и разбирает её в документ, повторяя запрос при сетевых ошибках, 429 и 5xx.
+// На 404/410 возвращает PageGoneError, на остальные 4xx - ошибку без повторов.
+func GetHTMLForClient(ctx context.Context, client *http.Client, pageUrl string) (*goquery.Document, error) {
+	return getHTMLForClient(ctx, client, pageUrl, false)
+}
 
-	return fmt.Sprintf("%x", hashBytes), nil
+// GetArticleHTMLForClient загружает страницу статьи, как GetHTMLForClient, но перенаправление на главную сайта
+// тоже считает удалением статьи (PageGoneError). Для списков новостей и разделов такой ответ - не удаление,
+// поэтому проверка включена только для статей.
+func GetArticleHTMLForClient(ctx context.Context, client *http.Client, pageUrl string) (*goquery.Document, error) {
+	return getHTMLForClient(ctx, client, pageUrl, true)
 }
 
-func GetHTMLForClient(client *http.Client, pageUrl string) (*goquery.Document, error) {
+func getHTMLForClient(ctx context.Context, client *http.Client, pageUrl string, article bool) (*goquery.Document, error) {
 	var lastErr error
 
 	for attempt := 0; attempt < maxRetries; attempt++ {
@@ -192,11 +159,13 @@ func GetHTMLForClient(client *http.Client, pageUrl string) (*goquery.Document, e
 				currentDelay = maxDelay
 			}
 			jitter := time.Duration(rand.Intn(500)) * time.Millisecond
-			time.Sleep(currentDelay + jitter)
+			if err := sleepContext(ctx, currentDelay+jitter); err != nil {
+				return nil, fmt.Errorf("запрос к %s прерван: %w", pageUrl, err)
+			}
 			fmt.Printf("%s[UTILS]%s[RETRY] Попытка #%d для %s после ошибки: %v%s\n", ColorBlue, ColorYellow, attempt+1, LimitString(pageUrl, 70), lastErr, ColorReset)
 		}
 
-		req, err := http.NewRequest("GET", pageUrl, nil)
+		req, err := http.NewRequestWithContext(ctx, "GET", pageUrl, nil)
 		if err != nil {
 			lastErr = fmt.Errorf("создание HTTP GET-запроса для %s: %w", pageUrl, err)
 			continue
@@ -207,6 +176,9 @@ func GetHTMLForClient(client *http.Client, pageUrl string) (*goquery.Document, e
 
 		resp, err := client.Do(req)
 		if err != nil {
+			if ctx.Err() != nil {
+				return nil, fmt.Errorf("запрос к %s прерван: %w", pageUrl, ctx.Err())
+			}
 			lastErr = fmt.Errorf("выполнение HTTP GET-запроса к %s: %w", pageUrl, err)
 			continue
 		}
@@ -222,12 +194,18 @@ func GetHTMLForClient(client *http.Client, pageUrl string) (*goquery.Document, e
 		contentType := resp.Header.Get("Content-Type")
 		// fmt.Printf("%s[UTILS]%s[INFO] URL: %s, Status: %s, Content-Type: %s%s\n", ColorBlue, ColorYellow, LimitString(pageUrl, 70), resp.Status, contentType, ColorReset) // Убрано
 
+		// Удалённая статья: повторять запрос бессмысленно
+		if resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusGone {
+			return nil, &PageGoneError{URL: pageUrl, StatusCode: resp.StatusCode}
+		}
+		if article && redirectedToHome(pageUrl, resp.Request.URL) {
+			return nil, &PageGoneError{URL: pageUrl, Location: resp.Request.URL.String()}
+		}
+
 		if resp.StatusCode != http.StatusOK {
-			if resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusForbidden || (resp.StatusCode >= 400 && resp.StatusCode < 500 && resp.StatusCode != http.StatusTooManyRequests) {
-				bodyToClose = nil
-				if attempt == 3 {
-					return nil, fmt.Errorf("HTTP-запрос к %s вернул статус %d (%s) - не повторяем", pageUrl, resp.StatusCode, resp.Status)
-				}
+			// Ошибка клиента (403, 400...) не исправится повтором; 429 - ждём и повторяем
+			if resp.StatusCode >= 400 && resp.StatusCode < 500 && resp.StatusCode != http.StatusTooManyRequests {
+				return nil, fmt.Errorf("HTTP-запрос к %s вернул статус %d (%s) - не повторяем", pageUrl, resp.StatusCode, resp.Status)
 			}
 			lastErr = fmt.Errorf("HTTP-запрос к %s вернул статус %d (%s) вместо 200 (OK)", pageUrl, resp.StatusCode, resp.Status)
 			bodyToClose = nil
@@ -238,6 +216,9 @@ func GetHTMLForClient(client *http.Client, pageUrl string) (*goquery.Document, e
 		bodyBytes, err := io.ReadAll(bodyToClose)
 		bodyToClose = nil
 		if err != nil {
+			if ctx.Err() != nil {
+				return nil, fmt.Errorf("чтение ответа с %s прервано: %w", pageUrl, ctx.Err())
+			}
 			lastErr = fmt.Errorf("ошибка чтения тела ответа с %s: %w", pageUrl, err)
 			continue
 		}
@@ -296,8 +277,8 @@ func GetHTMLForClient(client *http.Client, pageUrl string) (*goquery.Document, e
 }
 
 // --- Остальные функции из utils.go ---
-func GetJSONForClient(client *http.Client, pageUrl string) ([]byte, error) {
-	req, err := http.NewRequest("GET", pageUrl, nil)
+func GetJSONForClient(ctx context.Context, client *http.Client, pageUrl string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", pageUrl, nil)
 	if err != nil {
 		return nil, fmt.Errorf("создание HTTP GET-запроса для JSON API %s: %w", pageUrl, err)
 	}
@@ -0,0 +1,125 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+func TestRedirectedToHome(t *testing.T) {
+	tests := []struct {
+		name      string
+		requested string
+		final     string
+		want      bool
+	}{
+		{"главная того же сайта", "https://site.ru/news/1", "https://site.ru/", true},
+		{"главная без слеша", "https://site.ru/news/1", "https://site.ru", true},
+		{"переход на www", "https://site.ru/news/1", "https://www.site.ru/", true},
+		{"переход с www", "https://www.site.ru/news/1", "http://site.ru/", true},
+		{"регистр домена", "https://Site.ru/news/1", "https://site.ru/", true},
+		{"главная другого сайта", "https://site.ru/news/1", "https://other.ru/", false},
+		{"главная поддомена", "https://site.ru/news/1", "https://m.site.ru/", false},
+		{"главная с параметрами", "https://site.ru/news/1", "https://site.ru/?from=news", false},
+		{"другая статья", "https://site.ru/news/1", "https://site.ru/news/2", false},
+		{"запрошена главная", "https://site.ru/", "https://site.ru/", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			final, err := url.Parse(tt.final)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := redirectedToHome(tt.requested, final); got != tt.want {
+				t.Errorf("redirectedToHome(%q, %q) = %v, want %v", tt.requested, tt.final, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetHTMLForClientRedirect(t *testing.T) {
+	page := "<html><body><p>Главная страница</p></body></html>"
+	other := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Write([]byte(page))
+	}))
+	defer other.Close()
+	// Тот же сервер под другим именем хоста: httptest слушает 127.0.0.1
+	otherURL := strings.Replace(other.URL, "127.0.0.1", "localhost", 1)
+
+	site := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/":
+			w.Header().Set("Content-Type", "text/html; charset=utf-8")
+			w.Write([]byte(page))
+		case "/news/deleted":
+			http.Redirect(w, r, "/", http.StatusFound)
+		case "/news/moved":
+			http.Redirect(w, r, otherURL+"/", http.StatusMovedPermanently)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer site.Close()
+
+	ctx := context.Background()
+	var gone *PageGoneError
+	if _, err := GetArticleHTMLForClient(ctx, site.Client(), site.URL+"/news/deleted"); !errors.As(err, &gone) || gone.Reason() != "redirect home" {
+		t.Errorf("перенаправление статьи на главную сайта: ошибка %v, want PageGoneError", err)
+	}
+	// Список новостей, перенаправленный на главную, не удалён: документ главной возвращается как есть
+	if _, err := GetHTMLForClient(ctx, site.Client(), site.URL+"/news/deleted"); err != nil {
+		t.Errorf("перенаправление списка на главную сайта: %v", err)
+	}
+
+	doc, err := GetArticleHTMLForClient(ctx, site.Client(), site.URL+"/news/moved")
+	if err != nil {
+		t.Fatalf("перенаправление на главную другого сайта: %v", err)
+	}
+	if got := strings.TrimSpace(doc.Find("p").Text()); got != "Главная страница" {
+		t.Errorf("текст страницы = %q", got)
+	}
+}
+
+func TestGetHTMLForClientStatus(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		switch r.URL.Path {
+		case "/forbidden":
+			w.WriteHeader(http.StatusForbidden)
+		case "/gone":
+			w.WriteHeader(http.StatusGone)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	ctx := context.Background()
+	tests := []struct {
+		path string
+		gone bool
+	}{
+		{"/forbidden", false},
+		{"/gone", true},
+		{"/news/1", true},
+	}
+	for _, tt := range tests {
+		requests.Store(0)
+		_, err := GetHTMLForClient(ctx, server.Client(), server.URL+tt.path)
+		var gone *PageGoneError
+		if err == nil || errors.As(err, &gone) != tt.gone {
+			t.Errorf("%s: ошибка %v, want PageGoneError: %v", tt.path, err, tt.gone)
+		}
+		// 4xx не исправится повтором запроса
+		if got := requests.Load(); got != 1 {
+			t.Errorf("%s: запросов %d, want 1", tt.path, got)
+		}
+	}
+}